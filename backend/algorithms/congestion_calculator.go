@@ -102,15 +102,24 @@ func (cc *CongestionCalculator) calculateRoadStatistics(roadID uint, gpsData []m
 
 // calculateCongestionLevel 计算拥堵等级
 func (cc *CongestionCalculator) calculateCongestionLevel(stats *RoadStatistics, road *models.RoadSegment) float64 {
-	if stats.AverageSpeed == 0 {
+	return CongestionScore(stats.AverageSpeed, float64(stats.VehicleCount), road, models.WeatherClear.Effect())
+}
+
+// CongestionScore 按平均车速 (km/h) 和车辆数计算路段拥堵评分 (0-1)，未设置通行能力的路段只按车速评价
+// 恶劣天气下车速降低、通行能力下降属于正常现象，按天气折减后的车速和通行能力评价
+func CongestionScore(averageSpeed, vehicleCount float64, road *models.RoadSegment, effect models.WeatherEffect) float64 {
+	if averageSpeed == 0 {
 		return 1.0 // 完全拥堵
 	}
 
 	// 速度比率
-	speedRatio := stats.AverageSpeed / float64(road.MaxSpeed)
+	speedRatio := averageSpeed / (float64(road.MaxSpeed) * effect.SpeedFactor)
 
 	// 密度比率
-	densityRatio := float64(stats.VehicleCount) / float64(road.Capacity)
+	densityRatio := 0.0
+	if road.Capacity > 0 {
+		densityRatio = vehicleCount / (float64(road.Capacity) * effect.CapacityFactor)
+	}
 
 	// 拥堵评分 (0-1之间)
 	congestionScore := (1-speedRatio)*0.7 + densityRatio*0.3
@@ -140,8 +149,11 @@ func (cc *CongestionCalculator) GetCongestionLevel(roadID uint) CongestionLevel
 		}
 	}
 
-	score := stats.CongestionLevel
+	return ClassifyCongestion(stats.CongestionLevel)
+}
 
+// ClassifyCongestion 按拥堵评分划分拥堵等级
+func ClassifyCongestion(score float64) CongestionLevel {
 	if score < 0.2 {
 		return CongestionLevel{
 			Level:       "free",
@@ -272,6 +284,30 @@ func (cc *CongestionCalculator) GetCongestionTrend(roadID uint, hours int) []flo
 	return trend
 }
 
+// RecentAverageSpeeds 统计最近一段时间内各路段的GPS平均车速 (km/h)，无数据的路段不在结果中
+func (cc *CongestionCalculator) RecentAverageSpeeds(window time.Duration) (map[uint]float64, error) {
+	gpsData, err := cc.gpsRepo.FindSince(time.Now().Add(-window))
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[uint]float64)
+	counts := make(map[uint]int)
+	for _, data := range gpsData {
+		if data.RoadSegment == nil {
+			continue
+		}
+		totals[data.RoadSegment.ID] += float64(data.Speed)
+		counts[data.RoadSegment.ID]++
+	}
+
+	speeds := make(map[uint]float64, len(totals))
+	for roadID, total := range totals {
+		speeds[roadID] = total / float64(counts[roadID])
+	}
+	return speeds, nil
+}
+
 // UpdateRoadStatistics 更新路段统计
 func (cc *CongestionCalculator) UpdateRoadStatistics(roadID uint) {
 	cc.CalculateCongestion(roadID)
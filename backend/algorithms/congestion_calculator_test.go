@@ -0,0 +1,37 @@
+package algorithms
+
+import (
+	"backend/models"
+	"testing"
+)
+
+// 同样的车速和车辆数，恶劣天气下按折减后的车速和通行能力评价，拥堵评分更低
+func TestCongestionScoreDiscountsWeather(t *testing.T) {
+	road := &models.RoadSegment{MaxSpeed: 60, Capacity: 100}
+	clear := CongestionScore(42, 50, road, models.WeatherClear.Effect())
+	snow := CongestionScore(42, 50, road, models.WeatherSnow.Effect())
+	if snow >= clear {
+		t.Fatalf("snow score %.3f, want below clear score %.3f", snow, clear)
+	}
+	// 雪天限速折减为42 km/h，车速未受影响，只按密度评价
+	if want := 50.0 / 75 * 0.3; snow < want-1e-9 || snow > want+1e-9 {
+		t.Fatalf("snow score = %.4f, want %.4f", snow, want)
+	}
+}
+
+func TestCongestionScoreBounds(t *testing.T) {
+	road := &models.RoadSegment{MaxSpeed: 60}
+	if score := CongestionScore(0, 10, road, models.WeatherClear.Effect()); score != 1 {
+		t.Fatalf("stopped traffic score = %v, want 1", score)
+	}
+	// 未设置通行能力时只按车速评价
+	if score := CongestionScore(30, 1000, road, models.WeatherClear.Effect()); score < 0.35-1e-9 || score > 0.35+1e-9 {
+		t.Fatalf("half speed score = %v, want 0.35", score)
+	}
+	if score := CongestionScore(90, 0, road, models.WeatherClear.Effect()); score != 0 {
+		t.Fatalf("free flow score = %v, want 0", score)
+	}
+	if level := ClassifyCongestion(0.1).Level; level != "free" {
+		t.Fatalf("level = %s, want free", level)
+	}
+}
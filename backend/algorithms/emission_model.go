@@ -0,0 +1,115 @@
+package algorithms
+
+import (
+	"backend/models"
+	"sort"
+)
+
+// DefaultEmissionClass 未指定排放类别的车型使用的类别
+const DefaultEmissionClass = "passenger_car"
+
+// 运行工况：制动、怠速，其余按比功率 (VSP, kW/t) 分区间
+// 参考 MOVES 的运行工况划分
+const (
+	modeBraking = iota
+	modeIdle
+	modeCount = 2 + len(vspBins) + 1
+)
+
+// vspBins 比功率区间上界 (kW/t)，依次对应 <0, 0-3, 3-6, 6-9, 9-12, 12-18, 18-24, 24-30，最后一档为30以上
+var vspBins = [...]float64{0, 3, 6, 9, 12, 18, 24, 30}
+
+const (
+	brakingDecel = -0.9 // 制动工况的加速度阈值 (m/s²)
+	idleSpeed    = 0.5  // 怠速工况的车速阈值 (m/s)
+)
+
+// EmissionClass 排放类别：比功率计算系数和各运行工况下的油耗、NOx排放速率
+// VSP = v·(MassFactor·a + Rolling) + Aero·v³，道路坡度按0计
+type EmissionClass struct {
+	Name       string
+	MassFactor float64            // 旋转质量系数
+	Rolling    float64            // 滚动阻力项 (m/s²)
+	Aero       float64            // 空气阻力项 (1/m)
+	CO2PerFuel float64            // 每克燃油燃烧生成的CO2 (g)，按碳平衡计算
+	Fuel       [modeCount]float64 // 油耗速率 (g/s)
+	NOx        [modeCount]float64 // NOx排放速率 (g/s)
+}
+
+// emissionClasses 内置排放类别，小汽车和摩托车为汽油车，其余为柴油车
+// 比功率系数取自 Jiménez-Palacios（轻型车）和 Zhai 等（公交车、货车）的文献值
+var emissionClasses = map[string]EmissionClass{
+	"passenger_car": {
+		Name: "passenger_car", MassFactor: 1.1, Rolling: 0.132, Aero: 0.000302, CO2PerFuel: 3.09,
+		Fuel: [modeCount]float64{0.12, 0.22, 0.30, 0.55, 0.85, 1.15, 1.45, 1.85, 2.45, 3.05, 3.80},
+		NOx:  [modeCount]float64{0.0001, 0.0002, 0.0003, 0.0008, 0.0015, 0.0023, 0.0032, 0.0045, 0.0065, 0.0085, 0.0115},
+	},
+	"motorcycle": {
+		Name: "motorcycle", MassFactor: 1.1, Rolling: 0.132, Aero: 0.000302, CO2PerFuel: 3.09,
+		Fuel: [modeCount]float64{0.05, 0.08, 0.10, 0.18, 0.28, 0.38, 0.48, 0.62, 0.80, 1.00, 1.25},
+		NOx:  [modeCount]float64{0.00005, 0.0001, 0.00015, 0.0004, 0.0007, 0.0011, 0.0015, 0.0020, 0.0028, 0.0036, 0.0045},
+	},
+	"light_truck": {
+		Name: "light_truck", MassFactor: 1.1, Rolling: 0.132, Aero: 0.000302, CO2PerFuel: 3.16,
+		Fuel: [modeCount]float64{0.18, 0.35, 0.45, 0.85, 1.30, 1.75, 2.20, 2.80, 3.60, 4.40, 5.40},
+		NOx:  [modeCount]float64{0.002, 0.004, 0.006, 0.012, 0.019, 0.026, 0.033, 0.043, 0.056, 0.070, 0.088},
+	},
+	"heavy_truck": {
+		Name: "heavy_truck", MassFactor: 1.0, Rolling: 0.0976, Aero: 0.000134, CO2PerFuel: 3.16,
+		Fuel: [modeCount]float64{0.35, 0.65, 0.90, 2.40, 3.60, 4.80, 6.00, 7.60, 9.60, 11.6, 14.0},
+		NOx:  [modeCount]float64{0.008, 0.015, 0.020, 0.045, 0.065, 0.085, 0.105, 0.130, 0.165, 0.200, 0.240},
+	},
+	"diesel_bus": {
+		Name: "diesel_bus", MassFactor: 1.0, Rolling: 0.09199, Aero: 0.000169, CO2PerFuel: 3.16,
+		Fuel: [modeCount]float64{0.30, 0.55, 0.80, 2.00, 3.00, 4.00, 5.00, 6.40, 8.20, 10.0, 12.0},
+		NOx:  [modeCount]float64{0.007, 0.013, 0.018, 0.040, 0.058, 0.076, 0.094, 0.118, 0.150, 0.180, 0.215},
+	},
+}
+
+// LookupEmissionClass 按名称获取排放类别
+func LookupEmissionClass(name string) (EmissionClass, bool) {
+	class, ok := emissionClasses[name]
+	return class, ok
+}
+
+// EmissionClassNames 全部内置排放类别名称，按字母排序
+func EmissionClassNames() []string {
+	names := make([]string, 0, len(emissionClasses))
+	for name := range emissionClasses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// VSP 比功率 (kW/t)，speed 单位 m/s，accel 单位 m/s²
+func (c *EmissionClass) VSP(speed, accel float64) float64 {
+	return speed*(c.MassFactor*accel+c.Rolling) + c.Aero*speed*speed*speed
+}
+
+// OperatingMode 按车速和加速度确定运行工况
+func (c *EmissionClass) OperatingMode(speed, accel float64) int {
+	if accel <= brakingDecel && speed >= idleSpeed {
+		return modeBraking
+	}
+	if speed < idleSpeed {
+		return modeIdle
+	}
+	vsp := c.VSP(speed, accel)
+	for i, upper := range vspBins {
+		if vsp < upper {
+			return 2 + i
+		}
+	}
+	return modeCount - 1
+}
+
+// Rates 当前工况下的油耗和排放速率 (g/s)
+func (c *EmissionClass) Rates(speed, accel float64) models.Emissions {
+	mode := c.OperatingMode(speed, accel)
+	return models.Emissions{
+		Fuel: c.Fuel[mode],
+		CO2:  c.Fuel[mode] * c.CO2PerFuel,
+		NOx:  c.NOx[mode],
+	}
+}
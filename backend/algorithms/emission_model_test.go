@@ -0,0 +1,59 @@
+package algorithms
+
+import "testing"
+
+// 比功率按车速、加速度和空气阻力计算，运行工况按制动、怠速和比功率区间划分
+func TestEmissionClassOperatingMode(t *testing.T) {
+	class, _ := LookupEmissionClass(DefaultEmissionClass)
+	if vsp, want := class.VSP(20, 0.5), 20*(1.1*0.5+0.132)+0.000302*8000; vsp < want-1e-9 || vsp > want+1e-9 {
+		t.Fatalf("vsp = %v, want %v", vsp, want)
+	}
+
+	tests := []struct {
+		speed, accel float64
+		want         int
+	}{
+		{0, 0, modeIdle},
+		{0.3, -2, modeIdle}, // 低于怠速车速时不计为制动
+		{10, -1, modeBraking},
+		{10, -0.5, 2}, // VSP < 0
+		{10, 0, 3},    // VSP 约1.6
+		{15, 0.5, 6},  // VSP 约11.2
+		{30, 1, modeCount - 1},
+	}
+	for _, tt := range tests {
+		if got := class.OperatingMode(tt.speed, tt.accel); got != tt.want {
+			t.Fatalf("mode(%v, %v) = %d (vsp %.1f), want %d", tt.speed, tt.accel, got, class.VSP(tt.speed, tt.accel), tt.want)
+		}
+	}
+}
+
+// 各排放类别的油耗和NOx速率随比功率单调不减，CO2按碳平衡由油耗换算
+func TestEmissionClassRates(t *testing.T) {
+	names := EmissionClassNames()
+	if len(names) != 5 || names[0] != "diesel_bus" || names[len(names)-1] != "passenger_car" {
+		t.Fatalf("class names = %v", names)
+	}
+	for _, name := range names {
+		class, _ := LookupEmissionClass(name)
+		for mode := modeIdle + 1; mode < modeCount; mode++ {
+			if class.Fuel[mode] < class.Fuel[mode-1] || class.NOx[mode] < class.NOx[mode-1] {
+				t.Fatalf("%s: rates decrease at mode %d", name, mode)
+			}
+		}
+		mode := class.OperatingMode(15, 0.5)
+		rates := class.Rates(15, 0.5)
+		if rates.Fuel != class.Fuel[mode] || rates.CO2 != rates.Fuel*class.CO2PerFuel || rates.NOx != class.NOx[mode] {
+			t.Fatalf("%s: rates = %+v", name, rates)
+		}
+	}
+
+	car, _ := LookupEmissionClass("passenger_car")
+	truck, _ := LookupEmissionClass("heavy_truck")
+	if car.Rates(15, 0.5).CO2 >= truck.Rates(15, 0.5).CO2 {
+		t.Fatal("heavy truck emits less CO2 than a passenger car")
+	}
+	if _, ok := LookupEmissionClass("tram"); ok {
+		t.Fatal("found an unknown class")
+	}
+}
@@ -0,0 +1,87 @@
+package algorithms
+
+import "time"
+
+// FlowIntervalLength 流量统计区间长度
+const FlowIntervalLength = 15 * time.Minute
+
+// FlowInterval 15分钟区间流量
+type FlowInterval struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+}
+
+// PeakHour 高峰小时
+type PeakHour struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Volume int       `json:"volume"` // 高峰小时流量
+	Factor float64   `json:"factor"` // 高峰小时系数：小时流量与最大15分钟流量×4之比
+}
+
+// Label 高峰小时的 "HH:MM-HH:MM" 表示
+func (p PeakHour) Label() string {
+	return p.Start.Format("15:04") + "-" + p.End.Format("15:04")
+}
+
+// FindPeakHour 在连续的15分钟区间中查找流量之和最大的连续4个区间，流量全为0时返回false
+// 区间不足4个时按全部区间计算
+func FindPeakHour(intervals []FlowInterval) (PeakHour, bool) {
+	window := min(4, len(intervals))
+	best, bestStart := 0, -1
+	volume := 0
+	for i, interval := range intervals {
+		volume += interval.Count
+		if i >= window {
+			volume -= intervals[i-window].Count
+		}
+		if i >= window-1 && volume > best {
+			best, bestStart = volume, i-window+1
+		}
+	}
+	if bestStart < 0 {
+		return PeakHour{}, false
+	}
+
+	peak := PeakHour{
+		Start:  intervals[bestStart].Start,
+		End:    intervals[bestStart].Start.Add(time.Hour),
+		Volume: best,
+	}
+	maxCount := 0
+	for _, interval := range intervals[bestStart : bestStart+window] {
+		maxCount = max(maxCount, interval.Count)
+	}
+	peak.Factor = float64(best) / float64(4*maxCount)
+	return peak, true
+}
+
+// BinFlow 按15分钟区间统计 [start, end) 内的流量，keys 为每条记录的去重键（如车辆ID），同一区间内重复的键只计一次
+// 返回的区间连续，无记录的区间流量为0
+func BinFlow(start, end time.Time, times []time.Time, keys []string) []FlowInterval {
+	start = start.Truncate(FlowIntervalLength)
+	if !end.After(start) {
+		return nil
+	}
+
+	count := int((end.Sub(start) + FlowIntervalLength - 1) / FlowIntervalLength)
+	intervals := make([]FlowInterval, count)
+	seen := make([]map[string]bool, count)
+	for i := range intervals {
+		intervals[i].Start = start.Add(time.Duration(i) * FlowIntervalLength)
+		seen[i] = make(map[string]bool)
+	}
+
+	for i, t := range times {
+		if t.Before(start) || !t.Before(end) {
+			continue
+		}
+		index := int(t.Sub(start) / FlowIntervalLength)
+		if seen[index][keys[i]] {
+			continue
+		}
+		seen[index][keys[i]] = true
+		intervals[index].Count++
+	}
+	return intervals
+}
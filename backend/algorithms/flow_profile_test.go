@@ -0,0 +1,66 @@
+package algorithms
+
+import (
+	"testing"
+	"time"
+)
+
+// counts 从 07:00 起连续的15分钟区间流量
+func counts(values ...int) []FlowInterval {
+	start := time.Date(2024, 5, 1, 7, 0, 0, 0, time.UTC)
+	intervals := make([]FlowInterval, len(values))
+	for i, count := range values {
+		intervals[i] = FlowInterval{Start: start.Add(time.Duration(i) * FlowIntervalLength), Count: count}
+	}
+	return intervals
+}
+
+func TestFindPeakHour(t *testing.T) {
+	tests := []struct {
+		intervals []FlowInterval
+		label     string
+		volume    int
+		factor    float64
+	}{
+		{counts(10, 20, 30, 40, 50, 10), "07:15-08:15", 140, 140.0 / 200},
+		// 流量相同的窗口取最早的
+		{counts(25, 25, 25, 25, 25), "07:00-08:00", 100, 1},
+		// 不足4个区间时按全部区间计算
+		{counts(30, 10), "07:00-08:00", 40, 40.0 / 120},
+	}
+	for _, tt := range tests {
+		peak, ok := FindPeakHour(tt.intervals)
+		if !ok || peak.Label() != tt.label || peak.Volume != tt.volume || peak.Factor != tt.factor {
+			t.Fatalf("peak = %+v (%s), want %s volume %d factor %.3f", peak, peak.Label(), tt.label, tt.volume, tt.factor)
+		}
+	}
+
+	for _, intervals := range [][]FlowInterval{nil, counts(0, 0, 0, 0, 0)} {
+		if _, ok := FindPeakHour(intervals); ok {
+			t.Fatalf("found a peak hour in %+v", intervals)
+		}
+	}
+}
+
+// 同一区间内重复的键只计一次，范围外的记录忽略，起点对齐到15分钟
+func TestBinFlow(t *testing.T) {
+	base := time.Date(2024, 5, 1, 7, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+	times := []time.Time{at(-5), at(1), at(2), at(3), at(16), at(20), at(44), at(45)}
+	keys := []string{"A", "A", "A", "B", "A", "B", "C", "C"}
+
+	intervals := BinFlow(at(7), at(45), times, keys)
+	want := []int{2, 2, 1}
+	if len(intervals) != len(want) {
+		t.Fatalf("got %d intervals, want %d", len(intervals), len(want))
+	}
+	for i, interval := range intervals {
+		if !interval.Start.Equal(at(15*i)) || interval.Count != want[i] {
+			t.Fatalf("interval %d = %+v, want start %v count %d", i, interval, at(15*i), want[i])
+		}
+	}
+
+	if intervals := BinFlow(at(30), at(30), times, keys); intervals != nil {
+		t.Fatalf("empty range: %+v", intervals)
+	}
+}
@@ -0,0 +1,326 @@
+package algorithms
+
+import (
+	"backend/models"
+	"container/heap"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RoadGraph 路网拓扑
+// 每个路段视为从起点到终点的有向边，双向道路需要录入两条路段
+type RoadGraph struct {
+	segments map[uint]*models.RoadSegment
+	ids      []uint
+	lengths  map[uint]float64  // 路段长度（米）
+	outgoing map[string][]uint // 节点 -> 以该节点为起点的路段
+	incoming map[string][]uint // 节点 -> 以该节点为终点的路段
+	stretch  float64           // 路段长度与端点直线距离之比的最小值，用于A*估价
+}
+
+// EdgeWeight 路段通行代价，须为正值
+type EdgeWeight func(id uint) float64
+
+// NewRoadGraph 根据路段列表构建路网
+func NewRoadGraph(roads []models.RoadSegment) *RoadGraph {
+	graph := &RoadGraph{
+		segments: make(map[uint]*models.RoadSegment),
+		lengths:  make(map[uint]float64),
+		outgoing: make(map[string][]uint),
+		incoming: make(map[string][]uint),
+		stretch:  1,
+	}
+
+	for i := range roads {
+		road := roads[i]
+		graph.segments[road.ID] = &road
+		graph.ids = append(graph.ids, road.ID)
+		graph.lengths[road.ID] = graph.calculateLength(&road)
+		if straight := haversineDistance(road.StartLng, road.StartLat, road.EndLng, road.EndLat) * 1000; straight > 0 {
+			graph.stretch = math.Min(graph.stretch, graph.lengths[road.ID]/straight)
+		}
+
+		startNode := NodeKey(road.StartLng, road.StartLat)
+		graph.outgoing[startNode] = append(graph.outgoing[startNode], road.ID)
+		endNode := NodeKey(road.EndLng, road.EndLat)
+		graph.incoming[endNode] = append(graph.incoming[endNode], road.ID)
+	}
+
+	// 保证遍历顺序稳定
+	sort.Slice(graph.ids, func(i, j int) bool { return graph.ids[i] < graph.ids[j] })
+	for _, nodes := range []map[string][]uint{graph.outgoing, graph.incoming} {
+		for node := range nodes {
+			ids := nodes[node]
+			sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		}
+	}
+
+	return graph
+}
+
+// NodeKey 生成节点标识，坐标保留5位小数（约1米）视为同一节点
+func NodeKey(lng, lat float64) string {
+	return fmt.Sprintf("%.5f,%.5f", lng, lat)
+}
+
+// ParseNodeKey 解析 "lng,lat" 形式的节点坐标，返回按 NodeKey 规范化的节点标识
+func ParseNodeKey(node string) (string, error) {
+	lngText, latText, ok := strings.Cut(node, ",")
+	if !ok {
+		return "", fmt.Errorf("invalid node %q, expected \"lng,lat\"", node)
+	}
+	lng, err := strconv.ParseFloat(strings.TrimSpace(lngText), 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid node %q, expected \"lng,lat\"", node)
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latText), 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid node %q, expected \"lng,lat\"", node)
+	}
+	return NodeKey(lng, lat), nil
+}
+
+// IsEmpty 判断路网是否为空
+func (g *RoadGraph) IsEmpty() bool {
+	return len(g.ids) == 0
+}
+
+// SegmentIDs 获取全部路段ID（升序）
+func (g *RoadGraph) SegmentIDs() []uint {
+	return g.ids
+}
+
+// Segments 获取全部路段（按ID升序）
+func (g *RoadGraph) Segments() []models.RoadSegment {
+	roads := make([]models.RoadSegment, 0, len(g.ids))
+	for _, id := range g.ids {
+		roads = append(roads, *g.segments[id])
+	}
+	return roads
+}
+
+// Segment 获取路段
+func (g *RoadGraph) Segment(id uint) (*models.RoadSegment, bool) {
+	road, ok := g.segments[id]
+	return road, ok
+}
+
+// SegmentLength 获取路段长度（米）
+func (g *RoadGraph) SegmentLength(id uint) float64 {
+	return g.lengths[id]
+}
+
+// NextSegments 获取与路段终点相连的后续路段
+func (g *RoadGraph) NextSegments(id uint) []uint {
+	road, ok := g.segments[id]
+	if !ok {
+		return nil
+	}
+	return g.outgoing[NodeKey(road.EndLng, road.EndLat)]
+}
+
+// OutgoingSegments 获取以节点为起点的路段
+func (g *RoadGraph) OutgoingSegments(node string) []uint {
+	return g.outgoing[node]
+}
+
+// IncomingSegments 获取以节点为终点的路段
+func (g *RoadGraph) IncomingSegments(node string) []uint {
+	return g.incoming[node]
+}
+
+// LaneCount 获取路段车道数，未设置时按单车道处理
+func (g *RoadGraph) LaneCount(id uint) int {
+	road, ok := g.segments[id]
+	if !ok || road.Lanes < 1 {
+		return 1
+	}
+	return road.Lanes
+}
+
+// ContinuingLanes 获取在路段终点可以继续行驶的车道数
+// 后续路段车道数较少时，超出部分的车道在终点处结束（车道缩减）
+func (g *RoadGraph) ContinuingLanes(id uint) int {
+	lanes := g.LaneCount(id)
+	next := g.NextSegments(id)
+	if len(next) == 0 {
+		return lanes
+	}
+
+	continuing := 0
+	for _, nextID := range next {
+		if n := g.LaneCount(nextID); n > continuing {
+			continuing = n
+		}
+	}
+	if continuing > lanes {
+		return lanes
+	}
+	return continuing
+}
+
+// PositionOnSegment 根据路段上的行驶距离（米）计算经纬度
+func (g *RoadGraph) PositionOnSegment(id uint, offset float64) (float64, float64) {
+	road, ok := g.segments[id]
+	if !ok {
+		return 0, 0
+	}
+
+	ratio := 0.0
+	if length := g.lengths[id]; length > 0 {
+		ratio = math.Max(0, math.Min(1, offset/length))
+	}
+
+	lng := road.StartLng + (road.EndLng-road.StartLng)*ratio
+	lat := road.StartLat + (road.EndLat-road.StartLat)*ratio
+	return lng, lat
+}
+
+// SegmentHeading 获取路段方向角（度，正北为0）
+func (g *RoadGraph) SegmentHeading(id uint) float64 {
+	road, ok := g.segments[id]
+	if !ok {
+		return 0
+	}
+
+	direction := math.Atan2(road.EndLng-road.StartLng, road.EndLat-road.StartLat) * 180 / math.Pi
+	if direction < 0 {
+		direction += 360
+	}
+	return direction
+}
+
+// ShortestPath 使用Dijkstra算法按路段长度计算从起始路段到目标路段的最短路径
+// 返回的路段序列包含起止路段，不可达时返回nil
+func (g *RoadGraph) ShortestPath(from, to uint) []uint {
+	path, _ := g.FindPath(from, to, g.SegmentLength, nil)
+	return path
+}
+
+// FindPath 使用A*算法计算从起始路段到目标路段代价最小的路径，代价按驶完每个路段累计（含起止路段）
+// heuristic 为从路段终点到目标路段终点的代价下界，为nil时即Dijkstra算法
+// 返回路段序列和总代价，不可达时返回nil
+func (g *RoadGraph) FindPath(from, to uint, weight, heuristic EdgeWeight) ([]uint, float64) {
+	if _, ok := g.segments[from]; !ok {
+		return nil, 0
+	}
+	if _, ok := g.segments[to]; !ok {
+		return nil, 0
+	}
+	if heuristic == nil {
+		heuristic = func(uint) float64 { return 0 }
+	}
+
+	// 代价按到达路段终点计算，队列按代价加估价排序
+	dist := map[uint]float64{from: weight(from)}
+	prev := make(map[uint]uint)
+	visited := make(map[uint]bool)
+	queue := &segmentQueue{{id: from, cost: dist[from] + heuristic(from)}}
+
+	for queue.Len() > 0 {
+		current := heap.Pop(queue).(segmentItem)
+		if visited[current.id] {
+			continue
+		}
+		visited[current.id] = true
+		if current.id == to {
+			break
+		}
+
+		for _, next := range g.NextSegments(current.id) {
+			cost := dist[current.id] + weight(next)
+			if d, ok := dist[next]; !ok || cost < d {
+				dist[next] = cost
+				prev[next] = current.id
+				heap.Push(queue, segmentItem{id: next, cost: cost + heuristic(next)})
+			}
+		}
+	}
+
+	if !visited[to] {
+		return nil, 0
+	}
+
+	path := []uint{to}
+	for id := to; id != from; {
+		id = prev[id]
+		path = append(path, id)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, dist[to]
+}
+
+// DistanceBound 从路段终点到目标路段终点的行驶距离下界（米），可作为A*估价
+func (g *RoadGraph) DistanceBound(id, to uint) float64 {
+	road, ok := g.segments[id]
+	target, ok2 := g.segments[to]
+	if !ok || !ok2 {
+		return 0
+	}
+	return haversineDistance(road.EndLng, road.EndLat, target.EndLng, target.EndLat) * 1000 * g.stretch
+}
+
+// NearestSegment 查找距离坐标最近的路段，返回路段ID、投影点距路段起点的行驶距离（米）和垂直距离（米）
+func (g *RoadGraph) NearestSegment(lng, lat float64) (uint, float64, float64, bool) {
+	var nearest uint
+	nearestOffset, nearestDistance := 0.0, math.Inf(1)
+
+	// 在点附近按等距投影换算为平面坐标（米）
+	scaleX := 111320 * math.Cos(lat*math.Pi/180)
+	const scaleY = 110540
+	for _, id := range g.ids {
+		road := g.segments[id]
+		ax, ay := (road.StartLng-lng)*scaleX, (road.StartLat-lat)*scaleY
+		bx, by := (road.EndLng-lng)*scaleX, (road.EndLat-lat)*scaleY
+
+		ratio := 0.0
+		if dx, dy := bx-ax, by-ay; dx != 0 || dy != 0 {
+			ratio = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/(dx*dx+dy*dy)))
+		}
+		distance := math.Hypot(ax+(bx-ax)*ratio, ay+(by-ay)*ratio)
+		if distance < nearestDistance {
+			nearest, nearestOffset, nearestDistance = id, ratio*g.lengths[id], distance
+		}
+	}
+
+	return nearest, nearestOffset, nearestDistance, !math.IsInf(nearestDistance, 1)
+}
+
+// segmentItem 优先队列元素
+type segmentItem struct {
+	id   uint
+	cost float64
+}
+
+// segmentQueue 按代价排序的优先队列，代价相同时按路段ID排序保证结果稳定
+type segmentQueue []segmentItem
+
+func (q segmentQueue) Len() int { return len(q) }
+func (q segmentQueue) Less(i, j int) bool {
+	if q[i].cost != q[j].cost {
+		return q[i].cost < q[j].cost
+	}
+	return q[i].id < q[j].id
+}
+func (q segmentQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *segmentQueue) Push(x interface{}) { *q = append(*q, x.(segmentItem)) }
+func (q *segmentQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// calculateLength 计算路段长度（米），优先使用录入的长度（公里），最短按1米计
+func (g *RoadGraph) calculateLength(road *models.RoadSegment) float64 {
+	length := haversineDistance(road.StartLng, road.StartLat, road.EndLng, road.EndLat) * 1000
+	if road.Length > 0 {
+		length = road.Length * 1000
+	}
+	return math.Max(length, 1)
+}
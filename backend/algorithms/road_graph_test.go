@@ -0,0 +1,139 @@
+package algorithms
+
+import (
+	"backend/models"
+	"math"
+	"testing"
+)
+
+// squareNetwork 约1公里见方的路网：A(1)B(2)C 和 A(3)D(4)C 两条路径，路段5从C折返A
+func squareNetwork() []models.RoadSegment {
+	return []models.RoadSegment{
+		{ID: 1, StartLng: 116.00, StartLat: 39.00, EndLng: 116.01, EndLat: 39.00, MaxSpeed: 60, Lanes: 2},
+		{ID: 2, StartLng: 116.01, StartLat: 39.00, EndLng: 116.01, EndLat: 39.01, MaxSpeed: 60, Lanes: 1},
+		{ID: 3, StartLng: 116.00, StartLat: 39.00, EndLng: 116.00, EndLat: 39.01, MaxSpeed: 30, Lanes: 1},
+		{ID: 4, StartLng: 116.00, StartLat: 39.01, EndLng: 116.01, EndLat: 39.01, MaxSpeed: 30, Lanes: 1},
+		{ID: 5, StartLng: 116.01, StartLat: 39.01, EndLng: 116.00, EndLat: 39.00, MaxSpeed: 60, Lanes: 1, Length: 2},
+	}
+}
+
+func near(got, want, tolerance float64) bool {
+	return math.Abs(got-want) <= tolerance
+}
+
+func TestHaversineDistance(t *testing.T) {
+	// 经线上1度约111.2公里，纬度39度处纬线上1度约86.4公里
+	if d := haversineDistance(116, 39, 116, 40); !near(d, 111.19, 0.01) {
+		t.Fatalf("1 degree of latitude = %.3f km", d)
+	}
+	if d := haversineDistance(116, 39, 117, 39); !near(d, 86.42, 0.05) {
+		t.Fatalf("1 degree of longitude at 39N = %.3f km", d)
+	}
+	if d := haversineDistance(116, 39, 116, 39); d != 0 {
+		t.Fatalf("same point distance = %v", d)
+	}
+}
+
+func TestRoadGraphTopology(t *testing.T) {
+	graph := NewRoadGraph(squareNetwork())
+
+	if got := graph.NextSegments(1); len(got) != 1 || got[0] != 2 {
+		t.Fatalf("next of 1 = %v, want [2]", got)
+	}
+	if got := graph.NextSegments(5); len(got) != 2 || got[0] != 1 || got[1] != 3 {
+		t.Fatalf("next of 5 = %v, want [1 3]", got)
+	}
+	if got := graph.OutgoingSegments(NodeKey(116.00, 39.00)); len(got) != 2 {
+		t.Fatalf("outgoing of A = %v", got)
+	}
+	if got := graph.IncomingSegments(NodeKey(116.01, 39.01)); len(got) != 2 {
+		t.Fatalf("incoming of C = %v", got)
+	}
+
+	// 长度按坐标计算，录入长度（公里）优先
+	if length := graph.SegmentLength(1); !near(length, 864.2, 1) {
+		t.Fatalf("length of 1 = %.1f m", length)
+	}
+	if length := graph.SegmentLength(5); length != 2000 {
+		t.Fatalf("length of 5 = %.1f m, want the entered 2000 m", length)
+	}
+	// 路段1有两条车道，后续路段只有一条，终点处车道缩减
+	if lanes := graph.ContinuingLanes(1); lanes != 1 {
+		t.Fatalf("continuing lanes of 1 = %d, want 1", lanes)
+	}
+}
+
+func TestRoadGraphPositions(t *testing.T) {
+	graph := NewRoadGraph(squareNetwork())
+
+	lng, lat := graph.PositionOnSegment(1, graph.SegmentLength(1)/2)
+	if !near(lng, 116.005, 1e-9) || lat != 39 {
+		t.Fatalf("midpoint of 1 = %v,%v", lng, lat)
+	}
+	if lng, _ := graph.PositionOnSegment(1, 1e6); lng != 116.01 {
+		t.Fatalf("offset past the end is not clamped: %v", lng)
+	}
+	if heading := graph.SegmentHeading(1); !near(heading, 90, 1e-9) {
+		t.Fatalf("heading of eastbound 1 = %v", heading)
+	}
+	if heading := graph.SegmentHeading(3); heading != 0 {
+		t.Fatalf("heading of northbound 3 = %v", heading)
+	}
+
+	id, offset, distance, ok := graph.NearestSegment(116.004, 39.0002)
+	if !ok || id != 1 || !near(offset, 345.7, 1) || !near(distance, 22.1, 0.5) {
+		t.Fatalf("nearest = %d offset %.1f distance %.1f", id, offset, distance)
+	}
+	if _, _, _, ok := NewRoadGraph(nil).NearestSegment(116, 39); ok {
+		t.Fatal("found a segment in an empty network")
+	}
+}
+
+func TestFindPath(t *testing.T) {
+	graph := NewRoadGraph(squareNetwork())
+
+	// 按长度：5 -> 1 -> 2，代价含起止路段
+	path, cost := graph.FindPath(5, 2, graph.SegmentLength, nil)
+	if len(path) != 3 || path[0] != 5 || path[1] != 1 || path[2] != 2 {
+		t.Fatalf("path = %v, want [5 1 2]", path)
+	}
+	if want := graph.SegmentLength(5) + graph.SegmentLength(1) + graph.SegmentLength(2); !near(cost, want, 1e-9) {
+		t.Fatalf("cost = %.1f, want %.1f", cost, want)
+	}
+
+	// 路段1代价很高时绕行 3 -> 4
+	expensive := func(id uint) float64 {
+		if id == 1 {
+			return 1e6
+		}
+		return graph.SegmentLength(id)
+	}
+	if path, _ := graph.FindPath(5, 5, expensive, nil); len(path) != 1 {
+		t.Fatalf("path to itself = %v", path)
+	}
+	if path, _ := graph.FindPath(3, 2, expensive, nil); len(path) != 5 || path[1] != 4 || path[2] != 5 || path[3] != 1 {
+		t.Fatalf("path = %v, want [3 4 5 1 2]", path)
+	}
+
+	if path, _ := graph.FindPath(1, 99, graph.SegmentLength, nil); path != nil {
+		t.Fatalf("path to unknown segment = %v", path)
+	}
+	if path := NewRoadGraph(squareNetwork()[:2]).ShortestPath(2, 1); path != nil {
+		t.Fatalf("unreachable path = %v", path)
+	}
+}
+
+// 距离下界作为估价时，A*与Dijkstra的最小代价一致
+func TestFindPathHeuristicIsAdmissible(t *testing.T) {
+	graph := NewRoadGraph(squareNetwork())
+	for _, from := range graph.SegmentIDs() {
+		for _, to := range graph.SegmentIDs() {
+			heuristic := func(id uint) float64 { return graph.DistanceBound(id, to) }
+			_, dijkstra := graph.FindPath(from, to, graph.SegmentLength, nil)
+			_, astar := graph.FindPath(from, to, graph.SegmentLength, heuristic)
+			if !near(astar, dijkstra, 1e-6) {
+				t.Fatalf("%d -> %d: A* cost %.1f, Dijkstra cost %.1f", from, to, astar, dijkstra)
+			}
+		}
+	}
+}
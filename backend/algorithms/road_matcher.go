@@ -72,8 +72,8 @@ func (rm *RoadMatcher) pointToLineDistance(px, py, x1, y1, x2, y2 float64) float
 	}
 
 	// 如果不在线段范围内，计算到两个端点的距离
-	dist1 := rm.haversineDistance(px, py, x1, y1)
-	dist2 := rm.haversineDistance(px, py, x2, y2)
+	dist1 := haversineDistance(px, py, x1, y1)
+	dist2 := haversineDistance(px, py, x2, y2)
 
 	if dist1 < dist2 {
 		return dist1
@@ -90,8 +90,8 @@ func (rm *RoadMatcher) isPointInSegment(px, py, x1, y1, x2, y2 float64) bool {
 	return px >= minX && px <= maxX && py >= minY && py <= maxY
 }
 
-// haversineDistance 使用Haversine公式计算两点间距离
-func (rm *RoadMatcher) haversineDistance(lng1, lat1, lng2, lat2 float64) float64 {
+// haversineDistance 使用Haversine公式计算两点间距离（公里）
+func haversineDistance(lng1, lat1, lng2, lat2 float64) float64 {
 	const R = 6371 // 地球半径（公里）
 
 	dLat := (lat2 - lat1) * math.Pi / 180
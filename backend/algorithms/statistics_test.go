@@ -0,0 +1,53 @@
+package algorithms
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMeanConfidenceInterval(t *testing.T) {
+	values := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+	stat := MeanConfidenceInterval(values, 0.95)
+	stdDev := math.Sqrt(32.0 / 7)
+	halfWidth := 2.365 * stdDev / math.Sqrt(8)
+	if stat.Mean != 5 || math.Abs(stat.StdDev-stdDev) > 1e-12 || math.Abs(stat.HalfWidth-halfWidth) > 1e-12 {
+		t.Fatalf("stat = %+v, want std dev %.4f, half width %.4f", stat, stdDev, halfWidth)
+	}
+	if stat.Lower != stat.Mean-stat.HalfWidth || stat.Upper != stat.Mean+stat.HalfWidth {
+		t.Fatalf("bounds = [%v, %v]", stat.Lower, stat.Upper)
+	}
+
+	// 置信水平越高区间越宽
+	if wider := MeanConfidenceInterval(values, 0.99); wider.HalfWidth <= stat.HalfWidth {
+		t.Fatalf("99%% half width %.3f, 95%% half width %.3f", wider.HalfWidth, stat.HalfWidth)
+	}
+	if single := MeanConfidenceInterval([]float64{3}, 0.95); single != (SampleStatistic{Mean: 3, Lower: 3, Upper: 3}) {
+		t.Fatalf("single value = %+v", single)
+	}
+	if empty := MeanConfidenceInterval(nil, 0.95); empty != (SampleStatistic{}) {
+		t.Fatalf("no values = %+v", empty)
+	}
+}
+
+// 自由度超过30时的近似值随自由度增大趋近正态分布临界值，且与查表值衔接
+func TestStudentT(t *testing.T) {
+	for _, confidence := range []float64{0.90, 0.95, 0.99} {
+		if !IsSupportedConfidence(confidence) {
+			t.Fatalf("confidence %v not supported", confidence)
+		}
+		table, approx := StudentT(confidence, 30), StudentT(confidence, 31)
+		if approx >= table || table-approx > 0.01 {
+			t.Fatalf("confidence %v: t(30) = %.4f, t(31) = %.4f", confidence, table, approx)
+		}
+		if large := StudentT(confidence, 10000); math.Abs(large-normalQuantiles[confidence]) > 1e-3 {
+			t.Fatalf("confidence %v: t(10000) = %.4f", confidence, large)
+		}
+	}
+	// 自由度120时 t(0.95) 约为1.980
+	if got := StudentT(0.95, 120); math.Abs(got-1.980) > 1e-3 {
+		t.Fatalf("t(0.95, 120) = %.4f", got)
+	}
+	if !math.IsNaN(StudentT(0.95, 0)) || IsSupportedConfidence(0.8) {
+		t.Fatal("accepted invalid arguments")
+	}
+}
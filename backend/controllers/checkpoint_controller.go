@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"backend/services"
+	"encoding/json"
+
+	"github.com/beego/beego/v2/server/web"
+)
+
+// CheckpointController 模拟检查点控制器
+type CheckpointController struct {
+	SessionScope
+	Store services.CheckpointStore
+}
+
+// NewCheckpointController 创建模拟检查点控制器，存储方式由配置 checkpoint.storage 决定
+func NewCheckpointController(sessions *services.SessionManager) *CheckpointController {
+	var store services.CheckpointStore
+	if web.AppConfig.DefaultString("checkpoint.storage", "file") == "database" {
+		store = services.NewDatabaseCheckpointStore()
+	} else {
+		store = services.NewFileCheckpointStore(web.AppConfig.DefaultString("checkpoint.dir", "checkpoints"))
+	}
+
+	return &CheckpointController{
+		SessionScope: SessionScope{Sessions: sessions},
+		Store:        store,
+	}
+}
+
+// GetCheckpoints 获取检查点列表
+// @Title GetCheckpoints
+// @Description 获取已保存的模拟检查点
+// @Success 200 {array} services.CheckpointInfo
+// @router /simulation/checkpoints [get]
+func (c *CheckpointController) GetCheckpoints() {
+	checkpoints, err := c.Store.List()
+	if err != nil {
+		c.CustomAbort(500, "Failed to list checkpoints: "+err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    checkpoints,
+	}
+	c.ServeJSON()
+}
+
+// CreateCheckpoint 保存检查点
+// @Title CreateCheckpoint
+// @Description 保存当前完整模拟状态，同名检查点被覆盖
+// @Param body body object true "{\"name\": \"morning-peak\"}"
+// @Success 200 {object} services.CheckpointInfo
+// @router /simulation/checkpoints [post]
+func (c *CheckpointController) CreateCheckpoint() {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &req); err != nil {
+		c.CustomAbort(400, "Invalid request body")
+		return
+	}
+
+	snapshot, err := c.TrafficService.Snapshot()
+	if err != nil {
+		c.CustomAbort(500, "Failed to snapshot simulation: "+err.Error())
+		return
+	}
+	if req.Name == "" {
+		req.Name = "checkpoint-" + snapshot.Clock.Now().Format("20060102-150405")
+	}
+
+	if err := c.Store.Save(req.Name, snapshot); err != nil {
+		c.CustomAbort(400, "Failed to save checkpoint: "+err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "Checkpoint saved successfully",
+		"data": services.CheckpointInfo{
+			Name:    req.Name,
+			SimTime: snapshot.Clock.Now(),
+		},
+	}
+	c.ServeJSON()
+}
+
+// GetCheckpoint 下载检查点
+// @Title GetCheckpoint
+// @Description 获取检查点中的完整模拟状态
+// @Param name path string true "检查点名称"
+// @Success 200 {object} services.SimulationSnapshot
+// @router /simulation/checkpoints/:name [get]
+func (c *CheckpointController) GetCheckpoint() {
+	snapshot, err := c.Store.Load(c.Ctx.Input.Param(":name"))
+	if err != nil {
+		c.CustomAbort(404, "Checkpoint not found")
+		return
+	}
+
+	c.Data["json"] = snapshot
+	c.ServeJSON()
+}
+
+// RestoreCheckpoint 恢复检查点
+// @Title RestoreCheckpoint
+// @Description 从检查点恢复模拟状态，模拟运行中不可用
+// @Param name path string true "检查点名称"
+// @Success 200 {object} map[string]interface{}
+// @router /simulation/checkpoints/:name/restore [post]
+func (c *CheckpointController) RestoreCheckpoint() {
+	snapshot, err := c.Store.Load(c.Ctx.Input.Param(":name"))
+	if err != nil {
+		c.CustomAbort(404, "Checkpoint not found")
+		return
+	}
+
+	if err := c.TrafficService.Restore(snapshot); err != nil {
+		c.CustomAbort(409, "Failed to restore checkpoint: "+err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "Checkpoint restored successfully",
+		"data":    c.TrafficService.GetSimulationStatus(),
+	}
+	c.ServeJSON()
+}
+
+// DeleteCheckpoint 删除检查点
+// @Title DeleteCheckpoint
+// @Description 删除检查点
+// @Param name path string true "检查点名称"
+// @Success 200 {object} map[string]interface{}
+// @router /simulation/checkpoints/:name [delete]
+func (c *CheckpointController) DeleteCheckpoint() {
+	if err := c.Store.Delete(c.Ctx.Input.Param(":name")); err != nil {
+		c.CustomAbort(404, "Checkpoint not found")
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "Checkpoint deleted successfully",
+	}
+	c.ServeJSON()
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"backend/services"
+	"encoding/json"
+)
+
+// DemandController 交通需求控制器
+type DemandController struct {
+	SessionScope
+}
+
+// NewDemandController 创建交通需求控制器，按会话选择模拟服务
+func NewDemandController(sessions *services.SessionManager) *DemandController {
+	return &DemandController{
+		SessionScope: SessionScope{Sessions: sessions},
+	}
+}
+
+// GetDemand 获取需求配置
+// @Title GetDemand
+// @Description 获取交通小区和OD矩阵
+// @Success 200 {object} services.DemandConfig
+// @router /demand [get]
+func (c *DemandController) GetDemand() {
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    c.TrafficService.GetDemand(),
+	}
+	c.ServeJSON()
+}
+
+// SetDemand 设置需求配置
+// @Title SetDemand
+// @Description 替换交通小区和OD矩阵
+// @Success 200 {object} services.DemandConfig
+// @router /demand [put]
+func (c *DemandController) SetDemand() {
+	var config services.DemandConfig
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &config); err != nil {
+		c.CustomAbort(400, "Invalid request body")
+		return
+	}
+
+	result, err := c.TrafficService.SetDemand(config)
+	if err != nil {
+		c.CustomAbort(400, "Failed to set demand: "+err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "Demand updated successfully",
+		"data":    result,
+	}
+	c.ServeJSON()
+}
+
+// GetDemandStats 获取需求统计
+// @Title GetDemandStats
+// @Description 获取已发车、已到达和排队车辆数
+// @Success 200 {object} services.DemandStats
+// @router /demand/stats [get]
+func (c *DemandController) GetDemandStats() {
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    c.TrafficService.GetDemandStats(),
+	}
+	c.ServeJSON()
+}
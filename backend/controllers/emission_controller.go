@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"backend/services"
+	"strconv"
+)
+
+// EmissionController 排放统计控制器
+type EmissionController struct {
+	SessionScope
+}
+
+// NewEmissionController 创建排放统计控制器，按会话选择模拟服务
+func NewEmissionController(sessions *services.SessionManager) *EmissionController {
+	return &EmissionController{
+		SessionScope: SessionScope{Sessions: sessions},
+	}
+}
+
+// GetEmissionSummary 获取油耗和排放汇总
+// @Title GetEmissionSummary
+// @Description 获取时间窗口内的油耗、CO2和NOx总量，以及按路段和车型的分项，未指定窗口时返回本次模拟的累计值
+// @Param from query number false "窗口起始时刻，相对模拟开始 (s)"
+// @Param to query number false "窗口结束时刻，相对模拟开始 (s)"
+// @Success 200 {object} services.EmissionSummary
+// @router /emissions [get]
+func (c *EmissionController) GetEmissionSummary() {
+	from, err := optionalFloat(c.GetString("from"))
+	if err != nil {
+		c.CustomAbort(400, "Invalid from")
+		return
+	}
+	to, err := optionalFloat(c.GetString("to"))
+	if err != nil {
+		c.CustomAbort(400, "Invalid to")
+		return
+	}
+
+	summary, err := c.TrafficService.GetEmissionSummary(from, to)
+	if err != nil {
+		c.CustomAbort(400, "Failed to get emissions: "+err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    summary,
+	}
+	c.ServeJSON()
+}
+
+// optionalFloat 解析可选的数值参数，为空时返回nil
+func optionalFloat(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &number, nil
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"backend/models"
 	"backend/services"
+	"errors"
 	"strconv"
 
 	"github.com/beego/beego/v2/server/web"
@@ -11,16 +12,17 @@ import (
 // GPSController GPS控制器
 type GPSController struct {
 	web.Controller
-	gpsService *services.GPSService
+	GPSService *services.GPSService
 }
 
-func NewGPSController() *GPSController {
+// NewGPSController 创建GPS控制器，与模拟车辆共用同一GPS数据处理流程
+func NewGPSController(gpsService *services.GPSService) *GPSController {
 	return &GPSController{
-		gpsService: services.NewGPSService(),
+		GPSService: gpsService,
 	}
 }
 
-// CreateGPSData 创建GPS数据
+// CreateGPSData 创建GPS数据，匹配路段并检测超速和异常
 func (c *GPSController) CreateGPSData() {
 	var gpsData models.GPSData
 	if err := c.ParseForm(&gpsData); err != nil {
@@ -28,16 +30,12 @@ func (c *GPSController) CreateGPSData() {
 		return
 	}
 
-	if gpsData.VehicleID == "" {
-		c.CustomAbort(400, "Vehicle ID cannot be empty")
-		return
-	}
-	if gpsData.Longitude == 0 || gpsData.Latitude == 0 {
-		c.CustomAbort(400, "Longitude and latitude cannot be zero")
+	result, err := c.GPSService.IngestGPSData(&gpsData)
+	if errors.Is(err, services.ErrInvalidGPSData) {
+		c.CustomAbort(400, err.Error())
 		return
 	}
-
-	if err := c.gpsService.CreateGPSData(&gpsData); err != nil {
+	if err != nil {
 		c.CustomAbort(500, "Failed to create GPS data: "+err.Error())
 		return
 	}
@@ -45,6 +43,7 @@ func (c *GPSController) CreateGPSData() {
 	c.Data["json"] = map[string]interface{}{
 		"success": true,
 		"message": "GPS data created successfully",
+		"data":    result,
 	}
 	c.ServeJSON()
 }
@@ -65,7 +64,7 @@ func (c *GPSController) GetGPSDataByRoad() {
 		}
 	}
 
-	gpsData, err := c.gpsService.GetGPSDataByRoad(uint(roadId), minutes)
+	gpsData, err := c.GPSService.GetGPSDataByRoad(uint(roadId), minutes)
 	if err != nil {
 		c.CustomAbort(500, "Failed to get GPS data: "+err.Error())
 		return
@@ -78,6 +77,32 @@ func (c *GPSController) GetGPSDataByRoad() {
 	c.ServeJSON()
 }
 
+// GetGPSFlow 根据GPS数据统计每15分钟的车辆数和高峰小时
+func (c *GPSController) GetGPSFlow() {
+	hours, err := c.GetInt("hours", 24)
+	if err != nil || hours < 1 || hours > 24*31 {
+		c.CustomAbort(400, "Invalid hours")
+		return
+	}
+	roadId, err := c.GetUint64("road", 0)
+	if err != nil {
+		c.CustomAbort(400, "Invalid road ID")
+		return
+	}
+
+	profile, err := c.GPSService.GetFlowProfile(hours, uint(roadId))
+	if err != nil {
+		c.CustomAbort(500, "Failed to get GPS flow: "+err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    profile,
+	}
+	c.ServeJSON()
+}
+
 // GetGPSDataByVehicle 根据车辆获取GPS数据
 func (c *GPSController) GetGPSDataByVehicle() {
 	vehicleId := c.Ctx.Input.Param(":vehicleId")
@@ -93,7 +118,7 @@ func (c *GPSController) GetGPSDataByVehicle() {
 		}
 	}
 
-	gpsData, err := c.gpsService.GetGPSDataByVehicle(vehicleId, limit)
+	gpsData, err := c.GPSService.GetGPSDataByVehicle(vehicleId, limit)
 	if err != nil {
 		c.CustomAbort(500, "Failed to get GPS data: "+err.Error())
 		return
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"backend/services"
+	"encoding/json"
+)
+
+// GPSEmissionController 模拟GPS上报控制器
+type GPSEmissionController struct {
+	SessionScope
+}
+
+// NewGPSEmissionController 创建模拟GPS上报控制器，按会话选择模拟服务
+func NewGPSEmissionController(sessions *services.SessionManager) *GPSEmissionController {
+	return &GPSEmissionController{
+		SessionScope: SessionScope{Sessions: sessions},
+	}
+}
+
+// GetGPSEmission 获取GPS上报配置
+// @Title GetGPSEmission
+// @Description 获取模拟车辆GPS上报开关和采样间隔
+// @Success 200 {object} services.GPSEmissionConfig
+// @router /gps-emission [get]
+func (c *GPSEmissionController) GetGPSEmission() {
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    c.TrafficService.GetGPSEmission(),
+	}
+	c.ServeJSON()
+}
+
+// SetGPSEmission 设置GPS上报配置
+// @Title SetGPSEmission
+// @Description 设置模拟车辆GPS上报开关、采样间隔和误差模型，上报的数据与 POST /api/gps 经过同一处理流程；仅默认会话可以开启上报
+// @Param body body services.GPSEmissionConfig true "GPS上报配置"
+// @Success 200 {object} services.GPSEmissionConfig
+// @router /gps-emission [put]
+func (c *GPSEmissionController) SetGPSEmission() {
+	var config services.GPSEmissionConfig
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &config); err != nil {
+		c.CustomAbort(400, "Invalid request body")
+		return
+	}
+	if config.Enabled && c.SessionID != services.DefaultSessionID {
+		c.CustomAbort(409, "GPS emission is only available in the default session")
+		return
+	}
+
+	result, err := c.TrafficService.SetGPSEmission(config)
+	if err != nil {
+		c.CustomAbort(400, "Failed to set GPS emission: "+err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "GPS emission updated successfully",
+		"data":    result,
+	}
+	c.ServeJSON()
+}
+
+// GetGPSEmissionStats 获取GPS上报统计
+// @Title GetGPSEmissionStats
+// @Description 获取上报点数，以及按真值统计的路段匹配和超速检测结果
+// @Success 200 {object} services.GPSEmissionStats
+// @router /gps-emission/stats [get]
+func (c *GPSEmissionController) GetGPSEmissionStats() {
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    c.TrafficService.GetGPSEmissionStats(),
+	}
+	c.ServeJSON()
+}
+
+// GetGPSSamples 获取GPS上报真值记录
+// @Title GetGPSSamples
+// @Description 获取最近上报的GPS点及车辆真实路段、位置、车速和处理结果，按时间倒序
+// @Param vehicle query string false "车辆ID"
+// @Param limit query int false "记录数，默认100"
+// @Success 200 {array} services.GPSSample
+// @router /gps-emission/samples [get]
+func (c *GPSEmissionController) GetGPSSamples() {
+	limit, err := c.GetInt("limit", 100)
+	if err != nil {
+		c.CustomAbort(400, "Invalid limit")
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    c.TrafficService.GetGPSSamples(c.GetString("vehicle"), limit),
+	}
+	c.ServeJSON()
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"backend/services"
+	"encoding/json"
+)
+
+// IncidentController 交通事件控制器
+type IncidentController struct {
+	SessionScope
+}
+
+// NewIncidentController 创建交通事件控制器，按会话选择模拟服务
+func NewIncidentController(sessions *services.SessionManager) *IncidentController {
+	return &IncidentController{
+		SessionScope: SessionScope{Sessions: sessions},
+	}
+}
+
+// GetIncidents 获取交通事件列表
+// @Title GetIncidents
+// @Description 获取已生效和计划中的交通事件
+// @Success 200 {array} services.Incident
+// @router /incidents [get]
+func (c *IncidentController) GetIncidents() {
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    c.TrafficService.GetIncidents(),
+	}
+	c.ServeJSON()
+}
+
+// CreateIncident 创建交通事件
+// @Title CreateIncident
+// @Description 在路段指定位置注入事故或占道事件，可封闭车道并降低通行能力
+// @Param body body services.Incident true "交通事件"
+// @Success 200 {object} services.Incident
+// @router /incidents [post]
+func (c *IncidentController) CreateIncident() {
+	var incident services.Incident
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &incident); err != nil {
+		c.CustomAbort(400, "Invalid request body")
+		return
+	}
+
+	result, err := c.TrafficService.CreateIncident(incident)
+	if err != nil {
+		c.CustomAbort(400, "Failed to create incident: "+err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "Incident created successfully",
+		"data":    result,
+	}
+	c.ServeJSON()
+}
+
+// ClearIncident 清除交通事件
+// @Title ClearIncident
+// @Description 清除交通事件并解除对应告警
+// @Param id path string true "事件ID"
+// @Success 200 {object} map[string]interface{}
+// @router /incidents/:id [delete]
+func (c *IncidentController) ClearIncident() {
+	if !c.TrafficService.ClearIncident(c.Ctx.Input.Param(":id")) {
+		c.CustomAbort(404, "Incident not found")
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "Incident cleared successfully",
+	}
+	c.ServeJSON()
+}
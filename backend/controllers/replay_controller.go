@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"backend/services"
+	"encoding/json"
+	"errors"
+)
+
+// ReplayController GPS轨迹回放控制器
+type ReplayController struct {
+	SessionScope
+	GPSService *services.GPSService
+}
+
+// NewReplayController 创建GPS轨迹回放控制器，按会话选择模拟服务
+func NewReplayController(sessions *services.SessionManager, gpsService *services.GPSService) *ReplayController {
+	return &ReplayController{
+		SessionScope: SessionScope{Sessions: sessions},
+		GPSService:   gpsService,
+	}
+}
+
+// GetReplay 获取回放状态
+// @Title GetReplay
+// @Description 获取当前回放的时间范围、筛选条件、轨迹数和进度
+// @Success 200 {object} services.ReplayStatus
+// @router /simulation/replay [get]
+func (c *ReplayController) GetReplay() {
+	status, ok := c.TrafficService.GetReplayStatus()
+	if !ok {
+		c.CustomAbort(404, "No replay in progress")
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    status,
+	}
+	c.ServeJSON()
+}
+
+// StartReplay 开始回放
+// @Title StartReplay
+// @Description 读取时间范围内已入库的GPS点并按时间回放，车辆和模拟状态接口返回回放数据；回放期间可暂停、恢复、单步和修改倍速
+// @Param body body services.ReplayOptions true "{\"from\": \"2024-05-01T07:00:00+08:00\", \"to\": \"2024-05-01T09:00:00+08:00\", \"vehicle_ids\": [\"V001\"], \"road_id\": 3, \"speed\": 10}"
+// @Success 200 {object} services.ReplayStatus
+// @router /simulation/replay [post]
+func (c *ReplayController) StartReplay() {
+	var options services.ReplayOptions
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &options); err != nil {
+		c.CustomAbort(400, "Invalid request body")
+		return
+	}
+	if err := options.Validate(); err != nil {
+		c.CustomAbort(400, err.Error())
+		return
+	}
+
+	points, err := c.GPSService.GetReplayTrace(options)
+	if errors.Is(err, services.ErrTooManyReplayPoints) {
+		c.CustomAbort(400, err.Error())
+		return
+	}
+	if err != nil {
+		c.CustomAbort(500, "Failed to load GPS data: "+err.Error())
+		return
+	}
+
+	status, err := c.TrafficService.StartReplay(options, points)
+	if err != nil {
+		c.CustomAbort(409, "Failed to start replay: "+err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "回放已开始",
+		"data":    status,
+	}
+	c.ServeJSON()
+}
+
+// StopReplay 结束回放
+// @Title StopReplay
+// @Description 结束回放并恢复回放开始前的模拟状态
+// @Success 200 {object} map[string]interface{}
+// @router /simulation/replay [delete]
+func (c *ReplayController) StopReplay() {
+	if err := c.TrafficService.StopReplay(); err != nil {
+		c.CustomAbort(409, "Failed to stop replay: "+err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "回放已结束",
+		"data":    c.TrafficService.GetSimulationStatus(),
+	}
+	c.ServeJSON()
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"backend/services"
+	"encoding/json"
+)
+
+// ReroutingController 动态路径诱导控制器
+type ReroutingController struct {
+	SessionScope
+}
+
+// NewReroutingController 创建动态路径诱导控制器，按会话选择模拟服务
+func NewReroutingController(sessions *services.SessionManager) *ReroutingController {
+	return &ReroutingController{
+		SessionScope: SessionScope{Sessions: sessions},
+	}
+}
+
+// GetRerouting 获取路径诱导配置
+// @Title GetRerouting
+// @Description 获取有导航车辆比例、重新规划间隔等配置
+// @Success 200 {object} services.ReroutingConfig
+// @router /rerouting [get]
+func (c *ReroutingController) GetRerouting() {
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    c.TrafficService.GetRerouting(),
+	}
+	c.ServeJSON()
+}
+
+// SetRerouting 设置路径诱导配置
+// @Title SetRerouting
+// @Description 设置有导航车辆比例、重新规划间隔和事件触发规划
+// @Param body body services.ReroutingConfig true "路径诱导配置"
+// @Success 200 {object} services.ReroutingConfig
+// @router /rerouting [put]
+func (c *ReroutingController) SetRerouting() {
+	var config services.ReroutingConfig
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &config); err != nil {
+		c.CustomAbort(400, "Invalid request body")
+		return
+	}
+
+	result, err := c.TrafficService.SetRerouting(config)
+	if err != nil {
+		c.CustomAbort(400, "Failed to set rerouting: "+err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "Rerouting updated successfully",
+		"data":    result,
+	}
+	c.ServeJSON()
+}
+
+// GetReroutingStats 获取路径诱导统计
+// @Title GetReroutingStats
+// @Description 获取有导航车辆数和改道次数
+// @Success 200 {object} services.ReroutingStats
+// @router /rerouting/stats [get]
+func (c *ReroutingController) GetReroutingStats() {
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    c.TrafficService.GetReroutingStats(),
+	}
+	c.ServeJSON()
+}
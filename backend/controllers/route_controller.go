@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"backend/services"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/beego/beego/v2/server/web"
+)
+
+// RouteController 路径规划控制器
+type RouteController struct {
+	web.Controller
+	RouteService *services.RouteService
+}
+
+// NewRouteController 创建路径规划控制器
+func NewRouteController() *RouteController {
+	return &RouteController{
+		RouteService: services.NewRouteService(),
+	}
+}
+
+// GetRoute 规划路径
+// @Title GetRoute
+// @Description 规划两点间的路径，返回路段序列、几何、长度、自由流和当前行程时间
+// @Param from query string true "起点 lng,lat"
+// @Param to query string true "终点 lng,lat"
+// @Param weight query string false "distance、time（默认）或 congestion"
+// @Success 200 {object} services.Route
+// @router /routes [get]
+func (c *RouteController) GetRoute() {
+	fromLng, fromLat, err := parseCoordinate(c.GetString("from"))
+	if err != nil {
+		c.CustomAbort(400, "Invalid from: "+err.Error())
+		return
+	}
+	toLng, toLat, err := parseCoordinate(c.GetString("to"))
+	if err != nil {
+		c.CustomAbort(400, "Invalid to: "+err.Error())
+		return
+	}
+
+	route, err := c.RouteService.FindRoute(fromLng, fromLat, toLng, toLat, c.GetString("weight"))
+	if errors.Is(err, services.ErrNoRoute) {
+		c.CustomAbort(404, "No route found")
+		return
+	}
+	if err != nil {
+		c.CustomAbort(400, "Failed to find route: "+err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    route,
+	}
+	c.ServeJSON()
+}
+
+// parseCoordinate 解析 "lng,lat" 格式的坐标
+func parseCoordinate(value string) (float64, float64, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return 0, 0, errors.New("expected lng,lat")
+	}
+	lng, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return 0, 0, errors.New("invalid longitude")
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return 0, 0, errors.New("invalid latitude")
+	}
+	if lng < -180 || lng > 180 || lat < -90 || lat > 90 {
+		return 0, 0, errors.New("coordinate out of range")
+	}
+	return lng, lat, nil
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"backend/services"
+	"encoding/json"
+)
+
+// ScenarioController 模拟场景控制器
+type ScenarioController struct {
+	SessionScope
+}
+
+// NewScenarioController 创建模拟场景控制器，按会话选择模拟服务
+func NewScenarioController(sessions *services.SessionManager) *ScenarioController {
+	return &ScenarioController{
+		SessionScope: SessionScope{Sessions: sessions},
+	}
+}
+
+// ExportScenario 导出当前场景
+// @Title ExportScenario
+// @Description 将当前路网、车辆、需求、信号配时和模拟参数导出为场景文件
+// @Param name query string false "场景名称"
+// @Success 200 {object} services.Scenario
+// @router /simulation/scenario [get]
+func (c *ScenarioController) ExportScenario() {
+	scenario := c.TrafficService.ExportScenario()
+	if name := c.GetString("name"); name != "" {
+		scenario.Name = name
+	}
+
+	c.Ctx.Output.Header("Content-Disposition", "attachment; filename=\""+scenario.Name+".json\"")
+	c.Data["json"] = scenario
+	c.ServeJSON()
+}
+
+// LoadScenario 加载场景
+// @Title LoadScenario
+// @Description 加载场景，替换当前模拟状态，模拟运行中不可用
+// @Param body body services.Scenario true "场景"
+// @Success 200 {object} map[string]interface{}
+// @router /simulation/scenario [post]
+func (c *ScenarioController) LoadScenario() {
+	var scenario services.Scenario
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &scenario); err != nil {
+		c.CustomAbort(400, "Invalid request body")
+		return
+	}
+
+	if err := c.TrafficService.LoadScenario(scenario); err != nil {
+		c.CustomAbort(400, "Failed to load scenario: "+err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "Scenario loaded successfully",
+		"data":    c.TrafficService.GetSimulationStatus(),
+	}
+	c.ServeJSON()
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"backend/services"
+	"encoding/json"
+	"errors"
+
+	"github.com/beego/beego/v2/server/web"
+)
+
+// SessionScope 模拟相关控制器的公共部分，按路由参数 :session 选择模拟会话
+// 未带会话参数的旧路由使用默认会话；字段需导出，beego 为每个请求创建控制器实例时只复制可导出字段
+type SessionScope struct {
+	web.Controller
+	Sessions       *services.SessionManager
+	TrafficService *services.TrafficService
+	SessionID      string
+}
+
+// Prepare 解析请求所属会话
+func (c *SessionScope) Prepare() {
+	id := c.Ctx.Input.Param(":session")
+	if id == "" {
+		id = services.DefaultSessionID
+	}
+
+	service, ok := c.Sessions.Get(id)
+	if !ok {
+		c.CustomAbort(404, "Session not found")
+		return
+	}
+	c.SessionID = id
+	c.TrafficService = service
+}
+
+// SessionController 模拟会话控制器
+type SessionController struct {
+	web.Controller
+	Sessions *services.SessionManager
+}
+
+// NewSessionController 创建模拟会话控制器
+func NewSessionController(sessions *services.SessionManager) *SessionController {
+	return &SessionController{
+		Sessions: sessions,
+	}
+}
+
+// GetSessions 获取会话列表
+// @Title GetSessions
+// @Description 获取全部模拟会话及其运行状态
+// @Success 200 {array} services.SessionInfo
+// @router /sessions [get]
+func (c *SessionController) GetSessions() {
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    c.Sessions.List(),
+	}
+	c.ServeJSON()
+}
+
+// GetSession 获取会话
+// @Title GetSession
+// @Description 获取模拟会话及其运行状态
+// @Param session path string true "会话ID"
+// @Success 200 {object} services.SessionInfo
+// @router /sessions/:session [get]
+func (c *SessionController) GetSession() {
+	info, ok := c.Sessions.Info(c.Ctx.Input.Param(":session"))
+	if !ok {
+		c.CustomAbort(404, "Session not found")
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    info,
+	}
+	c.ServeJSON()
+}
+
+// CreateSession 创建会话
+// @Title CreateSession
+// @Description 创建独立的模拟会话，可同时加载场景 {"id": "peak-a", "name": "早高峰方案A", "scenario": {...}}
+// @Param body body services.SessionOptions false "会话参数"
+// @Success 200 {object} services.SessionInfo
+// @router /sessions [post]
+func (c *SessionController) CreateSession() {
+	var options services.SessionOptions
+	if len(c.Ctx.Input.RequestBody) > 0 {
+		if err := json.Unmarshal(c.Ctx.Input.RequestBody, &options); err != nil {
+			c.CustomAbort(400, "Invalid request body")
+			return
+		}
+	}
+
+	info, err := c.Sessions.Create(options)
+	if err != nil {
+		c.CustomAbort(400, "Failed to create session: "+err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "Session created successfully",
+		"data":    info,
+	}
+	c.ServeJSON()
+}
+
+// DeleteSession 删除会话
+// @Title DeleteSession
+// @Description 停止并删除模拟会话，默认会话不能删除
+// @Param session path string true "会话ID"
+// @Success 200 {object} map[string]interface{}
+// @router /sessions/:session [delete]
+func (c *SessionController) DeleteSession() {
+	if err := c.Sessions.Delete(c.Ctx.Input.Param(":session")); err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			c.CustomAbort(404, "Session not found")
+		} else {
+			c.CustomAbort(400, err.Error())
+		}
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "Session deleted successfully",
+	}
+	c.ServeJSON()
+}
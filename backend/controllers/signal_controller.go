@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"backend/services"
+	"encoding/json"
+)
+
+// SignalController 信号控制器
+type SignalController struct {
+	SessionScope
+}
+
+// NewSignalController 创建信号控制器，按会话选择模拟服务
+func NewSignalController(sessions *services.SessionManager) *SignalController {
+	return &SignalController{
+		SessionScope: SessionScope{Sessions: sessions},
+	}
+}
+
+// GetSignalPlans 获取信号配时方案列表
+// @Title GetSignalPlans
+// @Description 获取全部信号配时方案
+// @Success 200 {array} services.SignalPlan
+// @router /signals [get]
+func (c *SignalController) GetSignalPlans() {
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    c.TrafficService.GetSignalPlans(),
+	}
+	c.ServeJSON()
+}
+
+// GetSignalPlan 获取信号配时方案
+// @Title GetSignalPlan
+// @Description 获取指定信号配时方案
+// @Success 200 {object} services.SignalPlan
+// @router /signals/:id [get]
+func (c *SignalController) GetSignalPlan() {
+	plan, ok := c.TrafficService.GetSignalPlan(c.Ctx.Input.Param(":id"))
+	if !ok {
+		c.CustomAbort(404, "Signal not found")
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    plan,
+	}
+	c.ServeJSON()
+}
+
+// CreateSignalPlan 创建信号配时方案
+// @Title CreateSignalPlan
+// @Description 创建信号配时方案（固定配时或感应控制）
+// @Success 200 {object} services.SignalPlan
+// @router /signals [post]
+func (c *SignalController) CreateSignalPlan() {
+	var plan services.SignalPlan
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &plan); err != nil {
+		c.CustomAbort(400, "Invalid request body")
+		return
+	}
+
+	result, err := c.TrafficService.CreateSignalPlan(plan)
+	if err != nil {
+		c.CustomAbort(400, "Failed to create signal plan: "+err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "Signal plan created successfully",
+		"data":    result,
+	}
+	c.ServeJSON()
+}
+
+// UpdateSignalPlan 修改信号配时方案
+// @Title UpdateSignalPlan
+// @Description 修改信号配时方案
+// @Success 200 {object} services.SignalPlan
+// @router /signals/:id [put]
+func (c *SignalController) UpdateSignalPlan() {
+	var plan services.SignalPlan
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &plan); err != nil {
+		c.CustomAbort(400, "Invalid request body")
+		return
+	}
+
+	result, err := c.TrafficService.UpdateSignalPlan(c.Ctx.Input.Param(":id"), plan)
+	if err != nil {
+		c.CustomAbort(400, "Failed to update signal plan: "+err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "Signal plan updated successfully",
+		"data":    result,
+	}
+	c.ServeJSON()
+}
+
+// DeleteSignalPlan 删除信号配时方案
+// @Title DeleteSignalPlan
+// @Description 删除信号配时方案
+// @Success 200 {object} map[string]interface{}
+// @router /signals/:id [delete]
+func (c *SignalController) DeleteSignalPlan() {
+	if !c.TrafficService.DeleteSignalPlan(c.Ctx.Input.Param(":id")) {
+		c.CustomAbort(404, "Signal not found")
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "Signal plan deleted successfully",
+	}
+	c.ServeJSON()
+}
+
+// GetSignalStates 获取全部信号灯状态
+// @Title GetSignalStates
+// @Description 获取全部信号灯当前相位和灯色
+// @Success 200 {array} services.SignalState
+// @router /signals/states [get]
+func (c *SignalController) GetSignalStates() {
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    c.TrafficService.GetSignalStates(),
+	}
+	c.ServeJSON()
+}
+
+// GetSignalState 获取信号灯状态
+// @Title GetSignalState
+// @Description 获取指定信号灯当前相位和灯色
+// @Success 200 {object} services.SignalState
+// @router /signals/:id/state [get]
+func (c *SignalController) GetSignalState() {
+	state, ok := c.TrafficService.GetSignalState(c.Ctx.Input.Param(":id"))
+	if !ok {
+		c.CustomAbort(404, "Signal not found")
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    state,
+	}
+	c.ServeJSON()
+}
@@ -4,20 +4,19 @@ import (
 	"backend/models"
 	"backend/services"
 	"encoding/json"
-	"github.com/beego/beego/v2/server/web"
+	"errors"
 	"time"
 )
 
 // TrafficController 交通控制器
 type TrafficController struct {
-	web.Controller
-	trafficService *services.TrafficService
+	SessionScope
 }
 
-// NewTrafficController 创建交通控制器
-func NewTrafficController() *TrafficController {
+// NewTrafficController 创建交通控制器，按会话选择模拟服务
+func NewTrafficController(sessions *services.SessionManager) *TrafficController {
 	return &TrafficController{
-		trafficService: services.NewTrafficService(),
+		SessionScope: SessionScope{Sessions: sessions},
 	}
 }
 
@@ -27,7 +26,7 @@ func NewTrafficController() *TrafficController {
 // @Success 200 {object} services.TrafficSummary
 // @router /realtime [get]
 func (c *TrafficController) GetRealTimeTraffic() {
-	summary := c.trafficService.GetRealTimeSummary()
+	summary := c.TrafficService.GetRealTimeSummary()
 	c.Data["json"] = summary
 	c.ServeJSON()
 }
@@ -38,7 +37,7 @@ func (c *TrafficController) GetRealTimeTraffic() {
 // @Success 200 {array} models.TrafficAlert
 // @router /alerts [get]
 func (c *TrafficController) GetAlerts() {
-	alerts := c.trafficService.GetRecentAlerts(50) // 最近50条告警
+	alerts := c.TrafficService.GetRecentAlerts(50) // 最近50条告警
 	c.Data["json"] = alerts
 	c.ServeJSON()
 }
@@ -49,7 +48,7 @@ func (c *TrafficController) GetAlerts() {
 // @Success 200 {object} services.TrafficStats
 // @router /stats [get]
 func (c *TrafficController) GetTrafficStats() {
-	stats := c.trafficService.GetTrafficStats()
+	stats := c.TrafficService.GetTrafficStats()
 	c.Data["json"] = stats
 	c.ServeJSON()
 }
@@ -60,7 +59,7 @@ func (c *TrafficController) GetTrafficStats() {
 // @Success 200 {array} services.CongestionData
 // @router /congestion [get]
 func (c *TrafficController) GetCongestionData() {
-	congestion := c.trafficService.GetCongestionData()
+	congestion := c.TrafficService.GetCongestionData()
 	c.Data["json"] = congestion
 	c.ServeJSON()
 }
@@ -71,7 +70,7 @@ func (c *TrafficController) GetCongestionData() {
 // @Success 200 {object} services.VehicleFlow
 // @router /flow [get]
 func (c *TrafficController) GetVehicleFlow() {
-	flow := c.trafficService.GetVehicleFlow()
+	flow := c.TrafficService.GetVehicleFlow()
 	c.Data["json"] = flow
 	c.ServeJSON()
 }
@@ -82,11 +81,24 @@ func (c *TrafficController) GetVehicleFlow() {
 // @Success 200 {array} models.Vehicle
 // @router /vehicles [get]
 func (c *TrafficController) GetVehicles() {
-	vehicles := c.trafficService.GetVehicles()
+	vehicles := c.TrafficService.GetVehicles()
 	c.Data["json"] = vehicles
 	c.ServeJSON()
 }
 
+// GetLaneOccupancy 获取车道占用情况
+// @Title GetLaneOccupancy
+// @Description 获取各路段车道的车辆数、平均速度和密度
+// @Param road_id query int false "路段ID，指定时返回该路段全部车道"
+// @Success 200 {array} services.LaneOccupancy
+// @router /vehicles/lanes [get]
+func (c *TrafficController) GetLaneOccupancy() {
+	roadID, _ := c.GetUint64("road_id", 0)
+	occupancy := c.TrafficService.GetLaneOccupancy(uint(roadID))
+	c.Data["json"] = occupancy
+	c.ServeJSON()
+}
+
 // AddVehicle 添加车辆
 // @Title AddVehicle
 // @Description 添加新车辆到模拟系统
@@ -105,7 +117,11 @@ func (c *TrafficController) AddVehicle() {
 	vehicle.Status = "normal"
 
 	// 添加到服务
-	result := c.trafficService.AddVehicle(vehicle)
+	result, err := c.TrafficService.AddVehicle(vehicle)
+	if err != nil {
+		c.CustomAbort(400, "Failed to add vehicle: "+err.Error())
+		return
+	}
 
 	c.Data["json"] = map[string]interface{}{
 		"success": true,
@@ -127,7 +143,7 @@ func (c *TrafficController) RemoveVehicle() {
 		return
 	}
 
-	success := c.trafficService.RemoveVehicle(vehicleID)
+	success := c.TrafficService.RemoveVehicle(vehicleID)
 
 	c.Data["json"] = map[string]interface{}{
 		"success": success,
@@ -138,15 +154,30 @@ func (c *TrafficController) RemoveVehicle() {
 
 // StartSimulation 开始模拟
 // @Title StartSimulation
-// @Description 开始交通流模拟
+// @Description 开始交通流模拟，请求体可指定随机种子和时钟参数 {"seed": 42, "time_step": 0.5, "speed": 10}
 // @Success 200 {object} map[string]interface{}
 // @router /simulation/start [post]
 func (c *TrafficController) StartSimulation() {
-	c.trafficService.StartSimulation()
+	var options services.SimulationOptions
+	if len(c.Ctx.Input.RequestBody) > 0 {
+		if err := json.Unmarshal(c.Ctx.Input.RequestBody, &options); err != nil {
+			c.CustomAbort(400, "Invalid request body")
+			return
+		}
+	}
+
+	seed, err := c.TrafficService.StartSimulation(options)
+	if err != nil {
+		c.CustomAbort(400, "Failed to start simulation: "+err.Error())
+		return
+	}
 
 	c.Data["json"] = map[string]interface{}{
 		"success": true,
 		"message": "模拟已开始",
+		"data": map[string]interface{}{
+			"seed": seed,
+		},
 	}
 	c.ServeJSON()
 }
@@ -157,7 +188,7 @@ func (c *TrafficController) StartSimulation() {
 // @Success 200 {object} map[string]interface{}
 // @router /simulation/stop [post]
 func (c *TrafficController) StopSimulation() {
-	c.trafficService.StopSimulation()
+	c.TrafficService.StopSimulation()
 
 	c.Data["json"] = map[string]interface{}{
 		"success": true,
@@ -172,7 +203,7 @@ func (c *TrafficController) StopSimulation() {
 // @Success 200 {object} map[string]interface{}
 // @router /simulation/status [get]
 func (c *TrafficController) GetSimulationStatus() {
-	status := c.trafficService.GetSimulationStatus()
+	status := c.TrafficService.GetSimulationStatus()
 
 	c.Data["json"] = map[string]interface{}{
 		"success": true,
@@ -180,3 +211,96 @@ func (c *TrafficController) GetSimulationStatus() {
 	}
 	c.ServeJSON()
 }
+
+// PauseSimulation 暂停模拟
+// @Title PauseSimulation
+// @Description 暂停交通流模拟，模拟时钟停止推进
+// @Success 200 {object} map[string]interface{}
+// @router /simulation/pause [post]
+func (c *TrafficController) PauseSimulation() {
+	if err := c.TrafficService.PauseSimulation(); err != nil {
+		c.CustomAbort(409, err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "模拟已暂停",
+	}
+	c.ServeJSON()
+}
+
+// ResumeSimulation 恢复模拟
+// @Title ResumeSimulation
+// @Description 恢复已暂停的交通流模拟
+// @Success 200 {object} map[string]interface{}
+// @router /simulation/resume [post]
+func (c *TrafficController) ResumeSimulation() {
+	if err := c.TrafficService.ResumeSimulation(); err != nil {
+		c.CustomAbort(409, err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "模拟已恢复",
+	}
+	c.ServeJSON()
+}
+
+// StepSimulation 单步推进模拟
+// @Title StepSimulation
+// @Description 在模拟停止或暂停时推进指定步数，请求体 {"steps": 1}，单次最多3600步
+// @Success 200 {object} map[string]interface{}
+// @router /simulation/step [post]
+func (c *TrafficController) StepSimulation() {
+	var request struct {
+		Steps int `json:"steps"`
+	}
+	if len(c.Ctx.Input.RequestBody) > 0 {
+		if err := json.Unmarshal(c.Ctx.Input.RequestBody, &request); err != nil {
+			c.CustomAbort(400, "Invalid request body")
+			return
+		}
+	}
+
+	if err := c.TrafficService.StepSimulation(request.Steps); err != nil {
+		if errors.Is(err, services.ErrTooManySteps) {
+			c.CustomAbort(400, err.Error())
+			return
+		}
+		c.CustomAbort(409, err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    c.TrafficService.GetSimulationStatus(),
+	}
+	c.ServeJSON()
+}
+
+// UpdateClock 修改模拟时钟
+// @Title UpdateClock
+// @Description 修改时间步长、加速倍数（0为尽可能快）或起始时刻
+// @Success 200 {object} services.SimulationClock
+// @router /simulation/clock [put]
+func (c *TrafficController) UpdateClock() {
+	var options services.ClockOptions
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &options); err != nil {
+		c.CustomAbort(400, "Invalid request body")
+		return
+	}
+
+	clock, err := c.TrafficService.UpdateClock(options)
+	if err != nil {
+		c.CustomAbort(400, "Failed to update clock: "+err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    clock,
+	}
+	c.ServeJSON()
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"backend/services"
+	"errors"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// TrajectoryController 轨迹导出控制器
+type TrajectoryController struct {
+	SessionScope
+	GPSService *services.GPSService
+}
+
+// NewTrajectoryController 创建轨迹导出控制器，模拟轨迹按会话选择模拟服务
+func NewTrajectoryController(sessions *services.SessionManager, gpsService *services.GPSService) *TrajectoryController {
+	return &TrajectoryController{
+		SessionScope: SessionScope{Sessions: sessions},
+		GPSService:   gpsService,
+	}
+}
+
+// ExportTrajectories 导出车辆轨迹
+// @Title ExportTrajectories
+// @Description 将已入库的GPS数据或当前模拟记录的车辆轨迹导出为 GeoJSON LineString、CSV 或 SUMO FCD XML 文件
+// @Param source query string false "数据来源 gps 或 simulation，默认 gps"
+// @Param format query string false "导出格式 geojson、csv 或 fcd，默认 geojson"
+// @Param from query string false "开始时刻 (RFC3339)，gps 来源必填"
+// @Param to query string false "结束时刻 (RFC3339)，gps 来源必填"
+// @Param vehicles query string false "车辆ID，逗号分隔"
+// @Param roads query string false "路段ID，逗号分隔"
+// @Success 200 {file} trajectories
+// @router /trajectories [get]
+func (c *TrajectoryController) ExportTrajectories() {
+	format, err := services.ParseTrajectoryFormat(c.GetString("format", services.TrajectoryGeoJSON))
+	if err != nil {
+		c.CustomAbort(400, err.Error())
+		return
+	}
+	filter, err := c.trajectoryFilter()
+	if err != nil {
+		c.CustomAbort(400, err.Error())
+		return
+	}
+
+	var points []services.TrajectoryPoint
+	begin := filter.From
+	switch c.GetString("source", "gps") {
+	case "gps":
+		points, err = c.GPSService.GetTrajectories(filter)
+		if errors.Is(err, services.ErrInvalidTrajectoryRange) || errors.Is(err, services.ErrTooManyTrajectoryPoints) {
+			c.CustomAbort(400, err.Error())
+			return
+		}
+		if err != nil {
+			c.CustomAbort(500, "Failed to load GPS data: "+err.Error())
+			return
+		}
+	case "simulation":
+		points, begin = c.TrafficService.GetTrajectories(filter)
+	default:
+		c.CustomAbort(400, "source must be gps or simulation")
+		return
+	}
+
+	// 格式已校验，直接写入响应；写入开始后状态码已发出，出错时只能记录日志
+	c.Ctx.Output.Header("Content-Type", services.TrajectoryContentType(format))
+	c.Ctx.Output.Header("Content-Disposition", "attachment; filename=\""+services.TrajectoryFileName(format)+"\"")
+	if err := services.WriteTrajectories(c.Ctx.ResponseWriter, format, points, begin); err != nil {
+		logs.Warn("导出轨迹失败: ", err)
+	}
+}
+
+// trajectoryFilter 解析时间范围、车辆和路段筛选参数
+func (c *TrajectoryController) trajectoryFilter() (services.TrajectoryFilter, error) {
+	var filter services.TrajectoryFilter
+	var err error
+	if from := c.GetString("from"); from != "" {
+		if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			return filter, errors.New("invalid from, expected RFC3339")
+		}
+	}
+	if to := c.GetString("to"); to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			return filter, errors.New("invalid to, expected RFC3339")
+		}
+	}
+	filter.VehicleIDs, filter.RoadIDs, err = services.ParseTrajectoryIDs(c.GetString("vehicles"), c.GetString("roads"))
+	return filter, err
+}
@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"backend/services"
+	"encoding/json"
+)
+
+// TransitController 公交线路控制器
+type TransitController struct {
+	SessionScope
+}
+
+// NewTransitController 创建公交线路控制器，按会话选择模拟服务
+func NewTransitController(sessions *services.SessionManager) *TransitController {
+	return &TransitController{
+		SessionScope: SessionScope{Sessions: sessions},
+	}
+}
+
+// GetTransitLines 获取公交线路列表
+// @Title GetTransitLines
+// @Description 获取全部公交线路
+// @Success 200 {array} services.TransitLine
+// @router /transit/lines [get]
+func (c *TransitController) GetTransitLines() {
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    c.TrafficService.GetTransitLines(),
+	}
+	c.ServeJSON()
+}
+
+// GetTransitLine 获取公交线路
+// @Title GetTransitLine
+// @Description 获取公交线路
+// @Param id path string true "线路ID"
+// @Success 200 {object} services.TransitLine
+// @router /transit/lines/:id [get]
+func (c *TransitController) GetTransitLine() {
+	line, ok := c.TrafficService.GetTransitLine(c.Ctx.Input.Param(":id"))
+	if !ok {
+		c.CustomAbort(404, "Transit line not found")
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    line,
+	}
+	c.ServeJSON()
+}
+
+// CreateTransitLine 新增公交线路
+// @Title CreateTransitLine
+// @Description 新增公交线路，按发车间隔发出班次并在停靠站停站
+// @Param body body services.TransitLine true "公交线路"
+// @Success 200 {object} services.TransitLine
+// @router /transit/lines [post]
+func (c *TransitController) CreateTransitLine() {
+	var line services.TransitLine
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &line); err != nil {
+		c.CustomAbort(400, "Invalid request body")
+		return
+	}
+
+	result, err := c.TrafficService.CreateTransitLine(line)
+	if err != nil {
+		c.CustomAbort(400, "Failed to create transit line: "+err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "Transit line created successfully",
+		"data":    result,
+	}
+	c.ServeJSON()
+}
+
+// UpdateTransitLine 修改公交线路
+// @Title UpdateTransitLine
+// @Description 修改公交线路，对之后发出的班次生效
+// @Param id path string true "线路ID"
+// @Param body body services.TransitLine true "公交线路"
+// @Success 200 {object} services.TransitLine
+// @router /transit/lines/:id [put]
+func (c *TransitController) UpdateTransitLine() {
+	var line services.TransitLine
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &line); err != nil {
+		c.CustomAbort(400, "Invalid request body")
+		return
+	}
+
+	result, err := c.TrafficService.UpdateTransitLine(c.Ctx.Input.Param(":id"), line)
+	if err != nil {
+		c.CustomAbort(400, "Failed to update transit line: "+err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "Transit line updated successfully",
+		"data":    result,
+	}
+	c.ServeJSON()
+}
+
+// DeleteTransitLine 删除公交线路
+// @Title DeleteTransitLine
+// @Description 删除公交线路，运行中的班次不再停站
+// @Param id path string true "线路ID"
+// @Success 200 {object} map[string]interface{}
+// @router /transit/lines/:id [delete]
+func (c *TransitController) DeleteTransitLine() {
+	if !c.TrafficService.DeleteTransitLine(c.Ctx.Input.Param(":id")) {
+		c.CustomAbort(404, "Transit line not found")
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "Transit line deleted successfully",
+	}
+	c.ServeJSON()
+}
+
+// GetTransitTrips 获取运行中的公交班次
+// @Title GetTransitTrips
+// @Description 获取运行中和等待发车的公交班次
+// @Param line query string false "线路ID"
+// @Success 200 {array} services.TransitTrip
+// @router /transit/trips [get]
+func (c *TransitController) GetTransitTrips() {
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    c.TrafficService.GetTransitTrips(c.GetString("line")),
+	}
+	c.ServeJSON()
+}
+
+// GetStopEvents 获取到站记录
+// @Title GetStopEvents
+// @Description 获取最近的到站和越站记录，按时间倒序
+// @Param line query string false "线路ID"
+// @Param limit query int false "记录数，默认100"
+// @Success 200 {array} services.StopEvent
+// @router /transit/stop-events [get]
+func (c *TransitController) GetStopEvents() {
+	limit, err := c.GetInt("limit", 100)
+	if err != nil {
+		c.CustomAbort(400, "Invalid limit")
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    c.TrafficService.GetStopEvents(c.GetString("line"), limit),
+	}
+	c.ServeJSON()
+}
+
+// GetScheduleAdherence 获取准点情况
+// @Title GetScheduleAdherence
+// @Description 按线路和停靠站统计早点、准点、晚点和越站次数
+// @Param line query string false "线路ID"
+// @Success 200 {array} services.LineAdherence
+// @router /transit/adherence [get]
+func (c *TransitController) GetScheduleAdherence() {
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    c.TrafficService.GetScheduleAdherence(c.GetString("line")),
+	}
+	c.ServeJSON()
+}
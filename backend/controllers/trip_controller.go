@@ -0,0 +1,66 @@
+package controllers
+
+import "backend/services"
+
+// TripController 行程记录控制器
+type TripController struct {
+	SessionScope
+}
+
+// NewTripController 创建行程记录控制器，按会话选择模拟服务
+func NewTripController(sessions *services.SessionManager) *TripController {
+	return &TripController{
+		SessionScope: SessionScope{Sessions: sessions},
+	}
+}
+
+// GetTrips 获取已完成的行程
+// @Title GetTrips
+// @Description 获取最近完成的行程，包含出发和到达时刻、行程时间、行驶距离和平均车速，按到达时间倒序
+// @Param vehicle_type query string false "车型"
+// @Param origin query int false "出发路段ID"
+// @Param destination query int false "驶离路段ID"
+// @Param limit query int false "记录数，默认100"
+// @Success 200 {array} services.TripRecord
+// @router /trips [get]
+func (c *TripController) GetTrips() {
+	origin, err := c.GetUint64("origin", 0)
+	if err != nil {
+		c.CustomAbort(400, "Invalid origin")
+		return
+	}
+	destination, err := c.GetUint64("destination", 0)
+	if err != nil {
+		c.CustomAbort(400, "Invalid destination")
+		return
+	}
+	limit, err := c.GetInt("limit", 100)
+	if err != nil {
+		c.CustomAbort(400, "Invalid limit")
+		return
+	}
+
+	filter := services.TripFilter{
+		VehicleType: c.GetString("vehicle_type"),
+		Origin:      uint(origin),
+		Destination: uint(destination),
+	}
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    c.TrafficService.GetTrips(filter, limit),
+	}
+	c.ServeJSON()
+}
+
+// GetTripSummary 获取行程统计
+// @Title GetTripSummary
+// @Description 获取已完成行程数和行程时间、距离、车速统计
+// @Success 200 {object} services.TripSummary
+// @router /trips/summary [get]
+func (c *TripController) GetTripSummary() {
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    c.TrafficService.GetTripSummary(),
+	}
+	c.ServeJSON()
+}
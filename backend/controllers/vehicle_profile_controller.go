@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"backend/services"
+	"encoding/json"
+)
+
+// VehicleProfileController 车型参数控制器
+type VehicleProfileController struct {
+	SessionScope
+}
+
+// NewVehicleProfileController 创建车型参数控制器，按会话选择模拟服务
+func NewVehicleProfileController(sessions *services.SessionManager) *VehicleProfileController {
+	return &VehicleProfileController{
+		SessionScope: SessionScope{Sessions: sessions},
+	}
+}
+
+// GetVehicleProfiles 获取车型参数列表
+// @Title GetVehicleProfiles
+// @Description 获取全部车型的动力学参数
+// @Success 200 {array} services.VehicleProfile
+// @router /vehicle-profiles [get]
+func (c *VehicleProfileController) GetVehicleProfiles() {
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    c.TrafficService.GetVehicleProfiles(),
+	}
+	c.ServeJSON()
+}
+
+// GetVehicleProfile 获取车型参数
+// @Title GetVehicleProfile
+// @Description 获取指定车型的动力学参数
+// @Param type path string true "车型"
+// @Success 200 {object} services.VehicleProfile
+// @router /vehicle-profiles/:type [get]
+func (c *VehicleProfileController) GetVehicleProfile() {
+	profile, ok := c.TrafficService.GetVehicleProfile(c.Ctx.Input.Param(":type"))
+	if !ok {
+		c.CustomAbort(404, "Vehicle profile not found")
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    profile,
+	}
+	c.ServeJSON()
+}
+
+// SetVehicleProfile 新增或修改车型参数
+// @Title SetVehicleProfile
+// @Description 新增或修改车型的车长、加减速度、期望速度系数、最高车速和超速告警阈值；修改时未提供的字段保持原值，新增时 max_lane 默认为-1（不限）
+// @Param type path string true "车型"
+// @Param body body services.VehicleProfile true "车型参数"
+// @Success 200 {object} services.VehicleProfile
+// @router /vehicle-profiles/:type [put]
+func (c *VehicleProfileController) SetVehicleProfile() {
+	vehicleType := c.Ctx.Input.Param(":type")
+	profile, ok := c.TrafficService.GetVehicleProfile(vehicleType)
+	if !ok {
+		profile = services.NewVehicleProfile(vehicleType)
+	}
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &profile); err != nil {
+		c.CustomAbort(400, "Invalid request body")
+		return
+	}
+	profile.Type = vehicleType
+
+	result, err := c.TrafficService.SetVehicleProfile(profile)
+	if err != nil {
+		c.CustomAbort(400, "Failed to set vehicle profile: "+err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "Vehicle profile saved successfully",
+		"data":    result,
+	}
+	c.ServeJSON()
+}
+
+// DeleteVehicleProfile 删除车型参数
+// @Title DeleteVehicleProfile
+// @Description 删除车型参数，默认车型和仍在使用的车型不能删除
+// @Param type path string true "车型"
+// @Success 200 {object} map[string]interface{}
+// @router /vehicle-profiles/:type [delete]
+func (c *VehicleProfileController) DeleteVehicleProfile() {
+	vehicleType := c.Ctx.Input.Param(":type")
+	if _, ok := c.TrafficService.GetVehicleProfile(vehicleType); !ok {
+		c.CustomAbort(404, "Vehicle profile not found")
+		return
+	}
+
+	if err := c.TrafficService.DeleteVehicleProfile(vehicleType); err != nil {
+		c.CustomAbort(409, err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "Vehicle profile deleted successfully",
+	}
+	c.ServeJSON()
+}
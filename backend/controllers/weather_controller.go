@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"backend/services"
+	"encoding/json"
+)
+
+// WeatherController 天气控制器
+type WeatherController struct {
+	SessionScope
+}
+
+// NewWeatherController 创建天气控制器，按会话选择模拟服务
+func NewWeatherController(sessions *services.SessionManager) *WeatherController {
+	return &WeatherController{
+		SessionScope: SessionScope{Sessions: sessions},
+	}
+}
+
+// GetWeather 获取天气
+// @Title GetWeather
+// @Description 获取当前天气、对车速/车头时距/通行能力的影响系数和天气计划
+// @Success 200 {object} services.WeatherState
+// @router /weather [get]
+func (c *WeatherController) GetWeather() {
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"data":    c.TrafficService.GetWeather(),
+	}
+	c.ServeJSON()
+}
+
+// SetWeatherSchedule 设置天气计划
+// @Title SetWeatherSchedule
+// @Description 替换天气计划，天气为 clear、rain、heavy_rain、fog 或 snow，时刻相对模拟开始
+// @Param body body []services.WeatherChange true "天气计划"
+// @Success 200 {object} services.WeatherState
+// @router /weather [put]
+func (c *WeatherController) SetWeatherSchedule() {
+	var schedule []services.WeatherChange
+	if err := json.Unmarshal(c.Ctx.Input.RequestBody, &schedule); err != nil {
+		c.CustomAbort(400, "Invalid request body")
+		return
+	}
+
+	result, err := c.TrafficService.SetWeatherSchedule(schedule)
+	if err != nil {
+		c.CustomAbort(400, "Failed to set weather: "+err.Error())
+		return
+	}
+
+	c.Data["json"] = map[string]interface{}{
+		"success": true,
+		"message": "Weather updated successfully",
+		"data":    result,
+	}
+	c.ServeJSON()
+}
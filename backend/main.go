@@ -2,14 +2,73 @@ package main
 
 import (
 	"backend/routers"
+	"backend/services"
 	"backend/utils"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	beego "github.com/beego/beego/v2/server/web"
 )
 
 func main() {
+	scenarioFile := flag.String("scenario", "", "启动时加载的场景文件")
+	exportFile := flag.String("export-scenario", "", "将当前场景导出到文件后退出")
+	batchDuration := flag.Duration("batch", 0, "批量模式的模拟时长（如 2h），大于0时不连接数据库、不启动Web服务，按最快速度模拟后写出指标并退出")
+	batchOutput := flag.String("output", "results", "批量模式的指标输出目录")
+	batchInterval := flag.Duration("interval", 5*time.Minute, "批量模式的路段拥堵统计区间")
+	batchSeed := flag.Int64("seed", 0, "批量模式的随机种子，未指定时沿用场景种子")
+	trajectoryFile := flag.String("trajectories", "", "导出车辆轨迹到文件后退出：与 -batch 同用时导出本次模拟的轨迹，否则导出已入库的GPS数据")
+	trajectoryFormat := flag.String("format", "", "轨迹导出格式 geojson、csv 或 fcd（SUMO），未指定时按文件扩展名判断")
+	trajectoryFrom := flag.String("from", "", "轨迹导出的开始时刻 (RFC3339)，导出GPS数据时必填")
+	trajectoryTo := flag.String("to", "", "轨迹导出的结束时刻 (RFC3339)，导出GPS数据时必填")
+	trajectoryVehicles := flag.String("vehicles", "", "轨迹导出的车辆ID，逗号分隔")
+	trajectoryRoads := flag.String("roads", "", "轨迹导出的路段ID，逗号分隔")
+	experimentFile := flag.String("experiment", "", "参数扫描实验配置文件，对 -scenario 场景并行运行全部参数组合后将比较结果写入 -output 目录并退出")
+	flag.Parse()
+
+	if *experimentFile != "" {
+		if err := runExperiment(*scenarioFile, *experimentFile, *batchOutput, batchDuration.Seconds()); err != nil {
+			fmt.Printf("实验运行失败: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	var export *trajectoryExport
+	if *trajectoryFile != "" {
+		var err error
+		export, err = newTrajectoryExport(*trajectoryFile, *trajectoryFormat, *trajectoryFrom, *trajectoryTo, *trajectoryVehicles, *trajectoryRoads)
+		if err != nil {
+			fmt.Printf("轨迹导出参数错误: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *batchDuration > 0 {
+		var seed *int64
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "seed" {
+				seed = batchSeed
+			}
+		})
+		options := services.BatchOptions{
+			Duration: batchDuration.Seconds(),
+			Interval: batchInterval.Seconds(),
+			Seed:     seed,
+		}
+		if export != nil {
+			options.Trajectories = &export.filter
+		}
+		if err := runBatch(*scenarioFile, *batchOutput, options, export); err != nil {
+			fmt.Printf("批量运行失败: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// 先初始化数据库
 	if err := utils.InitDatabase(); err != nil {
 		fmt.Printf("数据库初始化失败: %v\n", err)
@@ -22,8 +81,177 @@ func main() {
 		os.Exit(1)
 	}
 
+	// 导出GPS数据轨迹
+	if export != nil {
+		points, err := services.NewGPSService().GetTrajectories(export.filter)
+		if err == nil {
+			err = export.write(points, export.filter.From)
+		}
+		if err != nil {
+			fmt.Printf("导出轨迹失败: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	trafficService := services.NewTrafficService()
+
+	// 加载场景文件
+	if *scenarioFile != "" {
+		scenario, err := services.ReadScenarioFile(*scenarioFile)
+		if err == nil {
+			err = trafficService.LoadScenario(scenario)
+		}
+		if err != nil {
+			fmt.Printf("加载场景失败: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// 导出场景文件
+	if *exportFile != "" {
+		if err := services.WriteScenarioFile(*exportFile, trafficService.ExportScenario()); err != nil {
+			fmt.Printf("导出场景失败: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("场景已导出到 %s\n", *exportFile)
+		return
+	}
+
+	// 模拟车辆生成的GPS数据与设备上报的数据经过同一处理流程
+	gpsService := services.NewGPSService()
+	sessions := services.NewSessionManager(trafficService)
+	sessions.SetGPSSink(gpsService)
+
 	// 手动初始化路由（在数据库初始化后）
-	routers.Init()
+	routers.Init(sessions, gpsService)
 
 	beego.Run()
 }
+
+// runBatch 命令行批量运行：加载场景文件，模拟指定时长后将指标写入输出目录，export 非空时同时导出轨迹
+func runBatch(scenarioFile, output string, options services.BatchOptions, export *trajectoryExport) error {
+	if scenarioFile == "" {
+		return errors.New("batch mode requires -scenario")
+	}
+	scenario, err := services.ReadScenarioFile(scenarioFile)
+	if err != nil {
+		return err
+	}
+
+	trafficService := services.NewStandaloneTrafficService()
+	if err := trafficService.LoadScenario(scenario); err != nil {
+		return fmt.Errorf("load scenario: %w", err)
+	}
+
+	metrics, err := trafficService.RunBatch(options)
+	if err != nil {
+		return err
+	}
+	if err := metrics.WriteFiles(output); err != nil {
+		return fmt.Errorf("write metrics: %w", err)
+	}
+
+	fmt.Printf("模拟 %.0fs（%d步）用时 %.1fs，车公里 %.1f，平均车速 %.1f km/h，指标已写入 %s\n",
+		metrics.Duration, metrics.Steps, metrics.WallTime, metrics.VehicleKm, metrics.MeanSpeed, output)
+
+	if export != nil {
+		return export.write(metrics.Trajectories, metrics.Start)
+	}
+	return nil
+}
+
+// trajectoryExport 命令行轨迹导出参数
+type trajectoryExport struct {
+	file   string
+	format string
+	filter services.TrajectoryFilter
+}
+
+// newTrajectoryExport 解析轨迹导出参数，未指定格式时按文件扩展名判断
+func newTrajectoryExport(file, format, from, to, vehicles, roads string) (*trajectoryExport, error) {
+	export := &trajectoryExport{file: file}
+	var err error
+	if format == "" {
+		export.format, err = services.TrajectoryFormatFromPath(file)
+	} else {
+		export.format, err = services.ParseTrajectoryFormat(format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if from != "" {
+		if export.filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			return nil, fmt.Errorf("invalid -from: %w", err)
+		}
+	}
+	if to != "" {
+		if export.filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			return nil, fmt.Errorf("invalid -to: %w", err)
+		}
+	}
+	export.filter.VehicleIDs, export.filter.RoadIDs, err = services.ParseTrajectoryIDs(vehicles, roads)
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// write 写出轨迹文件
+func (e *trajectoryExport) write(points []services.TrajectoryPoint, begin time.Time) error {
+	file, err := os.Create(e.file)
+	if err != nil {
+		return err
+	}
+	if err := services.WriteTrajectories(file, e.format, points, begin); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("已导出 %d 个轨迹点到 %s\n", len(points), e.file)
+	return nil
+}
+
+// runExperiment 命令行参数扫描实验：实验配置未指定时长时使用 -batch 时长
+func runExperiment(scenarioFile, experimentFile, output string, duration float64) error {
+	if scenarioFile == "" {
+		return errors.New("experiment mode requires -scenario")
+	}
+	scenario, err := services.ReadScenarioFile(scenarioFile)
+	if err != nil {
+		return err
+	}
+	experiment, err := services.ReadExperimentFile(experimentFile)
+	if err != nil {
+		return err
+	}
+	if experiment.Duration == 0 {
+		experiment.Duration = duration
+	}
+
+	start := time.Now()
+	result, err := services.RunExperiment(scenario, experiment, func(done, total int) {
+		fmt.Printf("\r已完成 %d/%d 次运行", done, total)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println()
+	if err := result.WriteFiles(output); err != nil {
+		return fmt.Errorf("write results: %w", err)
+	}
+
+	failed := 0
+	for _, run := range result.Runs {
+		if run.Error != "" {
+			failed++
+		}
+	}
+	fmt.Printf("%d 种参数组合共 %d 次运行（失败 %d 次）用时 %.1fs，结果已写入 %s\n",
+		len(result.Comparison), len(result.Runs), failed, time.Since(start).Seconds(), output)
+	return nil
+}
@@ -0,0 +1,20 @@
+package models
+
+// Emissions 油耗和排放量
+type Emissions struct {
+	Fuel float64 `json:"fuel"` // 燃油消耗 (g)
+	CO2  float64 `json:"co2"`  // 二氧化碳 (g)
+	NOx  float64 `json:"nox"`  // 氮氧化物 (g)
+}
+
+// Add 累加排放量
+func (e *Emissions) Add(other Emissions) {
+	e.Fuel += other.Fuel
+	e.CO2 += other.CO2
+	e.NOx += other.NOx
+}
+
+// Scale 按系数缩放，用于排放速率 (g/s) 乘以时长
+func (e Emissions) Scale(factor float64) Emissions {
+	return Emissions{Fuel: e.Fuel * factor, CO2: e.CO2 * factor, NOx: e.NOx * factor}
+}
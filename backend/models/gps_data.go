@@ -25,6 +25,14 @@ func (g *GPSData) GetLocation() (float64, float64) {
 	return g.Longitude, g.Latitude
 }
 
+// RoadSegmentID 获取匹配到的路段ID，未匹配时为0
+func (g *GPSData) RoadSegmentID() uint {
+	if g.RoadSegment == nil {
+		return 0
+	}
+	return g.RoadSegment.ID
+}
+
 // IsSpeeding 判断是否超速
 func (g *GPSData) IsSpeeding(maxSpeed int) bool {
 	return g.Speed > maxSpeed
@@ -12,6 +12,7 @@ type RoadSegment struct {
 	EndLat    float64   `orm:"digits(10);decimals(6)"`
 	MaxSpeed  int       `orm:"default(60)"`
 	Capacity  int       `orm:"default(1000)"`
+	Lanes     int       `orm:"default(1)"`
 	Length    float64   `orm:"digits(8);decimals(2);null"`
 	RoadType  string    `orm:"size(10);default(urban);index"`
 	CreatedAt time.Time `orm:"auto_now_add;type(datetime)"`
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// SimulationCheckpoint 模拟检查点，Data 为序列化后的完整模拟状态
+type SimulationCheckpoint struct {
+	ID        uint      `orm:"pk;auto"`
+	Name      string    `orm:"size(100);unique"`
+	SimTime   time.Time `orm:"type(datetime)"`
+	Data      string    `orm:"type(longtext)"`
+	CreatedAt time.Time `orm:"auto_now_add;type(datetime)"`
+}
+
+func (c *SimulationCheckpoint) TableName() string {
+	return "simulation_checkpoints"
+}
@@ -4,16 +4,29 @@ import "time"
 
 // Vehicle 车辆模型
 type Vehicle struct {
-	ID          uint      `json:"id" orm:"auto;pk"`
-	VehicleID   string    `json:"vehicle_id" orm:"size(50);unique"`
-	X           float64   `json:"x" orm:"column(x_coordinate)"`
-	Y           float64   `json:"y" orm:"column(y_coordinate)"`
-	Speed       float64   `json:"speed"`
-	Direction   float64   `json:"direction"`
-	VehicleType string    `json:"vehicle_type" orm:"size(20)"`
-	Status      string    `json:"status" orm:"size(20)"`
-	CreatedAt   time.Time `json:"created_at" orm:"auto_now_add"`
-	UpdatedAt   time.Time `json:"updated_at" orm:"auto_now"`
+	ID            uint      `json:"id" orm:"auto;pk"`
+	VehicleID     string    `json:"vehicle_id" orm:"size(50);unique"`
+	X             float64   `json:"x" orm:"column(x_coordinate)"`
+	Y             float64   `json:"y" orm:"column(y_coordinate)"`
+	Speed         float64   `json:"speed"`
+	Direction     float64   `json:"direction"`
+	VehicleType   string    `json:"vehicle_type" orm:"size(20)"`
+	Status        string    `json:"status" orm:"size(20)"`
+	RoadID        uint      `json:"road_id" orm:"-"`               // 当前所在路段（模拟用）
+	Offset        float64   `json:"offset" orm:"-"`                // 在路段上已行驶的距离（米）
+	Lane          int       `json:"lane" orm:"-"`                  // 所在车道，0为最外侧车道
+	Acceleration  float64   `json:"acceleration" orm:"-"`          // 当前加速度 (m/s²)
+	Route         []uint    `json:"route,omitempty" orm:"-"`       // 行驶路径（路段序列），为空时随机选择后续路段
+	RouteIndex    int       `json:"route_index" orm:"-"`           // 当前路段在路径中的位置
+	Informed      bool      `json:"informed" orm:"-"`              // 是否有导航，按实时路况重新规划路径
+	Released      bool      `json:"released,omitempty" orm:"-"`    // 是否经发车队列进入路网（交通需求和公交车辆）
+	Origin        uint      `json:"origin,omitempty" orm:"-"`      // 出发路段
+	Destination   uint      `json:"destination,omitempty" orm:"-"` // 目的路段，为0时无目的地，驶离路网时结束行程
+	DepartureTime time.Time `json:"departure_time" orm:"-"`        // 进入路网的时刻
+	Distance      float64   `json:"distance" orm:"-"`              // 本次行程已行驶的距离（米）
+	Emissions     Emissions `json:"emissions" orm:"-"`             // 本次行程的油耗和排放
+	CreatedAt     time.Time `json:"created_at" orm:"auto_now_add"`
+	UpdatedAt     time.Time `json:"updated_at" orm:"auto_now"`
 }
 
 // TableName 返回表名
@@ -0,0 +1,43 @@
+package models
+
+// WeatherCondition 天气状况
+type WeatherCondition string
+
+// 天气状况
+const (
+	WeatherClear     WeatherCondition = "clear"
+	WeatherRain      WeatherCondition = "rain"
+	WeatherHeavyRain WeatherCondition = "heavy_rain"
+	WeatherFog       WeatherCondition = "fog"
+	WeatherSnow      WeatherCondition = "snow"
+)
+
+// WeatherEffect 天气对交通的影响系数，晴天均为1
+type WeatherEffect struct {
+	SpeedFactor    float64 `json:"speed_factor"`    // 期望速度系数
+	HeadwayFactor  float64 `json:"headway_factor"`  // 车头时距系数
+	CapacityFactor float64 `json:"capacity_factor"` // 路段通行能力系数
+}
+
+// weatherEffects 各天气的影响系数，参考HCM恶劣天气下的自由流速度和通行能力折减
+var weatherEffects = map[WeatherCondition]WeatherEffect{
+	WeatherClear:     {SpeedFactor: 1, HeadwayFactor: 1, CapacityFactor: 1},
+	WeatherRain:      {SpeedFactor: 0.92, HeadwayFactor: 1.1, CapacityFactor: 0.9},
+	WeatherHeavyRain: {SpeedFactor: 0.85, HeadwayFactor: 1.25, CapacityFactor: 0.8},
+	WeatherFog:       {SpeedFactor: 0.8, HeadwayFactor: 1.3, CapacityFactor: 0.85},
+	WeatherSnow:      {SpeedFactor: 0.7, HeadwayFactor: 1.4, CapacityFactor: 0.75},
+}
+
+// IsValid 判断是否为已定义的天气状况
+func (w WeatherCondition) IsValid() bool {
+	_, ok := weatherEffects[w]
+	return ok
+}
+
+// Effect 获取天气影响系数，未指定或未知的天气按晴天处理
+func (w WeatherCondition) Effect() WeatherEffect {
+	if effect, ok := weatherEffects[w]; ok {
+		return effect
+	}
+	return weatherEffects[WeatherClear]
+}
@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"backend/models"
+	"github.com/beego/beego/v2/client/orm"
+)
+
+type CheckpointRepository struct {
+	orm orm.Ormer
+}
+
+func NewCheckpointRepository() *CheckpointRepository {
+	return &CheckpointRepository{
+		orm: orm.NewOrm(),
+	}
+}
+
+// Save 保存检查点，同名检查点被覆盖
+func (r *CheckpointRepository) Save(checkpoint *models.SimulationCheckpoint) error {
+	existing := &models.SimulationCheckpoint{Name: checkpoint.Name}
+	if err := r.orm.Read(existing, "Name"); err == nil {
+		checkpoint.ID = existing.ID
+		_, err = r.orm.Update(checkpoint, "SimTime", "Data")
+		return err
+	}
+	_, err := r.orm.Insert(checkpoint)
+	return err
+}
+
+func (r *CheckpointRepository) GetByName(name string) (*models.SimulationCheckpoint, error) {
+	checkpoint := &models.SimulationCheckpoint{Name: name}
+	err := r.orm.Read(checkpoint, "Name")
+	return checkpoint, err
+}
+
+// GetAll 获取全部检查点（不含状态数据）
+func (r *CheckpointRepository) GetAll() ([]models.SimulationCheckpoint, error) {
+	var checkpoints []models.SimulationCheckpoint
+	_, err := r.orm.QueryTable(new(models.SimulationCheckpoint)).
+		OrderBy("-created_at").
+		All(&checkpoints, "ID", "Name", "SimTime", "CreatedAt")
+	return checkpoints, err
+}
+
+func (r *CheckpointRepository) Delete(name string) error {
+	_, err := r.orm.QueryTable(new(models.SimulationCheckpoint)).
+		Filter("name", name).
+		Delete()
+	return err
+}
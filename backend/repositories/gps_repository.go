@@ -31,10 +31,19 @@ func (r *GPSRepository) FindRecent(limit int, since time.Time) ([]models.GPSData
 	return gpsData, err
 }
 
+func (r *GPSRepository) FindSince(since time.Time) ([]models.GPSData, error) {
+	var gpsData []models.GPSData
+	_, err := r.orm.QueryTable(new(models.GPSData)).
+		Filter("timestamp__gte", since).
+		Filter("road_segment_id__isnull", false).
+		All(&gpsData)
+	return gpsData, err
+}
+
 func (r *GPSRepository) FindByVehicle(vehicleId string, limit int) ([]models.GPSData, error) {
 	var gpsData []models.GPSData
 	_, err := r.orm.QueryTable(new(models.GPSData)).
-		Filter("vehicle_id", vehicleId).
+		Filter("VehicleID", vehicleId).
 		OrderBy("-timestamp").
 		Limit(limit).
 		All(&gpsData)
@@ -50,3 +59,31 @@ func (r *GPSRepository) FindByRoad(roadId uint, since time.Time) ([]models.GPSDa
 		All(&gpsData)
 	return gpsData, err
 }
+
+func (r *GPSRepository) FindObservations(since, until time.Time, roadId uint) ([]models.GPSData, error) {
+	var gpsData []models.GPSData
+	query := r.orm.QueryTable(new(models.GPSData)).
+		Filter("timestamp__gte", since).
+		Filter("timestamp__lt", until)
+	if roadId > 0 {
+		query = query.Filter("road_segment_id", roadId)
+	}
+	_, err := query.All(&gpsData, "VehicleID", "Timestamp")
+	return gpsData, err
+}
+
+// FindTrace 按时间顺序查询时间范围内的GPS点，可按车辆和匹配路段筛选，最多返回 limit 条
+func (r *GPSRepository) FindTrace(since, until time.Time, vehicleIds []string, roadIds []uint, limit int) ([]models.GPSData, error) {
+	var gpsData []models.GPSData
+	query := r.orm.QueryTable(new(models.GPSData)).
+		Filter("timestamp__gte", since).
+		Filter("timestamp__lt", until)
+	if len(vehicleIds) > 0 {
+		query = query.Filter("VehicleID__in", vehicleIds)
+	}
+	if len(roadIds) > 0 {
+		query = query.Filter("road_segment_id__in", roadIds)
+	}
+	_, err := query.OrderBy("timestamp", "id").Limit(limit).All(&gpsData)
+	return gpsData, err
+}
@@ -2,15 +2,19 @@ package routers
 
 import (
 	"backend/controllers"
+	"backend/services"
 	"github.com/beego/beego/v2/server/web"
 )
 
-func Init() {
+// Init 注册路由
+// 模拟相关路由同时注册在 /api 和 /api/sessions/:session 下，前者使用默认会话
+func Init(sessions *services.SessionManager, gpsService *services.GPSService) {
 	// 初始化控制器
 	roadController := controllers.NewRoadController()
-	gpsController := controllers.NewGPSController()
+	gpsController := controllers.NewGPSController(gpsService)
+	routeController := controllers.NewRouteController()
 	healthController := &controllers.HealthController{}
-	trafficController := controllers.NewTrafficController()
+	sessionController := controllers.NewSessionController(sessions)
 
 	// 健康检查
 	web.Router("/api/health", healthController, "get:GetHealth")
@@ -26,21 +30,130 @@ func Init() {
 	web.Router("/api/gps", gpsController, "post:CreateGPSData")
 	web.Router("/api/gps/road/:roadId:int", gpsController, "get:GetGPSDataByRoad")
 	web.Router("/api/gps/vehicle/:vehicleId", gpsController, "get:GetGPSDataByVehicle")
+	web.Router("/api/gps/flow", gpsController, "get:GetGPSFlow")
+
+	// 路径规划路由
+	web.Router("/api/routes", routeController, "get:GetRoute")
+
+	// 模拟会话路由
+	web.Router("/api/sessions", sessionController, "get:GetSessions")
+	web.Router("/api/sessions", sessionController, "post:CreateSession")
+	web.Router("/api/sessions/:session", sessionController, "get:GetSession")
+	web.Router("/api/sessions/:session", sessionController, "delete:DeleteSession")
+	web.Router("/api/sessions/:session/start", controllers.NewTrafficController(sessions), "post:StartSimulation")
+	web.Router("/api/sessions/:session/stop", controllers.NewTrafficController(sessions), "post:StopSimulation")
+
+	initSimulationRoutes("/api", sessions, gpsService)
+	initSimulationRoutes("/api/sessions/:session", sessions, gpsService)
+}
+
+// initSimulationRoutes 注册按会话隔离的模拟路由
+func initSimulationRoutes(prefix string, sessions *services.SessionManager, gpsService *services.GPSService) {
+	trafficController := controllers.NewTrafficController(sessions)
+	signalController := controllers.NewSignalController(sessions)
+	demandController := controllers.NewDemandController(sessions)
+	scenarioController := controllers.NewScenarioController(sessions)
+	checkpointController := controllers.NewCheckpointController(sessions)
+	incidentController := controllers.NewIncidentController(sessions)
+	profileController := controllers.NewVehicleProfileController(sessions)
+	transitController := controllers.NewTransitController(sessions)
+	reroutingController := controllers.NewReroutingController(sessions)
+	gpsEmissionController := controllers.NewGPSEmissionController(sessions)
+	weatherController := controllers.NewWeatherController(sessions)
+	tripController := controllers.NewTripController(sessions)
+	emissionController := controllers.NewEmissionController(sessions)
+	replayController := controllers.NewReplayController(sessions, gpsService)
+	trajectoryController := controllers.NewTrajectoryController(sessions, gpsService)
 
 	// 交通数据路由
-	web.Router("/api/traffic/realtime", trafficController, "get:GetRealTimeTraffic")
-	web.Router("/api/traffic/alerts", trafficController, "get:GetAlerts")
-	web.Router("/api/traffic/stats", trafficController, "get:GetTrafficStats")
-	web.Router("/api/traffic/congestion", trafficController, "get:GetCongestionData")
-	web.Router("/api/traffic/flow", trafficController, "get:GetVehicleFlow")
+	web.Router(prefix+"/traffic/realtime", trafficController, "get:GetRealTimeTraffic")
+	web.Router(prefix+"/traffic/alerts", trafficController, "get:GetAlerts")
+	web.Router(prefix+"/traffic/stats", trafficController, "get:GetTrafficStats")
+	web.Router(prefix+"/traffic/congestion", trafficController, "get:GetCongestionData")
+	web.Router(prefix+"/traffic/flow", trafficController, "get:GetVehicleFlow")
 
 	// 车辆管理路由
-	web.Router("/api/vehicles", trafficController, "get:GetVehicles")
-	web.Router("/api/vehicles", trafficController, "post:AddVehicle")
-	web.Router("/api/vehicles/:id", trafficController, "delete:RemoveVehicle")
+	web.Router(prefix+"/vehicles", trafficController, "get:GetVehicles")
+	web.Router(prefix+"/vehicles", trafficController, "post:AddVehicle")
+	web.Router(prefix+"/vehicles/lanes", trafficController, "get:GetLaneOccupancy")
+	web.Router(prefix+"/vehicles/:id", trafficController, "delete:RemoveVehicle")
+
+	// 行程记录路由
+	web.Router(prefix+"/trips", tripController, "get:GetTrips")
+	web.Router(prefix+"/trips/summary", tripController, "get:GetTripSummary")
+
+	// 轨迹导出路由
+	web.Router(prefix+"/trajectories", trajectoryController, "get:ExportTrajectories")
+
+	// 排放统计路由
+	web.Router(prefix+"/emissions", emissionController, "get:GetEmissionSummary")
+
+	// 车型参数路由
+	web.Router(prefix+"/vehicle-profiles", profileController, "get:GetVehicleProfiles")
+	web.Router(prefix+"/vehicle-profiles/:type", profileController, "get:GetVehicleProfile")
+	web.Router(prefix+"/vehicle-profiles/:type", profileController, "put:SetVehicleProfile")
+	web.Router(prefix+"/vehicle-profiles/:type", profileController, "delete:DeleteVehicleProfile")
 
 	// 模拟控制路由
-	web.Router("/api/simulation/start", trafficController, "post:StartSimulation")
-	web.Router("/api/simulation/stop", trafficController, "post:StopSimulation")
-	web.Router("/api/simulation/status", trafficController, "get:GetSimulationStatus")
+	web.Router(prefix+"/simulation/start", trafficController, "post:StartSimulation")
+	web.Router(prefix+"/simulation/stop", trafficController, "post:StopSimulation")
+	web.Router(prefix+"/simulation/status", trafficController, "get:GetSimulationStatus")
+	web.Router(prefix+"/simulation/pause", trafficController, "post:PauseSimulation")
+	web.Router(prefix+"/simulation/resume", trafficController, "post:ResumeSimulation")
+	web.Router(prefix+"/simulation/step", trafficController, "post:StepSimulation")
+	web.Router(prefix+"/simulation/clock", trafficController, "put:UpdateClock")
+	web.Router(prefix+"/simulation/scenario", scenarioController, "get:ExportScenario")
+	web.Router(prefix+"/simulation/scenario", scenarioController, "post:LoadScenario")
+	web.Router(prefix+"/simulation/checkpoints", checkpointController, "get:GetCheckpoints")
+	web.Router(prefix+"/simulation/checkpoints", checkpointController, "post:CreateCheckpoint")
+	web.Router(prefix+"/simulation/checkpoints/:name", checkpointController, "get:GetCheckpoint")
+	web.Router(prefix+"/simulation/checkpoints/:name", checkpointController, "delete:DeleteCheckpoint")
+	web.Router(prefix+"/simulation/checkpoints/:name/restore", checkpointController, "post:RestoreCheckpoint")
+	web.Router(prefix+"/simulation/replay", replayController, "get:GetReplay")
+	web.Router(prefix+"/simulation/replay", replayController, "post:StartReplay")
+	web.Router(prefix+"/simulation/replay", replayController, "delete:StopReplay")
+
+	// 信号控制路由
+	web.Router(prefix+"/signals", signalController, "get:GetSignalPlans")
+	web.Router(prefix+"/signals", signalController, "post:CreateSignalPlan")
+	web.Router(prefix+"/signals/states", signalController, "get:GetSignalStates")
+	web.Router(prefix+"/signals/:id", signalController, "get:GetSignalPlan")
+	web.Router(prefix+"/signals/:id", signalController, "put:UpdateSignalPlan")
+	web.Router(prefix+"/signals/:id", signalController, "delete:DeleteSignalPlan")
+	web.Router(prefix+"/signals/:id/state", signalController, "get:GetSignalState")
+
+	// 交通需求路由
+	web.Router(prefix+"/demand", demandController, "get:GetDemand")
+	web.Router(prefix+"/demand", demandController, "put:SetDemand")
+	web.Router(prefix+"/demand/stats", demandController, "get:GetDemandStats")
+
+	// 动态路径诱导路由
+	web.Router(prefix+"/rerouting", reroutingController, "get:GetRerouting")
+	web.Router(prefix+"/rerouting", reroutingController, "put:SetRerouting")
+	web.Router(prefix+"/rerouting/stats", reroutingController, "get:GetReroutingStats")
+
+	// 模拟GPS上报路由
+	web.Router(prefix+"/gps-emission", gpsEmissionController, "get:GetGPSEmission")
+	web.Router(prefix+"/gps-emission", gpsEmissionController, "put:SetGPSEmission")
+	web.Router(prefix+"/gps-emission/stats", gpsEmissionController, "get:GetGPSEmissionStats")
+	web.Router(prefix+"/gps-emission/samples", gpsEmissionController, "get:GetGPSSamples")
+
+	// 天气路由
+	web.Router(prefix+"/weather", weatherController, "get:GetWeather")
+	web.Router(prefix+"/weather", weatherController, "put:SetWeatherSchedule")
+
+	// 交通事件路由
+	web.Router(prefix+"/incidents", incidentController, "get:GetIncidents")
+	web.Router(prefix+"/incidents", incidentController, "post:CreateIncident")
+	web.Router(prefix+"/incidents/:id", incidentController, "delete:ClearIncident")
+
+	// 公交线路路由
+	web.Router(prefix+"/transit/lines", transitController, "get:GetTransitLines")
+	web.Router(prefix+"/transit/lines", transitController, "post:CreateTransitLine")
+	web.Router(prefix+"/transit/lines/:id", transitController, "get:GetTransitLine")
+	web.Router(prefix+"/transit/lines/:id", transitController, "put:UpdateTransitLine")
+	web.Router(prefix+"/transit/lines/:id", transitController, "delete:DeleteTransitLine")
+	web.Router(prefix+"/transit/trips", transitController, "get:GetTransitTrips")
+	web.Router(prefix+"/transit/stop-events", transitController, "get:GetStopEvents")
+	web.Router(prefix+"/transit/adherence", transitController, "get:GetScheduleAdherence")
 }
@@ -0,0 +1,213 @@
+package routers
+
+import (
+	"backend/models"
+	"backend/services"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/beego/beego/v2/client/orm"
+	"github.com/beego/beego/v2/server/web"
+)
+
+// emptyDriver 不含任何数据的数据库驱动：查询返回空结果，写入总是成功
+type emptyDriver struct{}
+
+func (emptyDriver) Open(name string) (driver.Conn, error) { return emptyConn{}, nil }
+
+type emptyConn struct{}
+
+func (emptyConn) Prepare(query string) (driver.Stmt, error) { return emptyStmt{}, nil }
+func (emptyConn) Close() error                              { return nil }
+func (emptyConn) Begin() (driver.Tx, error)                 { return emptyTx{}, nil }
+
+type emptyTx struct{}
+
+func (emptyTx) Commit() error   { return nil }
+func (emptyTx) Rollback() error { return nil }
+
+type emptyStmt struct{}
+
+func (emptyStmt) Close() error  { return nil }
+func (emptyStmt) NumInput() int { return -1 }
+func (emptyStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return emptyResult{}, nil
+}
+func (emptyStmt) Query(args []driver.Value) (driver.Rows, error) { return emptyRows{}, nil }
+
+type emptyResult struct{}
+
+func (emptyResult) LastInsertId() (int64, error) { return 1, nil }
+func (emptyResult) RowsAffected() (int64, error) { return 1, nil }
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string              { return nil }
+func (emptyRows) Close() error                   { return nil }
+func (emptyRows) Next(dest []driver.Value) error { return io.EOF }
+
+// TestMain 使用空数据库注册全部路由，与 main 的初始化顺序一致
+func TestMain(m *testing.M) {
+	sql.Register("empty", emptyDriver{})
+	if err := orm.RegisterDriver("empty", orm.DRMySQL); err != nil {
+		panic(err)
+	}
+	if err := orm.RegisterDataBase("default", "empty", ""); err != nil {
+		panic(err)
+	}
+	orm.RegisterModel(new(models.RoadSegment), new(models.GPSData), new(models.TrafficAlert), new(models.Vehicle), new(models.SimulationCheckpoint))
+
+	web.BConfig.CopyRequestBody = true
+	Init(services.NewSessionManager(services.NewStandaloneTrafficService()), services.NewGPSService())
+	os.Exit(m.Run())
+}
+
+// serve 经路由处理请求
+func serve(t *testing.T, method, url, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	if method == http.MethodPost {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	w := httptest.NewRecorder()
+	web.BeeApp.Handlers.ServeHTTP(w, req)
+	return w
+}
+
+// 路径规划请求由控制器交给路径规划服务处理，空路网返回400而不是500
+func TestGetRouteReachesRouteService(t *testing.T) {
+	w := serve(t, http.MethodGet, "/api/routes?from=116.0,39.0&to=116.01,39.0", "")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "road network is empty") {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+
+	w = serve(t, http.MethodGet, "/api/routes?from=116.0&to=116.01,39.0", "")
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "Invalid from") {
+		t.Fatalf("invalid coordinate: status = %d, body = %s", w.Code, w.Body.String())
+	}
+}
+
+// 设备上报的GPS数据经控制器进入GPS处理流程
+func TestCreateGPSDataReachesGPSService(t *testing.T) {
+	w := serve(t, http.MethodPost, "/api/gps", "VehicleID=V1&Longitude=116.005&Latitude=39.0&Speed=50")
+	var result services.GPSIngestResult
+	decodeSuccess(t, w, &result)
+	if result.RoadID != 0 || result.Overspeed {
+		t.Fatalf("empty road network: result = %+v", result)
+	}
+
+	w = serve(t, http.MethodPost, "/api/gps", "Longitude=116.005&Latitude=39.0")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("missing vehicle ID: status = %d, want 400", w.Code)
+	}
+
+	w = serve(t, http.MethodGet, "/api/gps/road/1?minutes=10", "")
+	decodeSuccess(t, w, nil)
+}
+
+// decodeSuccess 解析成功响应
+func decodeSuccess(t *testing.T, w *httptest.ResponseRecorder, data interface{}) {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Success bool            `json:"success"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode response: %v: %s", err, w.Body.String())
+	}
+	if !response.Success {
+		t.Fatalf("success = false: %s", w.Body.String())
+	}
+	if data != nil {
+		if err := json.Unmarshal(response.Data, data); err != nil {
+			t.Fatalf("decode data: %v", err)
+		}
+	}
+}
+
+// GPS流量统计经控制器进入GPS服务，无数据时各区间为0且没有高峰小时
+func TestGetGPSFlowReachesGPSService(t *testing.T) {
+	w := serve(t, http.MethodGet, "/api/gps/flow?hours=2&road=1", "")
+	var profile services.FlowProfile
+	decodeSuccess(t, w, &profile)
+	if len(profile.Intervals) < 8 {
+		t.Fatalf("intervals = %d, want at least 8 for 2 hours", len(profile.Intervals))
+	}
+	for _, interval := range profile.Intervals {
+		if interval.Count != 0 {
+			t.Fatalf("interval %+v has vehicles without GPS data", interval)
+		}
+	}
+	if profile.PeakHour != nil {
+		t.Fatalf("peak hour = %+v, want none", profile.PeakHour)
+	}
+
+	w = serve(t, http.MethodGet, "/api/gps/flow?hours=0", "")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("hours=0: status = %d, want 400", w.Code)
+	}
+}
+
+// 按车辆筛选GPS数据和轨迹时使用模型字段名，字段 VehicleID 的列名不是 vehicle_id
+func TestGPSVehicleFilters(t *testing.T) {
+	w := serve(t, http.MethodGet, "/api/gps/vehicle/V1", "")
+	decodeSuccess(t, w, nil)
+
+	w = serve(t, http.MethodGet, "/api/trajectories?from=2024-01-01T00:00:00Z&to=2024-01-01T01:00:00Z&vehicles=V1,V2&format=csv", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("trajectory export by vehicle: status = %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// 会话内的模拟路由只作用于该会话，不存在的会话返回404
+func TestSessionScopedRoutes(t *testing.T) {
+	body := `{"id": "router-a", "scenario": {"roads": [{"id": 1, "start_lng": 116.0, "start_lat": 39.0, "end_lng": 116.01, "end_lat": 39.0, "max_speed": 60, "lanes": 2}],
+		"vehicles": [{"vehicle_id": "S1", "road_id": 1, "speed": 40}]}}`
+	var info services.SessionInfo
+	decodeSuccess(t, serve(t, http.MethodPost, "/api/sessions", body), &info)
+	if info.ID != "router-a" {
+		t.Fatalf("session id = %s", info.ID)
+	}
+
+	vehicles := getVehicles(t, "/api/sessions/router-a/vehicles")
+	if len(vehicles) != 1 || vehicles[0].VehicleID != "S1" {
+		t.Fatalf("session vehicles = %+v", vehicles)
+	}
+	for _, vehicle := range getVehicles(t, "/api/vehicles") {
+		if vehicle.VehicleID == "S1" {
+			t.Fatal("session vehicle visible in the default session")
+		}
+	}
+
+	decodeSuccess(t, serve(t, http.MethodDelete, "/api/sessions/router-a", ""), nil)
+	if w := serve(t, http.MethodGet, "/api/sessions/router-a/vehicles", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("deleted session: status = %d, want 404", w.Code)
+	}
+}
+
+// getVehicles 获取车辆列表，该接口直接返回数组
+func getVehicles(t *testing.T, url string) []models.Vehicle {
+	t.Helper()
+	w := serve(t, http.MethodGet, url, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s: status = %d: %s", url, w.Code, w.Body.String())
+	}
+	var vehicles []models.Vehicle
+	if err := json.Unmarshal(w.Body.Bytes(), &vehicles); err != nil {
+		t.Fatalf("%s: decode vehicles: %v", url, err)
+	}
+	return vehicles
+}
@@ -0,0 +1,420 @@
+package services
+
+import (
+	"backend/algorithms"
+	"backend/models"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// defaultMetricsInterval 默认路段拥堵统计区间 (s)
+const defaultMetricsInterval = 300.0
+
+// BatchOptions 批量运行参数
+type BatchOptions struct {
+	Duration float64 // 模拟时长 (s)
+	Interval float64 // 路段拥堵统计区间 (s)，为0时取300
+	Seed     *int64  // 随机种子，为空时沿用场景给出的随机状态，场景未指定时随机生成
+
+	Trajectories *TrajectoryFilter // 非空时记录满足条件的全部车辆轨迹点，不受运行中轨迹记录数上限影响
+}
+
+// RunMetrics 批量运行的汇总指标
+type RunMetrics struct {
+	Scenario     string            `json:"scenario"`
+	Seed         int64             `json:"seed"`
+	Start        time.Time         `json:"start"`
+	End          time.Time         `json:"end"`
+	Duration     float64           `json:"duration"` // 模拟时长 (s)
+	Steps        int               `json:"steps"`
+	WallTime     float64           `json:"wall_time"` // 实际耗时 (s)
+	VehicleKm    float64           `json:"vehicle_km"`
+	VehicleHours float64           `json:"vehicle_hours"`
+	MeanSpeed    float64           `json:"mean_speed"`   // 车公里与车小时之比 (km/h)
+	Delay        float64           `json:"delay"`        // 相对自由流行驶的总延误 (veh·h)
+	DelayPerKm   float64           `json:"delay_per_km"` // 每车公里延误 (s)
+	Trips        TripSummary       `json:"trips"`
+	Demand       DemandStats       `json:"demand"`
+	Alerts       map[string]int    `json:"alerts"` // 按类型统计的告警数
+	Emissions    models.Emissions  `json:"emissions"`
+	Segments     []SegmentMetrics  `json:"segments"`   // 路段全程统计
+	Congestion   []SegmentInterval `json:"congestion"` // 路段按统计区间的拥堵情况
+
+	Trajectories []TrajectoryPoint `json:"-"` // 指定 BatchOptions.Trajectories 时记录的轨迹点
+}
+
+// SegmentMetrics 路段全程统计
+type SegmentMetrics struct {
+	RoadID       uint             `json:"road_id"`
+	Name         string           `json:"name"`
+	Entries      int              `json:"entries"` // 驶入车辆数
+	VehicleKm    float64          `json:"vehicle_km"`
+	VehicleHours float64          `json:"vehicle_hours"`
+	MeanSpeed    float64          `json:"mean_speed"` // km/h，无车辆行驶时为0
+	Delay        float64          `json:"delay"`      // veh·h
+	Emissions    models.Emissions `json:"emissions"`
+}
+
+// SegmentInterval 路段在一个统计区间内的拥堵情况
+type SegmentInterval struct {
+	Start           time.Time `json:"start"`
+	RoadID          uint      `json:"road_id"`
+	Entries         int       `json:"entries"`
+	AverageVehicles float64   `json:"average_vehicles"` // 区间内路段上的平均车辆数
+	MeanSpeed       float64   `json:"mean_speed"`       // km/h，无车辆行驶时为0
+	Delay           float64   `json:"delay"`            // veh·h
+	CongestionScore float64   `json:"congestion_score"` // 按平均车速和驶入流率计算，无车辆行驶时为0
+	CongestionLevel string    `json:"congestion_level"`
+}
+
+// segmentAccumulator 路段统计累计值
+type segmentAccumulator struct {
+	entries int
+	meters  float64
+	seconds float64 // 车辆在路段上行驶的总时间 (veh·s)
+	delay   float64 // veh·s
+}
+
+// metricsCollector 每步观测模拟状态，累计批量运行指标
+type metricsCollector struct {
+	start    float64 // 开始时的已模拟时长 (s)
+	interval float64
+
+	lastRoad map[string]uint // 车辆上一步所在路段，用于统计驶入车辆数
+	totals   map[uint]*segmentAccumulator
+
+	// 当前统计区间
+	index          int
+	current        map[uint]*segmentAccumulator
+	intervalTime   float64 // 已观测时长 (s)
+	speedFactor    float64 // 天气期望速度系数按时长的累计值
+	capacityFactor float64 // 天气通行能力系数按时长的累计值
+
+	intervals []SegmentInterval
+}
+
+// newMetricsCollector 创建指标收集器
+func newMetricsCollector(start, interval float64) *metricsCollector {
+	return &metricsCollector{
+		start:    start,
+		interval: interval,
+		lastRoad: make(map[string]uint),
+		totals:   make(map[uint]*segmentAccumulator),
+		current:  make(map[uint]*segmentAccumulator),
+	}
+}
+
+// accumulator 获取路段累计值
+func accumulator(accumulators map[uint]*segmentAccumulator, roadID uint) *segmentAccumulator {
+	acc, ok := accumulators[roadID]
+	if !ok {
+		acc = &segmentAccumulator{}
+		accumulators[roadID] = acc
+	}
+	return acc
+}
+
+// observe 观测刚完成的一个步长，调用方持有读锁
+func (m *metricsCollector) observe(s *TrafficService, dt float64) {
+	// 本步覆盖 [elapsed-dt, elapsed)，按步长起点归入统计区间
+	index := int(math.Floor((s.clock.ElapsedSeconds() - dt - m.start + 1e-9) / m.interval))
+	if index != m.index {
+		m.flush(s)
+		m.index = index
+	}
+
+	effect := s.weatherEffect()
+	m.intervalTime += dt
+	m.speedFactor += effect.SpeedFactor * dt
+	m.capacityFactor += effect.CapacityFactor * dt
+
+	roads := make(map[string]uint, len(s.vehicles))
+	for i := range s.vehicles {
+		vehicle := &s.vehicles[i]
+		if vehicle.RoadID == 0 {
+			continue
+		}
+		roads[vehicle.VehicleID] = vehicle.RoadID
+
+		speed := vehicle.Speed / 3.6
+		delay := 0.0
+		if free := freeFlowSpeed(s.network, vehicle.RoadID, s.profileFor(vehicle.VehicleType)); free > 0 {
+			delay = math.Max(dt*(1-speed/free), 0)
+		}
+		entered := m.lastRoad[vehicle.VehicleID] != vehicle.RoadID
+		for _, acc := range []*segmentAccumulator{accumulator(m.totals, vehicle.RoadID), accumulator(m.current, vehicle.RoadID)} {
+			acc.meters += speed * dt
+			acc.seconds += dt
+			acc.delay += delay
+			if entered {
+				acc.entries++
+			}
+		}
+	}
+	m.lastRoad = roads
+}
+
+// flush 结束当前统计区间，为每个路段生成一条拥堵记录
+func (m *metricsCollector) flush(s *TrafficService) {
+	if m.intervalTime == 0 {
+		return
+	}
+
+	start := s.clock.Start.Add(time.Duration((m.start + float64(m.index)*m.interval) * float64(time.Second)))
+	effect := models.WeatherEffect{
+		SpeedFactor:    m.speedFactor / m.intervalTime,
+		CapacityFactor: m.capacityFactor / m.intervalTime,
+	}
+	for _, road := range s.network.Segments() {
+		row := SegmentInterval{Start: start, RoadID: road.ID}
+		if acc, ok := m.current[road.ID]; ok && acc.seconds > 0 {
+			row.Entries = acc.entries
+			row.AverageVehicles = acc.seconds / m.intervalTime
+			row.MeanSpeed = acc.meters / acc.seconds * 3.6
+			row.Delay = acc.delay / 3600
+			flowRate := float64(acc.entries) / m.intervalTime * 3600
+			row.CongestionScore = algorithms.CongestionScore(row.MeanSpeed, flowRate, &road, effect)
+		}
+		row.CongestionLevel = algorithms.ClassifyCongestion(row.CongestionScore).Level
+		m.intervals = append(m.intervals, row)
+	}
+
+	m.current = make(map[uint]*segmentAccumulator)
+	m.intervalTime, m.speedFactor, m.capacityFactor = 0, 0, 0
+}
+
+// RunBatch 不经过模拟协程，以最快速度模拟指定时长并返回汇总指标
+func (s *TrafficService) RunBatch(options BatchOptions) (*RunMetrics, error) {
+	if options.Duration <= 0 {
+		return nil, errors.New("duration must be positive")
+	}
+	if options.Interval < 0 {
+		return nil, errors.New("interval cannot be negative")
+	}
+	if options.Interval == 0 {
+		options.Interval = defaultMetricsInterval
+	}
+
+	s.mu.Lock()
+	if s.simulating {
+		s.mu.Unlock()
+		return nil, errors.New("stop the simulation before running a batch")
+	}
+	if options.Seed != nil {
+		s.reseed(*options.Seed)
+	} else if !s.keepRandomState {
+		s.reseed(time.Now().UnixNano())
+	}
+	s.keepRandomState = false
+
+	dt := s.clock.StepSeconds()
+	steps := int(math.Ceil(options.Duration/dt - 1e-9))
+	metrics := &RunMetrics{
+		Scenario: s.scenarioName,
+		Seed:     s.seed,
+		Start:    s.clock.Now(),
+		Steps:    steps,
+	}
+	startTrips := s.tripStats
+	startDemand := s.demandStats
+	startEmissions := s.emissionTotals.Total
+	startRoadEmissions := maps.Clone(s.emissionTotals.Roads)
+	startAlerts := maps.Clone(s.alertCounts)
+	collector := newMetricsCollector(s.clock.ElapsedSeconds(), options.Interval)
+	for i := range s.vehicles {
+		collector.lastRoad[s.vehicles[i].VehicleID] = s.vehicles[i].RoadID
+	}
+	s.mu.Unlock()
+
+	wallStart := time.Now()
+	for i := 0; i < steps; i++ {
+		s.stepMu.Lock()
+		s.advance()
+		s.stepMu.Unlock()
+
+		s.mu.RLock()
+		collector.observe(s, dt)
+		if options.Trajectories != nil {
+			metrics.Trajectories = s.sampleTrajectories(metrics.Trajectories, options.Trajectories)
+		}
+		s.mu.RUnlock()
+	}
+	metrics.WallTime = time.Since(wallStart).Seconds()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	collector.flush(s)
+	metrics.End = s.clock.Now()
+	metrics.Duration = metrics.End.Sub(metrics.Start).Seconds()
+
+	// 行程、需求和告警只统计本次运行期间的增量，排队车辆数取结束时的值
+	var trips []TripRecord
+	for _, trip := range s.trips {
+		if trip.ArrivalTime.After(metrics.Start) {
+			trips = append(trips, trip)
+		}
+	}
+	metrics.Trips = tripSummary(s.tripStats.since(startTrips), trips)
+	metrics.Trips.Active = len(s.vehicles)
+	metrics.Demand = DemandStats{
+		Spawned:    s.demandStats.Spawned - startDemand.Spawned,
+		Arrived:    s.demandStats.Arrived - startDemand.Arrived,
+		Queued:     s.demandStats.Queued,
+		Unroutable: s.demandStats.Unroutable - startDemand.Unroutable,
+	}
+	metrics.Congestion = collector.intervals
+
+	metrics.Alerts = make(map[string]int)
+	for alertType, count := range s.alertCounts {
+		if count > startAlerts[alertType] {
+			metrics.Alerts[alertType] = count - startAlerts[alertType]
+		}
+	}
+
+	metrics.Emissions = s.emissionTotals.Total
+	metrics.Emissions.Add(startEmissions.Scale(-1))
+
+	delay := 0.0
+	for _, road := range s.network.Segments() {
+		segment := SegmentMetrics{RoadID: road.ID, Name: road.Name}
+		if acc, ok := collector.totals[road.ID]; ok {
+			segment.Entries = acc.entries
+			segment.VehicleKm = acc.meters / 1000
+			segment.VehicleHours = acc.seconds / 3600
+			segment.Delay = acc.delay / 3600
+			if acc.seconds > 0 {
+				segment.MeanSpeed = acc.meters / acc.seconds * 3.6
+			}
+			delay += acc.delay
+		}
+		segment.Emissions = s.emissionTotals.Roads[road.ID]
+		segment.Emissions.Add(startRoadEmissions[road.ID].Scale(-1))
+
+		metrics.VehicleKm += segment.VehicleKm
+		metrics.VehicleHours += segment.VehicleHours
+		metrics.Segments = append(metrics.Segments, segment)
+	}
+	metrics.Delay = delay / 3600
+	if metrics.VehicleHours > 0 {
+		metrics.MeanSpeed = metrics.VehicleKm / metrics.VehicleHours
+	}
+	if metrics.VehicleKm > 0 {
+		metrics.DelayPerKm = delay / metrics.VehicleKm
+	}
+	return metrics, nil
+}
+
+// WriteFiles 将指标写入目录：metrics.json 为完整指标，summary.csv、segments.csv、congestion.csv 便于表格分析
+func (m *RunMetrics) WriteFiles(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "metrics.json"), append(data, '\n'), 0644); err != nil {
+		return err
+	}
+
+	if err := writeCSV(filepath.Join(dir, "summary.csv"), m.summaryRows()); err != nil {
+		return err
+	}
+
+	segments := [][]string{{"road_id", "name", "entries", "vehicle_km", "vehicle_hours", "mean_speed", "delay", "fuel", "co2", "nox"}}
+	for _, segment := range m.Segments {
+		segments = append(segments, []string{
+			formatUint(segment.RoadID), segment.Name, strconv.Itoa(segment.Entries),
+			formatFloat(segment.VehicleKm), formatFloat(segment.VehicleHours), formatFloat(segment.MeanSpeed), formatFloat(segment.Delay),
+			formatFloat(segment.Emissions.Fuel), formatFloat(segment.Emissions.CO2), formatFloat(segment.Emissions.NOx),
+		})
+	}
+	if err := writeCSV(filepath.Join(dir, "segments.csv"), segments); err != nil {
+		return err
+	}
+
+	congestion := [][]string{{"start", "road_id", "entries", "average_vehicles", "mean_speed", "delay", "congestion_score", "congestion_level"}}
+	for _, row := range m.Congestion {
+		congestion = append(congestion, []string{
+			row.Start.Format(time.RFC3339), formatUint(row.RoadID), strconv.Itoa(row.Entries),
+			formatFloat(row.AverageVehicles), formatFloat(row.MeanSpeed), formatFloat(row.Delay),
+			formatFloat(row.CongestionScore), row.CongestionLevel,
+		})
+	}
+	return writeCSV(filepath.Join(dir, "congestion.csv"), congestion)
+}
+
+// summaryRows 汇总指标的 metric,value 表格，告警按类型展开为 alerts_<类型>
+func (m *RunMetrics) summaryRows() [][]string {
+	rows := [][]string{
+		{"metric", "value"},
+		{"scenario", m.Scenario},
+		{"seed", strconv.FormatInt(m.Seed, 10)},
+		{"start", m.Start.Format(time.RFC3339)},
+		{"end", m.End.Format(time.RFC3339)},
+		{"duration", formatFloat(m.Duration)},
+		{"steps", strconv.Itoa(m.Steps)},
+		{"wall_time", formatFloat(m.WallTime)},
+		{"vehicle_km", formatFloat(m.VehicleKm)},
+		{"vehicle_hours", formatFloat(m.VehicleHours)},
+		{"mean_speed", formatFloat(m.MeanSpeed)},
+		{"delay", formatFloat(m.Delay)},
+		{"delay_per_km", formatFloat(m.DelayPerKm)},
+		{"trips_completed", strconv.Itoa(m.Trips.Completed)},
+		{"trips_active", strconv.Itoa(m.Trips.Active)},
+		{"mean_travel_time", formatFloat(m.Trips.MeanTravelTime)},
+		{"p95_travel_time", formatFloat(m.Trips.P95TravelTime)},
+		{"spawned", strconv.Itoa(m.Demand.Spawned)},
+		{"queued", strconv.Itoa(m.Demand.Queued)},
+		{"unroutable", strconv.Itoa(m.Demand.Unroutable)},
+		{"fuel", formatFloat(m.Emissions.Fuel)},
+		{"co2", formatFloat(m.Emissions.CO2)},
+		{"nox", formatFloat(m.Emissions.NOx)},
+	}
+
+	types := make([]string, 0, len(m.Alerts))
+	for alertType := range m.Alerts {
+		types = append(types, alertType)
+	}
+	sort.Strings(types)
+	for _, alertType := range types {
+		rows = append(rows, []string{"alerts_" + alertType, strconv.Itoa(m.Alerts[alertType])})
+	}
+	return rows
+}
+
+// writeCSV 写入CSV文件
+func writeCSV(path string, rows [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(file)
+	if err := writer.WriteAll(rows); err != nil {
+		file.Close()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// formatFloat 按最短精确表示格式化数值
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// formatUint 格式化ID
+func formatUint(value uint) string {
+	return strconv.FormatUint(uint64(value), 10)
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"backend/models"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// 路段统计按车辆所在路段累计行驶距离、时间和相对自由流的延误，驶入车辆数在换路段时计数，统计区间按步长起点划分
+func TestMetricsCollectorObserve(t *testing.T) {
+	s := newNetworkService(t)
+	s.vehicles = nil
+	if _, err := s.AddVehicle(models.Vehicle{VehicleID: "V1", RoadID: 1, Speed: 36}); err != nil {
+		t.Fatalf("add vehicle: %v", err)
+	}
+	collector := newMetricsCollector(0, 2)
+	observe := func(roadID uint) {
+		s.vehicles[0].RoadID = roadID
+		s.clock.Advance()
+		collector.observe(s, 1)
+	}
+	observe(1)
+	observe(1)
+	observe(2)
+	collector.flush(s)
+
+	free := freeFlowSpeed(s.network, 1, s.profileFor(defaultVehicleType))
+	road1 := collector.totals[1]
+	if road1.entries != 1 || road1.meters != 20 || road1.seconds != 2 || !near(road1.delay, 2*(1-10/free), 1e-9) {
+		t.Fatalf("road 1 totals = %+v", road1)
+	}
+	if road2 := collector.totals[2]; road2.entries != 1 || road2.meters != 10 {
+		t.Fatalf("road 2 totals = %+v", road2)
+	}
+
+	// 2秒一个区间，每个区间为每个路段生成一条记录
+	if len(collector.intervals) != 2*3 {
+		t.Fatalf("got %d interval rows, want 6", len(collector.intervals))
+	}
+	first, second := collector.intervals[0], collector.intervals[3]
+	if !first.Start.Equal(s.clock.Start) || first.RoadID != 1 || first.AverageVehicles != 1 || !near(first.MeanSpeed, 36, 1e-9) {
+		t.Fatalf("first interval = %+v", first)
+	}
+	if !second.Start.Equal(s.clock.Start.Add(2*time.Second)) || second.RoadID != 1 || second.Entries != 0 || second.MeanSpeed != 0 {
+		t.Fatalf("second interval road 1 = %+v", second)
+	}
+	if row := collector.intervals[4]; row.RoadID != 2 || row.Entries != 1 || row.AverageVehicles != 1 || row.CongestionLevel == "" {
+		t.Fatalf("second interval road 2 = %+v", row)
+	}
+}
+
+// 批量运行按统计区间输出拥堵记录，汇总指标与路段统计一致，连续运行只统计增量
+func TestRunBatchMetrics(t *testing.T) {
+	s := newCheckpointService(t)
+	seed := int64(7)
+	metrics, err := s.RunBatch(BatchOptions{Duration: 600, Interval: 300, Seed: &seed})
+	if err != nil {
+		t.Fatalf("run batch: %v", err)
+	}
+	if metrics.Seed != 7 || metrics.Steps != 600 || metrics.Duration != 600 || len(metrics.Congestion) != 2*3 {
+		t.Fatalf("metrics = seed %d, %d steps, %.0fs, %d congestion rows", metrics.Seed, metrics.Steps, metrics.Duration, len(metrics.Congestion))
+	}
+	vehicleKm, vehicleHours, delay := 0.0, 0.0, 0.0
+	for _, segment := range metrics.Segments {
+		vehicleKm += segment.VehicleKm
+		vehicleHours += segment.VehicleHours
+		delay += segment.Delay
+	}
+	if !near(metrics.VehicleKm, vehicleKm, 1e-9) || !near(metrics.VehicleHours, vehicleHours, 1e-9) || !near(metrics.Delay, delay, 1e-9) {
+		t.Fatalf("totals %+v differ from segment sums", metrics)
+	}
+	if !near(metrics.MeanSpeed, metrics.VehicleKm/metrics.VehicleHours, 1e-9) || metrics.Delay <= 0 {
+		t.Fatalf("mean speed %.2f, delay %.3f", metrics.MeanSpeed, metrics.Delay)
+	}
+	if metrics.Trips.Completed == 0 || metrics.Demand.Spawned == 0 || metrics.Emissions != s.emissionTotals.Total {
+		t.Fatalf("trips %+v, demand %+v, emissions %+v", metrics.Trips, metrics.Demand, metrics.Emissions)
+	}
+
+	completed := s.tripStats.Completed
+	next, err := s.RunBatch(BatchOptions{Duration: 300})
+	if err != nil {
+		t.Fatalf("second batch: %v", err)
+	}
+	if next.Trips.Completed != s.tripStats.Completed-completed || !next.Start.Equal(metrics.End) || len(next.Congestion) != 3 {
+		t.Fatalf("second batch: %d trips, start %v, %d congestion rows", next.Trips.Completed, next.Start, len(next.Congestion))
+	}
+
+	for _, options := range []BatchOptions{{}, {Duration: 60, Interval: -1}} {
+		if _, err := s.RunBatch(options); err == nil {
+			t.Fatalf("accepted options %+v", options)
+		}
+	}
+}
+
+// 指标写入 metrics.json 和三个CSV文件，告警按类型展开为汇总行
+func TestRunMetricsWriteFiles(t *testing.T) {
+	s := newCheckpointService(t)
+	seed := int64(7)
+	metrics, err := s.RunBatch(BatchOptions{Duration: 120, Interval: 60, Seed: &seed})
+	if err != nil {
+		t.Fatalf("run batch: %v", err)
+	}
+	metrics.Alerts = map[string]int{"overspeed": 3, "congestion": 1}
+	dir := filepath.Join(t.TempDir(), "run")
+	if err := metrics.WriteFiles(dir); err != nil {
+		t.Fatalf("write files: %v", err)
+	}
+
+	readCSV := func(name string) [][]string {
+		file, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("open %s: %v", name, err)
+		}
+		defer file.Close()
+		rows, err := csv.NewReader(file).ReadAll()
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		return rows
+	}
+	summary := readCSV("summary.csv")
+	if last := summary[len(summary)-2:]; last[0][0] != "alerts_congestion" || last[1][0] != "alerts_overspeed" || last[1][1] != "3" {
+		t.Fatalf("alert rows = %v", last)
+	}
+	if rows := readCSV("segments.csv"); len(rows) != 1+len(metrics.Segments) || rows[0][0] != "road_id" {
+		t.Fatalf("segments.csv has %d rows", len(rows))
+	}
+	if rows := readCSV("congestion.csv"); len(rows) != 1+len(metrics.Congestion) || len(rows[1]) != 8 {
+		t.Fatalf("congestion.csv has %d rows", len(rows))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "metrics.json")); err != nil {
+		t.Fatalf("metrics.json: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"backend/algorithms"
+	"backend/models"
+	"math"
+)
+
+// maxDeceleration 物理极限减速度 (m/s²)
+const maxDeceleration = 9.0
+
+// IDMParams 智能驾驶模型（IDM）参数
+type IDMParams struct {
+	DesiredSpeedFactor float64 `json:"desired_speed_factor"` // 期望速度与路段限速之比
+	MaxAcceleration    float64 `json:"max_acceleration"`     // 最大加速度 (m/s²)
+	ComfortDecel       float64 `json:"comfort_decel"`        // 舒适减速度 (m/s²)
+	TimeHeadway        float64 `json:"time_headway"`         // 安全车头时距 (s)
+	MinGap             float64 `json:"min_gap"`              // 最小静止间距 (m)
+	Length             float64 `json:"length"`               // 车长 (m)
+	Delta              float64 `json:"delta"`                // 加速度指数
+}
+
+// idmAcceleration 计算IDM加速度
+// speed、desiredSpeed 单位 m/s；gap 为与前车的净间距（米），无前车时为 +Inf；
+// approachRate 为与前车的速度差（本车减前车，m/s）
+func idmAcceleration(params IDMParams, speed, desiredSpeed, gap, approachRate float64) float64 {
+	desiredSpeed = math.Max(desiredSpeed, 0.1)
+	freeRoad := 1 - math.Pow(speed/desiredSpeed, params.Delta)
+
+	acceleration := params.MaxAcceleration * freeRoad
+	if !math.IsInf(gap, 1) {
+		desiredGap := params.MinGap + math.Max(0,
+			speed*params.TimeHeadway+speed*approachRate/(2*math.Sqrt(params.MaxAcceleration*params.ComfortDecel)))
+		gap = math.Max(gap, 0.1)
+		acceleration = params.MaxAcceleration * (freeRoad - (desiredGap/gap)*(desiredGap/gap))
+	}
+
+	return math.Max(acceleration, -maxDeceleration)
+}
+
+// computeAccelerations 基于同一时刻的状态计算路网上每辆车的跟驰加速度
+func (s *TrafficService) computeAccelerations() []float64 {
+	accelerations := make([]float64, len(s.vehicles))
+
+	for _, indices := range s.buildLaneIndex() {
+		for k, idx := range indices {
+			leader := -1
+			if k+1 < len(indices) {
+				leader = indices[k+1]
+			}
+			accelerations[idx] = s.accelerationInLane(idx, s.vehicles[idx].Lane, leader)
+		}
+	}
+
+	return accelerations
+}
+
+// accelerationInLane 计算车辆在指定车道、跟随指定前车（-1表示无前车）时的加速度
+func (s *TrafficService) accelerationInLane(idx, lane, leader int) float64 {
+	vehicle := &s.vehicles[idx]
+	params := s.idmParamsFor(vehicle.VehicleType)
+	speed := vehicle.Speed / 3.6
+
+	gap := math.Inf(1)
+	approachRate := 0.0
+	if leader >= 0 {
+		leaderVehicle := &s.vehicles[leader]
+		gap = leaderVehicle.Offset - s.idmParamsFor(leaderVehicle.VehicleType).Length - vehicle.Offset
+		approachRate = speed - leaderVehicle.Speed/3.6
+	}
+
+	// 车道结束或信号灯要求停车时，停车线视为静止障碍物
+	endGap := s.network.SegmentLength(vehicle.RoadID) - vehicle.Offset
+	if lane >= s.network.ContinuingLanes(vehicle.RoadID) || s.mustStopAtSignal(vehicle.RoadID, speed, endGap, params) {
+		if endGap < gap {
+			gap = endGap
+			approachRate = speed
+		}
+	}
+
+	// 封闭车道的事件位置同样视为静止障碍物
+	if distance, ok := s.incidentAhead(vehicle.RoadID, lane, vehicle.Offset); ok && distance < gap {
+		gap = distance
+		approachRate = speed
+	}
+
+	// 公交车停站期间制动至静止，下一停靠站视为静止障碍物，使车辆停在站点处
+	if trip, line, ok := s.transitTripFor(vehicle); ok {
+		if trip.Dwelling {
+			return -speed / s.clock.StepSeconds()
+		}
+		if distance, ok := s.distanceToStop(vehicle, trip, line); ok && distance >= 0 && distance+params.MinGap < gap {
+			gap = distance + params.MinGap
+			approachRate = speed
+		}
+	}
+
+	desiredSpeed := s.desiredSpeed(vehicle) * s.incidentSpeedFactor(vehicle.RoadID, vehicle.Offset)
+	return idmAcceleration(params, speed, desiredSpeed, gap, approachRate)
+}
+
+// applyAcceleration 按加速度更新车速，返回本步行驶距离（米）
+func (s *TrafficService) applyAcceleration(vehicle *models.Vehicle, acceleration, dt float64) float64 {
+	speed := vehicle.Speed / 3.6
+	newSpeed := speed + acceleration*dt
+
+	var distance float64
+	if newSpeed < 0 {
+		// 本步内停车
+		distance = speed * speed / (2 * -acceleration)
+		newSpeed = 0
+	} else {
+		distance = (speed + newSpeed) / 2 * dt
+	}
+
+	// 不超过车型最高车速
+	if maxSpeed := s.profileFor(vehicle.VehicleType).MaxSpeed / 3.6; newSpeed > maxSpeed {
+		distance = (speed + maxSpeed) / 2 * dt
+		newSpeed = maxSpeed
+	}
+
+	vehicle.Acceleration = acceleration
+	vehicle.Speed = newSpeed * 3.6
+	return distance
+}
+
+// desiredSpeed 车辆在当前路段和天气下的期望速度 (m/s)
+func (s *TrafficService) desiredSpeed(vehicle *models.Vehicle) float64 {
+	return freeFlowSpeed(s.network, vehicle.RoadID, s.profileFor(vehicle.VehicleType)) * s.weatherEffect().SpeedFactor
+}
+
+// freeFlowSpeed 车型在路段上的自由流速度 (m/s)：路段限速按车型系数折算，且不超过车型最高车速
+func freeFlowSpeed(network *algorithms.RoadGraph, roadID uint, profile VehicleProfile) float64 {
+	return math.Min(roadSpeedLimit(network, roadID)*profile.DesiredSpeedFactor, profile.MaxSpeed/3.6)
+}
+
+// roadSpeedLimit 获取路段限速 (m/s)
+func roadSpeedLimit(network *algorithms.RoadGraph, roadID uint) float64 {
+	road, ok := network.Segment(roadID)
+	if !ok || road.MaxSpeed <= 0 {
+		return 60 / 3.6
+	}
+	return float64(road.MaxSpeed) / 3.6
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"backend/models"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestIDMAcceleration(t *testing.T) {
+	params := defaultVehicleProfiles()[defaultVehicleType].IDMParams
+	desired := 50 / 3.6
+
+	// 静止且前方无车时以最大加速度起步，达到期望速度后不再加速
+	if a := idmAcceleration(params, 0, desired, math.Inf(1), 0); a != params.MaxAcceleration {
+		t.Fatalf("start from rest = %v, want %v", a, params.MaxAcceleration)
+	}
+	if a := idmAcceleration(params, desired, desired, math.Inf(1), 0); !near(a, 0, 1e-12) {
+		t.Fatalf("at desired speed = %v, want 0", a)
+	}
+	// 静止排队时间距等于最小间距，保持静止
+	if a := idmAcceleration(params, 0, desired, params.MinGap, 0); !near(a, 0, 1e-12) {
+		t.Fatalf("standing queue = %v, want 0", a)
+	}
+	// 跟驰时接近前车越快减速越大
+	slow := idmAcceleration(params, desired, desired, 40, 0)
+	fast := idmAcceleration(params, desired, desired, 40, 5)
+	if !(fast < slow && slow < 0) {
+		t.Fatalf("approaching faster = %v, steady = %v", fast, slow)
+	}
+	// 减速度不超过物理极限
+	if a := idmAcceleration(params, desired, desired, 0.5, desired); a != -maxDeceleration {
+		t.Fatalf("emergency braking = %v, want %v", a, -maxDeceleration)
+	}
+}
+
+func TestApplyAcceleration(t *testing.T) {
+	s := newNetworkService(t)
+
+	// 本步内停车时只行驶到停止为止
+	vehicle := models.Vehicle{VehicleType: "car", Speed: 36}
+	if distance := s.applyAcceleration(&vehicle, -5, 3); !near(distance, 10, 1e-9) || vehicle.Speed != 0 {
+		t.Fatalf("stopping: distance %v speed %v, want 10 and 0", distance, vehicle.Speed)
+	}
+
+	// 不超过车型最高车速
+	truck := defaultVehicleProfiles()["truck"]
+	vehicle = models.Vehicle{VehicleType: "truck", Speed: truck.MaxSpeed}
+	s.applyAcceleration(&vehicle, 2, 1)
+	if !near(vehicle.Speed, truck.MaxSpeed, 1e-9) {
+		t.Fatalf("truck speed %v exceeds max %v", vehicle.Speed, truck.MaxSpeed)
+	}
+}
+
+// 单车道路段上的车队驶向封闭车道的事件，依次减速停车排队，不会相互穿越
+func TestCarFollowingFormsQueueBehindBlockage(t *testing.T) {
+	s := NewStandaloneTrafficService()
+	scenario := Scenario{
+		Name:      "queue",
+		Roads:     []ScenarioRoad{{ID: 1, StartLng: 116.0, StartLat: 39.0, EndLng: 116.03, EndLat: 39.0, MaxSpeed: 60, Lanes: 1}},
+		Incidents: []Incident{{ID: "block", RoadID: 1, Position: 2000, Lanes: []int{0}}},
+	}
+	for i := 0; i < 6; i++ {
+		scenario.Vehicles = append(scenario.Vehicles, ScenarioVehicle{
+			VehicleID: fmt.Sprintf("Q%d", i), RoadID: 1, Offset: float64(i) * 60, Speed: 50,
+		})
+	}
+	if err := s.LoadScenario(scenario); err != nil {
+		t.Fatalf("load scenario: %v", err)
+	}
+
+	for step := 0; step < 600; step++ {
+		stepService(t, s, 1)
+		indices := s.buildLaneIndex()[laneKey{roadID: 1, lane: 0}]
+		for k := 1; k < len(indices); k++ {
+			follower, leader := s.vehicles[indices[k-1]], s.vehicles[indices[k]]
+			if gap := leader.Offset - s.idmParamsFor(leader.VehicleType).Length - follower.Offset; gap < 0 {
+				t.Fatalf("step %d: %s overlaps %s by %.2f m", step, follower.VehicleID, leader.VehicleID, -gap)
+			}
+		}
+	}
+
+	if len(s.vehicles) != 6 {
+		t.Fatalf("%d vehicles left, want 6", len(s.vehicles))
+	}
+	params := s.idmParamsFor(defaultVehicleType)
+	for _, vehicle := range s.vehicles {
+		if vehicle.Speed > 0.5 || vehicle.Offset > 2000 {
+			t.Fatalf("%s at %.1f m with %.1f km/h, want stopped before the blockage", vehicle.VehicleID, vehicle.Offset, vehicle.Speed)
+		}
+	}
+	// 排队长度约为车长与最小间距之和乘以车辆数
+	tail := 2000.0
+	for _, vehicle := range s.vehicles {
+		tail = math.Min(tail, vehicle.Offset)
+	}
+	if queue := 2000 - tail; queue > 6*(params.Length+params.MinGap)+5 {
+		t.Fatalf("queue length %.1f m", queue)
+	}
+}
@@ -0,0 +1,408 @@
+package services
+
+import (
+	"backend/algorithms"
+	"backend/models"
+	"backend/repositories"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// snapshotVersion 快照格式版本
+const snapshotVersion = 1
+
+// checkpointNamePattern 检查点名称，同时用作文件名
+var checkpointNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,100}$`)
+
+// SimulationSnapshot 完整模拟状态，恢复后从保存时刻继续且结果与不中断运行一致
+type SimulationSnapshot struct {
+	Version        int                   `json:"version"`
+	Scenario       Scenario              `json:"scenario"` // 路网、需求、信号配时方案
+	Clock          SimulationClock       `json:"clock"`
+	Seed           int64                 `json:"seed"`
+	RandomState    []byte                `json:"random_state"`     // 随机源内部状态
+	GPSRandomState []byte                `json:"gps_random_state"` // GPS误差随机源内部状态
+	Vehicles       []models.Vehicle      `json:"vehicles"`
+	Departures     []models.Vehicle      `json:"departures"` // 等待进入路网的车辆
+	Alerts         []models.TrafficAlert `json:"alerts"`
+	Signals        []SignalRuntime       `json:"signals"`
+	Incidents      []Incident            `json:"incidents"` // 含生效状态
+	DemandStats    DemandStats           `json:"demand_stats"`
+	Flow           []FlowRecord          `json:"flow"` // 15分钟流量记录
+	Trips          []TripRecord          `json:"trips"`
+	TripStats      TripStats             `json:"trip_stats"`
+	Emissions      EmissionTotals        `json:"emissions"`
+	EmissionLog    []EmissionRecord      `json:"emission_records"` // 1分钟排放记录
+	Rerouting      ReroutingStats        `json:"rerouting_stats"`
+	GPSEmission    GPSEmissionStats      `json:"gps_emission_stats"`
+	GPSPending     []GPSSample           `json:"gps_pending"` // 等待送达的GPS点
+	NextVehicleID  uint                  `json:"next_vehicle_id"`
+	NextIncident   int                   `json:"next_incident"`
+	NextAlertID    uint                  `json:"next_alert_id"`
+	AlertCounts    map[string]int        `json:"alert_counts"`
+	TransitLines   []TransitLine         `json:"transit_lines"` // 含已发班次数
+	TransitTrips   []TransitTrip         `json:"transit_trips"`
+	StopEvents     []StopEvent           `json:"stop_events"`
+}
+
+// CheckpointInfo 检查点摘要
+type CheckpointInfo struct {
+	Name      string    `json:"name"`
+	SimTime   time.Time `json:"sim_time"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CheckpointStore 检查点存储
+type CheckpointStore interface {
+	Save(name string, snapshot SimulationSnapshot) error
+	Load(name string) (SimulationSnapshot, error)
+	List() ([]CheckpointInfo, error)
+	Delete(name string) error
+}
+
+// validateCheckpointName 校验检查点名称
+func validateCheckpointName(name string) error {
+	if !checkpointNamePattern.MatchString(name) {
+		return errors.New("checkpoint name may only contain letters, digits, '.', '_' and '-'")
+	}
+	return nil
+}
+
+// Snapshot 保存当前完整模拟状态，运行中调用时等待当前步长完成，取两步之间的状态
+func (s *TrafficService) Snapshot() (SimulationSnapshot, error) {
+	s.stepMu.Lock()
+	defer s.stepMu.Unlock()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.replay != nil {
+		return SimulationSnapshot{}, errors.New("snapshots are not available during a replay")
+	}
+
+	randomState, err := s.rngSource.MarshalBinary()
+	if err != nil {
+		return SimulationSnapshot{}, err
+	}
+	gpsRandomState, err := s.gpsRngSource.MarshalBinary()
+	if err != nil {
+		return SimulationSnapshot{}, err
+	}
+
+	scenario := s.exportScenario()
+	scenario.Vehicles = nil
+	scenario.Incidents = nil
+	scenario.Transit = nil
+
+	departures := make([]models.Vehicle, 0, len(s.departures))
+	for _, departure := range s.departures {
+		departures = append(departures, departure.vehicle)
+	}
+
+	return SimulationSnapshot{
+		Version:        snapshotVersion,
+		Scenario:       scenario,
+		Clock:          *s.clock,
+		Seed:           s.seed,
+		RandomState:    randomState,
+		GPSRandomState: gpsRandomState,
+		Vehicles:       append([]models.Vehicle(nil), s.vehicles...),
+		Departures:     departures,
+		Alerts:         append([]models.TrafficAlert(nil), s.alerts...),
+		Signals:        s.signals.Runtime(),
+		Incidents:      append([]Incident(nil), s.incidents...),
+		DemandStats:    s.demandStats,
+		Flow:           append([]FlowRecord(nil), s.flowRecords...),
+		Trips:          append([]TripRecord(nil), s.trips...),
+		TripStats:      s.tripStats,
+		Emissions:      s.emissionTotals.clone(),
+		EmissionLog:    cloneEmissionRecords(s.emissionRecords),
+		Rerouting:      s.reroutingStats,
+		GPSEmission:    s.gpsStats,
+		GPSPending:     append([]GPSSample(nil), s.gpsPending...),
+		NextVehicleID:  s.nextVehicleID,
+		NextIncident:   s.nextIncidentID,
+		NextAlertID:    s.nextAlertID,
+		AlertCounts:    maps.Clone(s.alertCounts),
+		TransitLines:   append([]TransitLine(nil), s.transitLines...),
+		TransitTrips:   s.sortedTransitTrips(),
+		StopEvents:     append([]StopEvent(nil), s.stopEvents...),
+	}, nil
+}
+
+// Restore 从快照恢复模拟状态，模拟运行中不可用
+// 恢复后启动模拟时若不指定种子，沿用快照中的随机状态
+func (s *TrafficService) Restore(snapshot SimulationSnapshot) error {
+	if snapshot.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", snapshot.Version)
+	}
+	return s.loadScenario(snapshot.Scenario, &snapshot)
+}
+
+// validate 校验快照中的运行状态，返回恢复后的模拟随机源和GPS误差随机源
+func (snapshot *SimulationSnapshot) validate(network *algorithms.RoadGraph, signals *SignalManager, profiles map[string]VehicleProfile) (*rand.PCG, *rand.PCG, error) {
+	if snapshot.Clock.Step < minTimeStep || snapshot.Clock.Step > maxTimeStep || snapshot.Clock.Speed < 0 {
+		return nil, nil, errors.New("invalid clock in snapshot")
+	}
+
+	for _, vehicles := range [][]models.Vehicle{snapshot.Vehicles, snapshot.Departures} {
+		for _, vehicle := range vehicles {
+			if vehicle.RoadID == 0 {
+				continue
+			}
+			if _, ok := network.Segment(vehicle.RoadID); !ok {
+				return nil, nil, fmt.Errorf("vehicle %s: road %d not found", vehicle.VehicleID, vehicle.RoadID)
+			}
+		}
+	}
+
+	if err := signals.checkRuntime(snapshot.Signals); err != nil {
+		return nil, nil, err
+	}
+
+	for i := range snapshot.Incidents {
+		if err := validateIncident(&snapshot.Incidents[i], network); err != nil {
+			return nil, nil, fmt.Errorf("incident %s: %w", snapshot.Incidents[i].ID, err)
+		}
+	}
+
+	stopCounts := make(map[string]int)
+	for i := range snapshot.TransitLines {
+		line := &snapshot.TransitLines[i]
+		if err := validateTransitLine(line, network, profiles); err != nil {
+			return nil, nil, fmt.Errorf("transit line %s: %w", line.ID, err)
+		}
+		stopCounts[line.ID] = len(line.Stops)
+	}
+	for _, trip := range snapshot.TransitTrips {
+		count, ok := stopCounts[trip.LineID]
+		if !ok {
+			return nil, nil, fmt.Errorf("transit trip %s: line %s not found", trip.VehicleID, trip.LineID)
+		}
+		if trip.NextStop < 0 || trip.NextStop > count {
+			return nil, nil, fmt.Errorf("transit trip %s: invalid next stop %d", trip.VehicleID, trip.NextStop)
+		}
+	}
+
+	source := &rand.PCG{}
+	if err := source.UnmarshalBinary(snapshot.RandomState); err != nil {
+		return nil, nil, fmt.Errorf("invalid random state: %w", err)
+	}
+	// 早期快照没有GPS误差随机源，按种子重新生成
+	gpsSource := gpsRandomSource(snapshot.Seed)
+	if len(snapshot.GPSRandomState) > 0 {
+		if err := gpsSource.UnmarshalBinary(snapshot.GPSRandomState); err != nil {
+			return nil, nil, fmt.Errorf("invalid GPS random state: %w", err)
+		}
+	}
+	return source, gpsSource, nil
+}
+
+// applySnapshot 用快照覆盖场景加载后的运行状态
+func (s *TrafficService) applySnapshot(snapshot *SimulationSnapshot, source, gpsSource *rand.PCG) {
+	clock := snapshot.Clock
+	s.clock = &clock
+	s.seed = snapshot.Seed
+	s.rngSource = source
+	s.rng = rand.New(source)
+	s.gpsRngSource = gpsSource
+	s.gpsRng = rand.New(gpsSource)
+	s.keepRandomState = true
+
+	s.vehicles = append(make([]models.Vehicle, 0, len(snapshot.Vehicles)), snapshot.Vehicles...)
+	s.departures = make([]pendingDeparture, 0, len(snapshot.Departures))
+	for _, vehicle := range snapshot.Departures {
+		s.departures = append(s.departures, pendingDeparture{vehicle: vehicle})
+	}
+	s.alerts = append(make([]models.TrafficAlert, 0, len(snapshot.Alerts)), snapshot.Alerts...)
+	s.signals.restoreRuntime(snapshot.Signals)
+	s.incidents = append(make([]Incident, 0, len(snapshot.Incidents)), snapshot.Incidents...)
+	s.demandStats = snapshot.DemandStats
+	s.flowRecords = append([]FlowRecord(nil), snapshot.Flow...)
+	s.trips = append([]TripRecord(nil), snapshot.Trips...)
+	s.tripStats = snapshot.TripStats
+	s.emissionTotals = snapshot.Emissions.clone()
+	s.emissionRecords = cloneEmissionRecords(snapshot.EmissionLog)
+	s.reroutingStats = snapshot.Rerouting
+	s.gpsStats = snapshot.GPSEmission
+	s.gpsPending = append([]GPSSample(nil), snapshot.GPSPending...)
+	s.nextVehicleID = snapshot.NextVehicleID
+	s.nextIncidentID = snapshot.NextIncident
+	s.nextAlertID = snapshot.NextAlertID
+	s.alertCounts = maps.Clone(snapshot.AlertCounts)
+	s.transitLines = append(make([]TransitLine, 0, len(snapshot.TransitLines)), snapshot.TransitLines...)
+	s.transitTrips = make(map[string]*TransitTrip, len(snapshot.TransitTrips))
+	for _, trip := range snapshot.TransitTrips {
+		s.transitTrips[trip.VehicleID] = &trip
+	}
+	s.stopEvents = append([]StopEvent(nil), snapshot.StopEvents...)
+}
+
+// FileCheckpointStore 以JSON文件保存检查点
+type FileCheckpointStore struct {
+	Dir string
+}
+
+// NewFileCheckpointStore 创建文件检查点存储
+func NewFileCheckpointStore(dir string) *FileCheckpointStore {
+	return &FileCheckpointStore{Dir: dir}
+}
+
+func (f *FileCheckpointStore) path(name string) string {
+	return filepath.Join(f.Dir, name+".json")
+}
+
+// Save 保存检查点，同名检查点被覆盖
+func (f *FileCheckpointStore) Save(name string, snapshot SimulationSnapshot) error {
+	if err := validateCheckpointName(name); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(f.Dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	// 先写临时文件再改名，避免进程中断留下不完整的检查点
+	tmp := f.path(name) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path(name))
+}
+
+// Load 读取检查点
+func (f *FileCheckpointStore) Load(name string) (SimulationSnapshot, error) {
+	if err := validateCheckpointName(name); err != nil {
+		return SimulationSnapshot{}, err
+	}
+
+	data, err := os.ReadFile(f.path(name))
+	if err != nil {
+		return SimulationSnapshot{}, err
+	}
+
+	var snapshot SimulationSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return SimulationSnapshot{}, fmt.Errorf("parse checkpoint %s: %w", name, err)
+	}
+	return snapshot, nil
+}
+
+// List 列出检查点，按创建时间倒序
+func (f *FileCheckpointStore) List() ([]CheckpointInfo, error) {
+	entries, err := os.ReadDir(f.Dir)
+	if os.IsNotExist(err) {
+		return []CheckpointInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]CheckpointInfo, 0, len(entries))
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if entry.IsDir() || !ok {
+			continue
+		}
+		snapshot, err := f.Load(name)
+		if err != nil {
+			continue
+		}
+		fileInfo, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		infos = append(infos, CheckpointInfo{
+			Name:      name,
+			SimTime:   snapshot.Clock.Now(),
+			CreatedAt: fileInfo.ModTime(),
+		})
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].CreatedAt.After(infos[j].CreatedAt) })
+	return infos, nil
+}
+
+// Delete 删除检查点
+func (f *FileCheckpointStore) Delete(name string) error {
+	if err := validateCheckpointName(name); err != nil {
+		return err
+	}
+	return os.Remove(f.path(name))
+}
+
+// DatabaseCheckpointStore 在数据库中保存检查点
+type DatabaseCheckpointStore struct {
+	repo *repositories.CheckpointRepository
+}
+
+// NewDatabaseCheckpointStore 创建数据库检查点存储
+func NewDatabaseCheckpointStore() *DatabaseCheckpointStore {
+	return &DatabaseCheckpointStore{repo: repositories.NewCheckpointRepository()}
+}
+
+// Save 保存检查点，同名检查点被覆盖
+func (d *DatabaseCheckpointStore) Save(name string, snapshot SimulationSnapshot) error {
+	if err := validateCheckpointName(name); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	return d.repo.Save(&models.SimulationCheckpoint{
+		Name:    name,
+		SimTime: snapshot.Clock.Now(),
+		Data:    string(data),
+	})
+}
+
+// Load 读取检查点
+func (d *DatabaseCheckpointStore) Load(name string) (SimulationSnapshot, error) {
+	checkpoint, err := d.repo.GetByName(name)
+	if err != nil {
+		return SimulationSnapshot{}, err
+	}
+
+	var snapshot SimulationSnapshot
+	if err := json.Unmarshal([]byte(checkpoint.Data), &snapshot); err != nil {
+		return SimulationSnapshot{}, fmt.Errorf("parse checkpoint %s: %w", name, err)
+	}
+	return snapshot, nil
+}
+
+// List 列出检查点，按创建时间倒序
+func (d *DatabaseCheckpointStore) List() ([]CheckpointInfo, error) {
+	checkpoints, err := d.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]CheckpointInfo, 0, len(checkpoints))
+	for _, checkpoint := range checkpoints {
+		infos = append(infos, CheckpointInfo{
+			Name:      checkpoint.Name,
+			SimTime:   checkpoint.SimTime,
+			CreatedAt: checkpoint.CreatedAt,
+		})
+	}
+	return infos, nil
+}
+
+// Delete 删除检查点
+func (d *DatabaseCheckpointStore) Delete(name string) error {
+	return d.repo.Delete(name)
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"backend/models"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// stubGPSSink 接收模拟GPS点，不做处理
+type stubGPSSink struct{}
+
+func (stubGPSSink) IngestGPSData(gpsData *models.GPSData) (*GPSIngestResult, error) {
+	return &GPSIngestResult{}, nil
+}
+
+// checkpointScenario 含需求、信号、公交和GPS上报的小路网场景
+func checkpointScenario() Scenario {
+	seed := int64(42)
+	fastest := 0.0
+	start := time.Date(2024, 5, 1, 7, 0, 0, 0, time.UTC)
+	return Scenario{
+		Name: "checkpoint",
+		Simulation: SimulationOptions{
+			Seed:         &seed,
+			ClockOptions: ClockOptions{StartTime: &start, Speed: &fastest},
+		},
+		Roads: []ScenarioRoad{
+			{ID: 1, StartLng: 116.0, StartLat: 39.0, EndLng: 116.01, EndLat: 39.0, MaxSpeed: 60, Lanes: 2},
+			{ID: 2, StartLng: 116.01, StartLat: 39.0, EndLng: 116.02, EndLat: 39.0, MaxSpeed: 40, Lanes: 2},
+			{ID: 3, StartLng: 116.01, StartLat: 39.01, EndLng: 116.01, EndLat: 39.0, MaxSpeed: 40, Lanes: 1},
+		},
+		Demand: DemandConfig{
+			Zones: []DemandZone{{ID: "A", RoadIDs: []uint{1, 3}}, {ID: "B", RoadIDs: []uint{2}}},
+			Matrix: []ODDemand{{
+				Origin:      "A",
+				Destination: "B",
+				Rate:        1800,
+				VehicleMix:  map[string]float64{"car": 0.8, "truck": 0.2},
+			}},
+		},
+		Signals: []SignalPlan{{
+			Type:   SignalActuated,
+			Phases: []SignalPhase{{RoadIDs: []uint{1}, Green: 30}, {RoadIDs: []uint{3}, Green: 20}},
+		}},
+		Transit: []TransitLine{{
+			ID:      "L1",
+			RoadIDs: []uint{1, 2},
+			Stops:   []TransitStop{{RoadID: 1, Position: 300}, {RoadID: 2, Position: 400}},
+			Headway: 180,
+			Dwell:   DwellTime{Mean: 20, StdDev: 5, Min: 5},
+		}},
+		GPSEmission: GPSEmissionConfig{Enabled: true, Interval: 5},
+	}
+}
+
+func newCheckpointService(t *testing.T) *TrafficService {
+	t.Helper()
+	s := NewStandaloneTrafficService()
+	if err := s.LoadScenario(checkpointScenario()); err != nil {
+		t.Fatalf("load scenario: %v", err)
+	}
+	s.SetGPSSink(stubGPSSink{})
+	return s
+}
+
+func snapshotJSON(t *testing.T, s *TrafficService) string {
+	t.Helper()
+	snapshot, err := s.Snapshot()
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatalf("marshal snapshot: %v", err)
+	}
+	return string(data)
+}
+
+func stepService(t *testing.T, s *TrafficService, steps int) {
+	t.Helper()
+	if err := s.StepSimulation(steps); err != nil {
+		t.Fatalf("step: %v", err)
+	}
+}
+
+// 从检查点恢复后继续运行，结果与不中断运行一致
+func TestRestoreMatchesUninterruptedRun(t *testing.T) {
+	uninterrupted := newCheckpointService(t)
+	stepService(t, uninterrupted, 300)
+	snapshot, err := uninterrupted.Snapshot()
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	stepService(t, uninterrupted, 300)
+
+	// 经JSON往返，与检查点文件一致
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatalf("marshal snapshot: %v", err)
+	}
+	var saved SimulationSnapshot
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("unmarshal snapshot: %v", err)
+	}
+
+	restored := NewStandaloneTrafficService()
+	restored.SetGPSSink(stubGPSSink{})
+	if err := restored.Restore(saved); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	stepService(t, restored, 300)
+
+	if got, want := snapshotJSON(t, restored), snapshotJSON(t, uninterrupted); got != want {
+		t.Fatal("restored run diverged from the uninterrupted run")
+	}
+}
+
+// 运行中保存的检查点位于两步之间，与单步推进到同一时刻的状态一致
+func TestSnapshotWhileRunningIsAtStepBoundary(t *testing.T) {
+	running := newCheckpointService(t)
+	if _, err := running.StartSimulation(SimulationOptions{}); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	var snapshots []SimulationSnapshot
+	for len(snapshots) < 5 {
+		time.Sleep(20 * time.Millisecond)
+		snapshot, err := running.Snapshot()
+		if err != nil {
+			t.Fatalf("snapshot: %v", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	running.StopSimulation()
+
+	reference := newCheckpointService(t)
+	for i, snapshot := range snapshots {
+		for reference.clock.ElapsedSeconds() < snapshot.Clock.ElapsedSeconds() {
+			stepService(t, reference, 1)
+		}
+		got, err := json.Marshal(snapshot)
+		if err != nil {
+			t.Fatalf("marshal snapshot: %v", err)
+		}
+		if want := snapshotJSON(t, reference); string(got) != want {
+			t.Fatalf("snapshot %d at %.0fs is not at a step boundary", i, snapshot.Clock.ElapsedSeconds())
+		}
+	}
+}
+
+// 文件检查点保存后可读取和列出，删除后不再列出，名称不能包含路径
+func TestFileCheckpointStore(t *testing.T) {
+	s := newCheckpointService(t)
+	stepService(t, s, 60)
+	snapshot, err := s.Snapshot()
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+
+	store := NewFileCheckpointStore(filepath.Join(t.TempDir(), "checkpoints"))
+	if infos, err := store.List(); err != nil || len(infos) != 0 {
+		t.Fatalf("empty store: infos = %v, err = %v", infos, err)
+	}
+	if err := store.Save("morning-peak_1", snapshot); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	loaded, err := store.Load("morning-peak_1")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	restored := NewStandaloneTrafficService()
+	if err := restored.Restore(loaded); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if got, want := snapshotJSON(t, restored), snapshotJSON(t, s); got != want {
+		t.Fatal("loaded checkpoint differs from the saved state")
+	}
+
+	infos, err := store.List()
+	if err != nil || len(infos) != 1 || infos[0].Name != "morning-peak_1" || !infos[0].SimTime.Equal(s.clock.Now()) {
+		t.Fatalf("list: infos = %+v, err = %v", infos, err)
+	}
+
+	for _, name := range []string{"../escape", "a/b", ""} {
+		if err := store.Save(name, snapshot); err == nil {
+			t.Fatalf("saved checkpoint with invalid name %q", name)
+		}
+	}
+
+	if err := store.Delete("morning-peak_1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if infos, err := store.List(); err != nil || len(infos) != 0 {
+		t.Fatalf("after delete: infos = %v, err = %v", infos, err)
+	}
+}
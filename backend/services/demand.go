@@ -0,0 +1,353 @@
+package services
+
+import (
+	"backend/algorithms"
+	"backend/models"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strings"
+)
+
+// DemandZone 交通小区，由一组路段组成
+type DemandZone struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	RoadIDs []uint `json:"road_ids"`
+}
+
+// DemandPeriod 时变发车率区间
+type DemandPeriod struct {
+	Start float64 `json:"start"` // 相对模拟开始的时间 (s)
+	End   float64 `json:"end"`   // 相对模拟开始的时间 (s)
+	Rate  float64 `json:"rate"`  // 发车率（车辆/小时）
+}
+
+// ODDemand OD需求，起终点可以是小区ID或节点坐标 "lng,lat"，坐标校验时按5位小数规范化
+type ODDemand struct {
+	ID          string             `json:"id"`
+	Origin      string             `json:"origin"`
+	Destination string             `json:"destination"`
+	Rate        float64            `json:"rate"`              // 默认发车率（车辆/小时）
+	Periods     []DemandPeriod     `json:"periods"`           // 时变发车率，所在区间优先于默认发车率
+	Profile     string             `json:"profile,omitempty"` // 时段需求系数ID，默认发车率按模拟时刻所在时段的系数缩放
+	VehicleMix  map[string]float64 `json:"vehicle_mix"`       // 车型比例，为空时全部为小汽车
+}
+
+// DemandConfig 需求配置
+type DemandConfig struct {
+	Zones    []DemandZone    `json:"zones"`
+	Matrix   []ODDemand      `json:"matrix"`
+	Profiles []DemandProfile `json:"profiles,omitempty"`
+}
+
+// DemandStats 需求统计
+type DemandStats struct {
+	Spawned    int `json:"spawned"`    // 已进入路网车辆数
+	Arrived    int `json:"arrived"`    // 其中已驶离路网的车辆数
+	Queued     int `json:"queued"`     // 等待进入路网车辆数
+	Unroutable int `json:"unroutable"` // 起终点间无可行路径的需求次数
+}
+
+// pendingDeparture 等待进入路网的车辆
+type pendingDeparture struct {
+	vehicle models.Vehicle
+}
+
+// rateAt 获取指定时刻的发车率（车辆/小时），t 为相对模拟开始的时间，factor 为时段需求系数
+func (d *ODDemand) rateAt(t, factor float64) float64 {
+	for _, period := range d.Periods {
+		if t >= period.Start && t < period.End {
+			return period.Rate
+		}
+	}
+	return d.Rate * factor
+}
+
+// validateDemand 校验需求配置
+func validateDemand(config *DemandConfig, network *algorithms.RoadGraph) error {
+	zones := make(map[string]bool)
+	for _, zone := range config.Zones {
+		if zone.ID == "" {
+			return fmt.Errorf("zone id cannot be empty")
+		}
+		if zones[zone.ID] {
+			return fmt.Errorf("duplicate zone %s", zone.ID)
+		}
+		zones[zone.ID] = true
+		for _, roadID := range zone.RoadIDs {
+			if _, ok := network.Segment(roadID); !ok {
+				return fmt.Errorf("zone %s: road %d not found", zone.ID, roadID)
+			}
+		}
+	}
+
+	profiles := make(map[string]bool)
+	for i := range config.Profiles {
+		profile := &config.Profiles[i]
+		if profile.ID == "" {
+			return fmt.Errorf("profile id cannot be empty")
+		}
+		if profiles[profile.ID] {
+			return fmt.Errorf("duplicate profile %s", profile.ID)
+		}
+		profiles[profile.ID] = true
+		if err := validateDemandProfile(profile); err != nil {
+			return err
+		}
+	}
+
+	for i := range config.Matrix {
+		od := &config.Matrix[i]
+		if od.ID == "" {
+			od.ID = fmt.Sprintf("OD%03d", i+1)
+		}
+		if od.Rate < 0 {
+			return fmt.Errorf("od %s: rate cannot be negative", od.ID)
+		}
+		for _, period := range od.Periods {
+			if period.End <= period.Start || period.Rate < 0 {
+				return fmt.Errorf("od %s: invalid period %v-%v", od.ID, period.Start, period.End)
+			}
+		}
+		if od.Profile != "" && !profiles[od.Profile] {
+			return fmt.Errorf("od %s: unknown profile %s", od.ID, od.Profile)
+		}
+		// 节点坐标按 NodeKey 规范化，起点须有驶出路段，终点须有驶入路段
+		if !zones[od.Origin] {
+			node, err := demandNode(od.Origin)
+			if err != nil {
+				return fmt.Errorf("od %s: %w", od.ID, err)
+			}
+			if len(network.OutgoingSegments(node)) == 0 {
+				return fmt.Errorf("od %s: no road starts at origin node %s", od.ID, node)
+			}
+			od.Origin = node
+		}
+		if !zones[od.Destination] {
+			node, err := demandNode(od.Destination)
+			if err != nil {
+				return fmt.Errorf("od %s: %w", od.ID, err)
+			}
+			if len(network.IncomingSegments(node)) == 0 {
+				return fmt.Errorf("od %s: no road ends at destination node %s", od.ID, node)
+			}
+			od.Destination = node
+		}
+	}
+
+	return nil
+}
+
+// demandNode 解析OD起终点中的节点坐标，不是小区ID也不是坐标时报告未知小区
+func demandNode(endpoint string) (string, error) {
+	if !strings.Contains(endpoint, ",") {
+		return "", fmt.Errorf("unknown zone %s", endpoint)
+	}
+	return algorithms.ParseNodeKey(endpoint)
+}
+
+// originRoads 获取起点可发车的路段：小区内路段或以节点为起点的路段
+func (s *TrafficService) originRoads(endpoint string) []uint {
+	for _, zone := range s.demand.Zones {
+		if zone.ID == endpoint {
+			return zone.RoadIDs
+		}
+	}
+	return s.network.OutgoingSegments(endpoint)
+}
+
+// destinationRoads 获取终点可到达的路段：小区内路段或以节点为终点的路段
+func (s *TrafficService) destinationRoads(endpoint string) []uint {
+	for _, zone := range s.demand.Zones {
+		if zone.ID == endpoint {
+			return zone.RoadIDs
+		}
+	}
+	return s.network.IncomingSegments(endpoint)
+}
+
+// generateDemand 按OD矩阵生成本步出发的车辆，发车服从泊松过程
+func (s *TrafficService) generateDemand(dt float64) {
+	for i := range s.demand.Matrix {
+		od := &s.demand.Matrix[i]
+		rate := od.rateAt(s.clock.ElapsedSeconds(), s.demand.profileFactor(od.Profile, s.clock.Now()))
+		count := poisson(s.rng, rate/3600*dt)
+		for n := 0; n < count; n++ {
+			origins := s.originRoads(od.Origin)
+			destinations := s.destinationRoads(od.Destination)
+			if len(origins) == 0 || len(destinations) == 0 {
+				s.demandStats.Unroutable++
+				continue
+			}
+
+			origin := origins[s.rng.IntN(len(origins))]
+			destination := destinations[s.rng.IntN(len(destinations))]
+			route := s.network.ShortestPath(origin, destination)
+			if route == nil {
+				s.demandStats.Unroutable++
+				continue
+			}
+
+			s.nextVehicleID++
+			vehicle := models.Vehicle{
+				ID:          s.nextVehicleID,
+				VehicleID:   fmt.Sprintf("D%06d", s.nextVehicleID),
+				VehicleType: pickVehicleType(s.rng, od.VehicleMix),
+				Status:      "normal",
+				RoadID:      origin,
+				Route:       route,
+			}
+			s.assignInformed(&vehicle)
+			s.departures = append(s.departures, pendingDeparture{vehicle: vehicle})
+		}
+	}
+
+	s.releaseDepartures()
+}
+
+// releaseDepartures 将等待车辆放入路网，入口车道空间不足的车辆继续排队
+// 同一起点路段按先到先发，队首受阻时其后车辆本步不再尝试
+func (s *TrafficService) releaseDepartures() {
+	blocked := make(map[uint]bool)
+	remaining := s.departures[:0]
+	for _, departure := range s.departures {
+		vehicle := departure.vehicle
+		if blocked[vehicle.RoadID] || !s.enterNetwork(&vehicle) {
+			blocked[vehicle.RoadID] = true
+			remaining = append(remaining, departure)
+			continue
+		}
+
+		// 公交车从首站静止发车
+		if _, ok := s.transitTrips[vehicle.VehicleID]; ok {
+			vehicle.Speed = 0
+		}
+		s.departVehicle(&vehicle)
+		vehicle.Released = true
+		s.vehicles = append(s.vehicles, vehicle)
+		s.demandStats.Spawned++
+	}
+	s.departures = remaining
+	s.demandStats.Queued = len(s.departures)
+}
+
+// enterNetwork 在起点路段选择入口空间最大的车道，空间不足时返回false
+func (s *TrafficService) enterNetwork(vehicle *models.Vehicle) bool {
+	params := s.idmParamsFor(vehicle.VehicleType)
+	desiredSpeed := s.desiredSpeed(vehicle)
+
+	bestLane, bestGap, bestSpeed := -1, 0.0, 0.0
+	for lane := 0; lane <= s.highestAllowedLane(vehicle.VehicleType, vehicle.RoadID); lane++ {
+		gap, leaderSpeed := s.network.SegmentLength(vehicle.RoadID), desiredSpeed
+		for i := range s.vehicles {
+			other := &s.vehicles[i]
+			if other.RoadID != vehicle.RoadID || other.Lane != lane {
+				continue
+			}
+			if g := other.Offset - s.idmParamsFor(other.VehicleType).Length; g < gap {
+				gap = g
+				leaderSpeed = other.Speed / 3.6
+			}
+		}
+		if gap > bestGap {
+			bestLane, bestGap, bestSpeed = lane, gap, leaderSpeed
+		}
+	}
+
+	if bestLane < 0 || bestGap < params.MinGap+params.Length {
+		return false
+	}
+
+	vehicle.Lane = bestLane
+	vehicle.Offset = 0
+	vehicle.Speed = math.Min(desiredSpeed, bestSpeed) * 3.6
+	s.syncPosition(vehicle)
+	return true
+}
+
+// pickVehicleType 按车型比例随机选择车型
+func pickVehicleType(rng *rand.Rand, mix map[string]float64) string {
+	if len(mix) == 0 {
+		return defaultVehicleType
+	}
+
+	types := make([]string, 0, len(mix))
+	total := 0.0
+	for vehicleType, share := range mix {
+		types = append(types, vehicleType)
+		total += share
+	}
+	sort.Strings(types)
+
+	r := rng.Float64() * total
+	for _, vehicleType := range types {
+		r -= mix[vehicleType]
+		if r < 0 {
+			return vehicleType
+		}
+	}
+	return types[len(types)-1]
+}
+
+// poissonNormalThreshold 均值超过该值时泊松分布按正态分布近似
+const poissonNormalThreshold = 30
+
+// poisson 生成泊松分布随机数
+// 均值较小时用逐次相乘法；均值较大时 exp(-lambda) 下溢且循环次数随均值增长，改用正态近似
+func poisson(rng *rand.Rand, lambda float64) int {
+	if lambda <= 0 {
+		return 0
+	}
+	if lambda > poissonNormalThreshold {
+		return max(int(math.Round(lambda+math.Sqrt(lambda)*rng.NormFloat64())), 0)
+	}
+
+	limit := math.Exp(-lambda)
+	count := 0
+	p := rng.Float64()
+	for p > limit {
+		count++
+		p *= rng.Float64()
+	}
+	return count
+}
+
+// GetDemand 获取需求配置
+func (s *TrafficService) GetDemand() DemandConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.demand
+}
+
+// SetDemand 设置需求配置，尚未进入路网的需求车辆被清空，公交班次保留
+func (s *TrafficService) SetDemand(config DemandConfig) (DemandConfig, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := validateDemand(&config, s.network); err != nil {
+		return DemandConfig{}, err
+	}
+	if err := checkVehicleMix(&config, s.profiles); err != nil {
+		return DemandConfig{}, err
+	}
+
+	s.demand = config
+	remaining := s.departures[:0]
+	for _, departure := range s.departures {
+		if _, ok := s.transitTrips[departure.vehicle.VehicleID]; ok {
+			remaining = append(remaining, departure)
+		}
+	}
+	s.departures = remaining
+	s.demandStats.Queued = len(s.departures)
+	return config, nil
+}
+
+// GetDemandStats 获取需求统计
+func (s *TrafficService) GetDemandStats() DemandStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.demandStats
+}
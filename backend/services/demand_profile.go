@@ -0,0 +1,79 @@
+package services
+
+import (
+	"fmt"
+	"time"
+)
+
+// profileIntervals 需求系数时段数，一天按15分钟划分
+const profileIntervals = 96
+
+// 日期类型
+const (
+	DayTypeWeekday  = "weekday"
+	DayTypeSaturday = "saturday"
+	DayTypeSunday   = "sunday"
+	DayTypeWeekend  = "weekend" // 周六、周日未单独配置时使用
+	DayTypeDefault  = "default" // 对应日期类型未配置时使用
+)
+
+// DemandProfile 时段需求系数，按日期类型给出一天96个15分钟时段的发车率系数
+type DemandProfile struct {
+	ID      string               `json:"id"`
+	Name    string               `json:"name"`
+	Factors map[string][]float64 `json:"factors"` // 日期类型 -> 96个时段系数
+}
+
+// validateDemandProfile 校验时段需求系数
+func validateDemandProfile(profile *DemandProfile) error {
+	if len(profile.Factors) == 0 {
+		return fmt.Errorf("profile %s: factors cannot be empty", profile.ID)
+	}
+	for dayType, factors := range profile.Factors {
+		switch dayType {
+		case DayTypeWeekday, DayTypeSaturday, DayTypeSunday, DayTypeWeekend, DayTypeDefault:
+		default:
+			return fmt.Errorf("profile %s: unknown day type %s, expected weekday, saturday, sunday, weekend or default", profile.ID, dayType)
+		}
+		if len(factors) != profileIntervals {
+			return fmt.Errorf("profile %s: %s needs %d factors, got %d", profile.ID, dayType, profileIntervals, len(factors))
+		}
+		for _, factor := range factors {
+			if factor < 0 {
+				return fmt.Errorf("profile %s: factors cannot be negative", profile.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// dayTypes 日期对应的日期类型，按优先顺序排列
+func dayTypes(t time.Time) []string {
+	switch t.Weekday() {
+	case time.Saturday:
+		return []string{DayTypeSaturday, DayTypeWeekend, DayTypeDefault}
+	case time.Sunday:
+		return []string{DayTypeSunday, DayTypeWeekend, DayTypeDefault}
+	}
+	return []string{DayTypeWeekday, DayTypeDefault}
+}
+
+// factorAt 获取指定时刻所在时段的系数，对应日期类型未配置时为1
+func (p *DemandProfile) factorAt(t time.Time) float64 {
+	for _, dayType := range dayTypes(t) {
+		if factors, ok := p.Factors[dayType]; ok {
+			return factors[(t.Hour()*60+t.Minute())/15]
+		}
+	}
+	return 1
+}
+
+// profileFactor 获取需求系数，未指定时段需求系数的OD为1
+func (config *DemandConfig) profileFactor(profileID string, t time.Time) float64 {
+	for i := range config.Profiles {
+		if config.Profiles[i].ID == profileID {
+			return config.Profiles[i].factorAt(t)
+		}
+	}
+	return 1
+}
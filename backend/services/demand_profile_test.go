@@ -0,0 +1,140 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// constantFactors 96个时段系数均为 factor，index 时段为 peak
+func constantFactors(factor float64, index int, peak float64) []float64 {
+	factors := make([]float64, profileIntervals)
+	for i := range factors {
+		factors[i] = factor
+	}
+	factors[index] = peak
+	return factors
+}
+
+// 按日期类型取系数：周六优先 saturday，其次 weekend、default，均未配置时为1
+func TestDemandProfileFactorAt(t *testing.T) {
+	profile := DemandProfile{ID: "P", Factors: map[string][]float64{
+		DayTypeWeekday: constantFactors(1, 32, 2.5),
+		DayTypeWeekend: constantFactors(0.5, 0, 0.5),
+	}}
+	tests := []struct {
+		at   time.Time
+		want float64
+	}{
+		{time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC), 2.5},   // 周三 08:00 为第32个时段
+		{time.Date(2024, 5, 1, 8, 14, 59, 0, time.UTC), 2.5}, // 同一时段
+		{time.Date(2024, 5, 1, 8, 15, 0, 0, time.UTC), 1},
+		{time.Date(2024, 5, 4, 8, 0, 0, 0, time.UTC), 0.5}, // 周六
+		{time.Date(2024, 5, 5, 8, 0, 0, 0, time.UTC), 0.5}, // 周日
+	}
+	for _, tt := range tests {
+		if got := profile.factorAt(tt.at); got != tt.want {
+			t.Fatalf("factorAt(%v) = %v, want %v", tt.at, got, tt.want)
+		}
+	}
+
+	profile.Factors = map[string][]float64{DayTypeSaturday: constantFactors(0.8, 0, 0.8)}
+	if got := profile.factorAt(time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)); got != 1 {
+		t.Fatalf("weekday without factors = %v, want 1", got)
+	}
+	profile.Factors[DayTypeDefault] = constantFactors(0.3, 0, 0.3)
+	if got := profile.factorAt(time.Date(2024, 5, 5, 8, 0, 0, 0, time.UTC)); got != 0.3 {
+		t.Fatalf("sunday falls back to default: got %v", got)
+	}
+
+	config := DemandConfig{Profiles: []DemandProfile{profile}}
+	if got := config.profileFactor("missing", time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)); got != 1 {
+		t.Fatalf("unknown profile factor = %v, want 1", got)
+	}
+}
+
+// 非法的时段需求系数和引用不存在的系数的OD被拒绝
+func TestValidateDemandProfiles(t *testing.T) {
+	s := newNetworkService(t)
+	valid := constantFactors(1, 0, 1)
+	tests := []struct {
+		name   string
+		config DemandConfig
+		want   string
+	}{
+		{"empty factors", DemandConfig{Profiles: []DemandProfile{{ID: "P"}}}, "factors cannot be empty"},
+		{"unknown day type", DemandConfig{Profiles: []DemandProfile{{ID: "P", Factors: map[string][]float64{"holiday": valid}}}}, "unknown day type holiday"},
+		{"short factors", DemandConfig{Profiles: []DemandProfile{{ID: "P", Factors: map[string][]float64{DayTypeWeekday: valid[:24]}}}}, "needs 96 factors"},
+		{"negative factor", DemandConfig{Profiles: []DemandProfile{{ID: "P", Factors: map[string][]float64{DayTypeWeekday: constantFactors(1, 5, -1)}}}}, "cannot be negative"},
+		{"missing id", DemandConfig{Profiles: []DemandProfile{{Factors: map[string][]float64{DayTypeWeekday: valid}}}}, "profile id cannot be empty"},
+		{"duplicate id", DemandConfig{Profiles: []DemandProfile{
+			{ID: "P", Factors: map[string][]float64{DayTypeWeekday: valid}},
+			{ID: "P", Factors: map[string][]float64{DayTypeWeekday: valid}},
+		}}, "duplicate profile P"},
+		{"unknown profile", DemandConfig{Matrix: []ODDemand{{Origin: "116.0,39.0", Destination: "116.02,39", Profile: "P"}}}, "unknown profile P"},
+	}
+	for _, tt := range tests {
+		err := validateDemand(&tt.config, s.network)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+// 默认发车率按模拟时刻所在时段的系数缩放，系数为0的时段不发车
+func TestGenerateDemandFollowsProfile(t *testing.T) {
+	// 模拟起始时刻为周三 07:00，即第28个时段
+	generated := func(factor float64) int {
+		s := newNetworkService(t)
+		_, err := s.SetDemand(DemandConfig{
+			Zones:    []DemandZone{{ID: "A", RoadIDs: []uint{1}}, {ID: "B", RoadIDs: []uint{2}}},
+			Matrix:   []ODDemand{{Origin: "A", Destination: "B", Rate: 1800, Profile: "AM"}},
+			Profiles: []DemandProfile{{ID: "AM", Factors: map[string][]float64{DayTypeWeekday: constantFactors(1, 28, factor)}}},
+		})
+		if err != nil {
+			t.Fatalf("set demand: %v", err)
+		}
+		for i := 0; i < 600; i++ {
+			s.generateDemand(1)
+		}
+		stats := s.GetDemandStats()
+		return stats.Spawned + stats.Queued
+	}
+
+	if n := generated(0); n != 0 {
+		t.Fatalf("factor 0 generated %d vehicles", n)
+	}
+	// 600秒内期望600辆，标准差约24
+	if n := generated(2); n < 500 || n > 700 {
+		t.Fatalf("factor 2 generated %d vehicles in 600s, want about 600", n)
+	}
+}
+
+// 流量记录按15分钟连续保存，无车辆的区间补0，模拟时刻回退时清空；高峰小时取流入最多的连续4个区间
+func TestVehicleFlowPeakHourFromRecords(t *testing.T) {
+	s := newNetworkService(t)
+	record := func(minutes, incoming, outgoing int) {
+		s.clock.Elapsed = time.Duration(minutes) * time.Minute
+		s.recordFlow(incoming, outgoing)
+	}
+	for _, r := range [][3]int{{0, 10, 0}, {20, 30, 5}, {50, 40, 10}, {95, 20, 20}, {110, 5, 30}} {
+		record(r[0], r[1], r[2])
+	}
+
+	if len(s.flowRecords) != 8 || s.flowRecords[2].Incoming != 0 || s.flowRecords[3].Incoming != 40 {
+		t.Fatalf("records = %+v", s.flowRecords)
+	}
+	flow := s.GetVehicleFlow()
+	if flow.PeakHour != "07:00-08:00" || flow.PeakHourVolume != 80 || flow.PeakHourFactor != 0.5 {
+		t.Fatalf("peak hour %s volume %d factor %.3f", flow.PeakHour, flow.PeakHourVolume, flow.PeakHourFactor)
+	}
+	// 最近一小时为 08:00 之后的区间
+	if flow.IncomingVehicles != 25 || flow.OutgoingVehicles != 50 || flow.FlowRate != 2 {
+		t.Fatalf("last hour flow = %+v", flow)
+	}
+
+	record(30, 1, 0)
+	if len(s.flowRecords) != 1 || s.flowRecords[0].Incoming != 1 {
+		t.Fatalf("records after clock rewind = %+v", s.flowRecords)
+	}
+}
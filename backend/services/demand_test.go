@@ -0,0 +1,186 @@
+package services
+
+import (
+	"backend/models"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// 泊松随机数的均值和方差都接近 lambda，大均值时的正态近似同样成立
+func TestPoissonMeanAndVariance(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for _, lambda := range []float64{0.5, 3, 100} {
+		const samples = 20000
+		sum, sumSquares := 0.0, 0.0
+		for i := 0; i < samples; i++ {
+			n := float64(poisson(rng, lambda))
+			sum += n
+			sumSquares += n * n
+		}
+		mean := sum / samples
+		variance := sumSquares/samples - mean*mean
+		if !near(mean, lambda, 4*math.Sqrt(lambda/samples)) {
+			t.Fatalf("lambda %v: mean = %.3f", lambda, mean)
+		}
+		if !near(variance, lambda, lambda*0.1) {
+			t.Fatalf("lambda %v: variance = %.3f", lambda, variance)
+		}
+	}
+
+	if n := poisson(rng, 0); n != 0 {
+		t.Fatalf("lambda 0: got %d", n)
+	}
+}
+
+// 车型按比例抽取，未配置比例时全部为小汽车
+func TestPickVehicleTypeFollowsMix(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	if vehicleType := pickVehicleType(rng, nil); vehicleType != defaultVehicleType {
+		t.Fatalf("empty mix: got %s", vehicleType)
+	}
+
+	const samples = 10000
+	counts := make(map[string]int)
+	for i := 0; i < samples; i++ {
+		counts[pickVehicleType(rng, map[string]float64{"car": 3, "truck": 1})]++
+	}
+	if share := float64(counts["truck"]) / samples; !near(share, 0.25, 0.02) {
+		t.Fatalf("truck share = %.3f, want 0.25", share)
+	}
+	if counts["car"]+counts["truck"] != samples {
+		t.Fatalf("unexpected vehicle types: %v", counts)
+	}
+}
+
+// 所在时变区间的发车率优先，区间外按默认发车率乘时段系数
+func TestODDemandRateAt(t *testing.T) {
+	od := ODDemand{Rate: 600, Periods: []DemandPeriod{{Start: 0, End: 900, Rate: 1200}}}
+	tests := []struct {
+		t, factor, want float64
+	}{
+		{0, 1, 1200},
+		{899, 2, 1200},
+		{900, 1, 600},
+		{1800, 1.5, 900},
+	}
+	for _, tt := range tests {
+		if got := od.rateAt(tt.t, tt.factor); got != tt.want {
+			t.Fatalf("rateAt(%v, %v) = %v, want %v", tt.t, tt.factor, got, tt.want)
+		}
+	}
+}
+
+// 节点坐标形式的起终点按 NodeKey 规范化，未命名的OD按序号编号，非法配置报错
+func TestValidateDemand(t *testing.T) {
+	s := newNetworkService(t)
+	config := DemandConfig{Matrix: []ODDemand{{Origin: "116.0, 39.0", Destination: "116.02,39", Rate: 100}}}
+	if err := validateDemand(&config, s.network); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	od := config.Matrix[0]
+	if od.ID != "OD001" || od.Origin != "116.00000,39.00000" || od.Destination != "116.02000,39.00000" {
+		t.Fatalf("normalized od = %+v", od)
+	}
+
+	tests := []struct {
+		name   string
+		config DemandConfig
+		want   string
+	}{
+		{"unknown zone", DemandConfig{Matrix: []ODDemand{{Origin: "C", Destination: "116.02,39"}}}, "unknown zone C"},
+		{"negative rate", DemandConfig{Matrix: []ODDemand{{Origin: "116.0,39.0", Destination: "116.02,39", Rate: -1}}}, "rate cannot be negative"},
+		{"invalid period", DemandConfig{Matrix: []ODDemand{{Origin: "116.0,39.0", Destination: "116.02,39", Periods: []DemandPeriod{{Start: 60, End: 60}}}}}, "invalid period"},
+		{"origin without outgoing road", DemandConfig{Matrix: []ODDemand{{Origin: "116.02,39.0", Destination: "116.02,39"}}}, "no road starts"},
+		{"zone road not found", DemandConfig{Zones: []DemandZone{{ID: "A", RoadIDs: []uint{9}}}}, "road 9 not found"},
+		{"duplicate zone", DemandConfig{Zones: []DemandZone{{ID: "A"}, {ID: "A"}}}, "duplicate zone A"},
+	}
+	for _, tt := range tests {
+		err := validateDemand(&tt.config, s.network)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+// 按OD矩阵生成的车辆数符合发车率，路径从起点小区驶向终点小区
+func TestGenerateDemandFollowsODMatrix(t *testing.T) {
+	s := newNetworkService(t)
+	_, err := s.SetDemand(DemandConfig{
+		Zones:  []DemandZone{{ID: "A", RoadIDs: []uint{1, 3}}, {ID: "B", RoadIDs: []uint{2}}},
+		Matrix: []ODDemand{{Origin: "A", Destination: "B", Rate: 1800}},
+	})
+	if err != nil {
+		t.Fatalf("set demand: %v", err)
+	}
+	stepService(t, s, 600)
+
+	stats := s.GetDemandStats()
+	// 600秒内期望300辆，标准差约17
+	if generated := stats.Spawned + stats.Queued; generated < 230 || generated > 370 {
+		t.Fatalf("generated %d vehicles in 600s, want about 300", generated)
+	}
+	if stats.Unroutable != 0 {
+		t.Fatalf("unroutable = %d", stats.Unroutable)
+	}
+	for _, vehicle := range s.vehicles {
+		if !strings.HasPrefix(vehicle.VehicleID, "D") {
+			t.Fatalf("unexpected vehicle %s", vehicle.VehicleID)
+		}
+		route := vehicle.Route
+		if len(route) != 2 || (route[0] != 1 && route[0] != 3) || route[1] != 2 {
+			t.Fatalf("vehicle %s route = %v, want [1 2] or [3 2]", vehicle.VehicleID, route)
+		}
+		if vehicle.VehicleType != defaultVehicleType {
+			t.Fatalf("vehicle %s type = %s without vehicle mix", vehicle.VehicleID, vehicle.VehicleType)
+		}
+	}
+}
+
+// 起终点间没有路径的需求计入 Unroutable，不生成车辆
+func TestGenerateDemandCountsUnroutable(t *testing.T) {
+	s := newNetworkService(t)
+	_, err := s.SetDemand(DemandConfig{
+		Zones:  []DemandZone{{ID: "A", RoadIDs: []uint{1}}, {ID: "B", RoadIDs: []uint{2}}},
+		Matrix: []ODDemand{{Origin: "B", Destination: "A", Rate: 3600}},
+	})
+	if err != nil {
+		t.Fatalf("set demand: %v", err)
+	}
+	for i := 0; i < 60; i++ {
+		s.generateDemand(1)
+	}
+
+	stats := s.GetDemandStats()
+	if stats.Unroutable == 0 || stats.Spawned != 0 || stats.Queued != 0 || len(s.vehicles) != 0 {
+		t.Fatalf("stats = %+v, %d vehicles", stats, len(s.vehicles))
+	}
+}
+
+// 入口车道空间不足时车辆排队等待，同一路段按先到先发
+func TestReleaseDeparturesQueuesWhenEntryBlocked(t *testing.T) {
+	s := newNetworkService(t)
+	s.vehicles = append(s.vehicles, models.Vehicle{VehicleID: "X", RoadID: 3, Offset: 1})
+	s.departures = append(s.departures,
+		pendingDeparture{vehicle: models.Vehicle{VehicleID: "D1", RoadID: 3, Route: []uint{3, 2}}},
+		pendingDeparture{vehicle: models.Vehicle{VehicleID: "D2", RoadID: 3, Route: []uint{3, 2}}},
+	)
+
+	s.releaseDepartures()
+	if stats := s.GetDemandStats(); stats.Spawned != 0 || stats.Queued != 2 {
+		t.Fatalf("blocked entry: stats = %+v", stats)
+	}
+
+	s.vehicles = s.vehicles[:0]
+	s.releaseDepartures()
+	if stats := s.GetDemandStats(); stats.Spawned != 1 || stats.Queued != 1 {
+		t.Fatalf("after clearing entry: stats = %+v", stats)
+	}
+	if len(s.vehicles) != 1 || s.vehicles[0].VehicleID != "D1" || !s.vehicles[0].Released {
+		t.Fatalf("released vehicles = %+v, want D1 first", s.vehicles)
+	}
+	if s.departures[0].vehicle.VehicleID != "D2" {
+		t.Fatalf("queued vehicle = %s, want D2", s.departures[0].vehicle.VehicleID)
+	}
+}
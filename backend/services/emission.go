@@ -0,0 +1,184 @@
+package services
+
+import (
+	"backend/algorithms"
+	"backend/models"
+	"errors"
+	"maps"
+	"sort"
+	"time"
+)
+
+const (
+	emissionIntervalLength = time.Minute // 排放记录区间长度
+	maxEmissionRecords     = 24 * 60     // 保留的排放记录数（1天）
+)
+
+// EmissionTotals 按路段和车型汇总的油耗和排放
+type EmissionTotals struct {
+	Total models.Emissions            `json:"total"`
+	Roads map[uint]models.Emissions   `json:"roads"`
+	Types map[string]models.Emissions `json:"types"`
+}
+
+// init 初始化路段和车型汇总
+func (t *EmissionTotals) init() {
+	if t.Roads == nil {
+		t.Roads = make(map[uint]models.Emissions)
+	}
+	if t.Types == nil {
+		t.Types = make(map[string]models.Emissions)
+	}
+}
+
+// add 累加一辆车一个步长的排放，roadID 为0（无路网）时只计入总量和车型
+func (t *EmissionTotals) add(roadID uint, vehicleType string, emissions models.Emissions) {
+	t.init()
+	t.Total.Add(emissions)
+	if roadID != 0 {
+		road := t.Roads[roadID]
+		road.Add(emissions)
+		t.Roads[roadID] = road
+	}
+	typeTotal := t.Types[vehicleType]
+	typeTotal.Add(emissions)
+	t.Types[vehicleType] = typeTotal
+}
+
+// clone 复制汇总，快照与运行状态不共享路段和车型映射
+func (t EmissionTotals) clone() EmissionTotals {
+	t.Roads = maps.Clone(t.Roads)
+	t.Types = maps.Clone(t.Types)
+	return t
+}
+
+// merge 合并另一组汇总
+func (t *EmissionTotals) merge(other *EmissionTotals) {
+	t.init()
+	t.Total.Add(other.Total)
+	for roadID, emissions := range other.Roads {
+		road := t.Roads[roadID]
+		road.Add(emissions)
+		t.Roads[roadID] = road
+	}
+	for vehicleType, emissions := range other.Types {
+		typeTotal := t.Types[vehicleType]
+		typeTotal.Add(emissions)
+		t.Types[vehicleType] = typeTotal
+	}
+}
+
+// EmissionRecord 1分钟区间的排放汇总
+type EmissionRecord struct {
+	Start time.Time `json:"start"`
+	EmissionTotals
+}
+
+// RoadEmissions 路段排放
+type RoadEmissions struct {
+	RoadID uint `json:"road_id"`
+	models.Emissions
+}
+
+// EmissionSummary 时间窗口内的油耗和排放汇总
+type EmissionSummary struct {
+	From         time.Time                   `json:"from"`
+	To           time.Time                   `json:"to"`
+	Total        models.Emissions            `json:"total"`
+	Roads        []RoadEmissions             `json:"roads"` // 按CO2降序
+	VehicleTypes map[string]models.Emissions `json:"vehicle_types"`
+}
+
+// emissionClassFor 获取车型的排放类别，未配置时使用默认类别
+func (s *TrafficService) emissionClassFor(vehicleType string) algorithms.EmissionClass {
+	if class, ok := algorithms.LookupEmissionClass(s.profileFor(vehicleType).EmissionClass); ok {
+		return class
+	}
+	class, _ := algorithms.LookupEmissionClass(algorithms.DefaultEmissionClass)
+	return class
+}
+
+// accumulateEmissions 按车辆本步的车速和加速度计算油耗和排放，计入车辆、所在路段和本次模拟
+func (s *TrafficService) accumulateEmissions(vehicle *models.Vehicle, dt float64) {
+	class := s.emissionClassFor(vehicle.VehicleType)
+	emissions := class.Rates(vehicle.Speed/3.6, vehicle.Acceleration).Scale(dt)
+
+	vehicle.Emissions.Add(emissions)
+	s.emissionTotals.add(vehicle.RoadID, vehicle.VehicleType, emissions)
+	s.emissionRecord().add(vehicle.RoadID, vehicle.VehicleType, emissions)
+}
+
+// emissionRecord 当前模拟时刻所在区间的排放记录，模拟时刻回退时清空记录
+func (s *TrafficService) emissionRecord() *EmissionRecord {
+	start := s.clock.Now().Truncate(emissionIntervalLength)
+	if n := len(s.emissionRecords); n > 0 {
+		last := s.emissionRecords[n-1].Start
+		if last.Equal(start) {
+			return &s.emissionRecords[n-1]
+		}
+		if start.Before(last) {
+			s.emissionRecords = nil
+		}
+	}
+
+	s.emissionRecords = append(s.emissionRecords, EmissionRecord{Start: start})
+	if len(s.emissionRecords) > maxEmissionRecords {
+		s.emissionRecords = append(s.emissionRecords[:0], s.emissionRecords[len(s.emissionRecords)-maxEmissionRecords:]...)
+	}
+	return &s.emissionRecords[len(s.emissionRecords)-1]
+}
+
+// cloneEmissionRecords 复制排放记录，只有最后一条记录会继续累加，复制其汇总映射
+func cloneEmissionRecords(records []EmissionRecord) []EmissionRecord {
+	records = append([]EmissionRecord(nil), records...)
+	if n := len(records); n > 0 {
+		records[n-1].EmissionTotals = records[n-1].EmissionTotals.clone()
+	}
+	return records
+}
+
+// GetEmissionSummary 获取油耗和排放汇总
+// from、to 为相对模拟开始的时刻 (s)，均为空时返回本次模拟的累计值，否则按1分钟排放记录汇总窗口内的排放
+func (s *TrafficService) GetEmissionSummary(from, to *float64) (EmissionSummary, error) {
+	if from != nil && to != nil && *to <= *from {
+		return EmissionSummary{}, errors.New("to must be later than from")
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	summary := EmissionSummary{From: s.clock.Start, To: s.clock.Now()}
+	totals := &s.emissionTotals
+	if from != nil || to != nil {
+		if from != nil {
+			summary.From = s.clock.Start.Add(time.Duration(*from * float64(time.Second)))
+		}
+		if to != nil {
+			summary.To = s.clock.Start.Add(time.Duration(*to * float64(time.Second)))
+		}
+		totals = &EmissionTotals{}
+		for i := range s.emissionRecords {
+			record := &s.emissionRecords[i]
+			if !record.Start.Before(summary.From.Truncate(emissionIntervalLength)) && record.Start.Before(summary.To) {
+				totals.merge(&record.EmissionTotals)
+			}
+		}
+	}
+
+	summary.Total = totals.Total
+	summary.Roads = make([]RoadEmissions, 0, len(totals.Roads))
+	for roadID, emissions := range totals.Roads {
+		summary.Roads = append(summary.Roads, RoadEmissions{RoadID: roadID, Emissions: emissions})
+	}
+	sort.Slice(summary.Roads, func(i, j int) bool {
+		if summary.Roads[i].CO2 != summary.Roads[j].CO2 {
+			return summary.Roads[i].CO2 > summary.Roads[j].CO2
+		}
+		return summary.Roads[i].RoadID < summary.Roads[j].RoadID
+	})
+	summary.VehicleTypes = make(map[string]models.Emissions, len(totals.Types))
+	for vehicleType, emissions := range totals.Types {
+		summary.VehicleTypes[vehicleType] = emissions
+	}
+	return summary, nil
+}
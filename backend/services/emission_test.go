@@ -0,0 +1,90 @@
+package services
+
+import (
+	"backend/algorithms"
+	"backend/models"
+	"testing"
+	"time"
+)
+
+// 按车型的排放类别和本步工况计算排放，计入车辆、路段、车型和当前1分钟记录
+func TestAccumulateEmissions(t *testing.T) {
+	s := newNetworkService(t)
+	car := models.Vehicle{VehicleType: defaultVehicleType, RoadID: 1}
+	truck := models.Vehicle{VehicleType: "truck", RoadID: 2, Speed: 54, Acceleration: 0.5}
+	s.accumulateEmissions(&car, 2)
+	s.accumulateEmissions(&truck, 2)
+
+	carClass, _ := algorithms.LookupEmissionClass("passenger_car")
+	truckClass, _ := algorithms.LookupEmissionClass("heavy_truck")
+	idle := carClass.Rates(0, 0).Scale(2)
+	moving := truckClass.Rates(15, 0.5).Scale(2)
+	if car.Emissions != idle || truck.Emissions != moving {
+		t.Fatalf("car %+v, want idle %+v; truck %+v, want %+v", car.Emissions, idle, truck.Emissions, moving)
+	}
+
+	totals := s.emissionTotals
+	if !near(totals.Total.CO2, idle.CO2+moving.CO2, 1e-9) || totals.Roads[1] != idle || totals.Types["truck"] != moving {
+		t.Fatalf("totals = %+v", totals)
+	}
+	if len(s.emissionRecords) != 1 || s.emissionRecords[0].Total != totals.Total {
+		t.Fatalf("records = %+v", s.emissionRecords)
+	}
+}
+
+// 运行中每辆车的排放累计之和（含已完成行程）等于本次模拟的总量
+func TestEmissionTotalsMatchVehicles(t *testing.T) {
+	s := newCheckpointService(t)
+	stepService(t, s, 300)
+
+	var sum models.Emissions
+	for _, vehicle := range s.vehicles {
+		sum.Add(vehicle.Emissions)
+	}
+	for _, trip := range s.trips {
+		sum.Add(trip.Emissions)
+	}
+	total := s.emissionTotals.Total
+	if total.CO2 == 0 || !near(sum.CO2, total.CO2, 1e-6) || !near(sum.NOx, total.NOx, 1e-9) || !near(sum.Fuel, total.Fuel, 1e-6) {
+		t.Fatalf("vehicles %+v, total %+v", sum, total)
+	}
+}
+
+// 指定时间窗口时按1分钟记录汇总，路段按CO2降序；非法窗口报错
+func TestEmissionSummaryWindow(t *testing.T) {
+	s := newNetworkService(t)
+	emit := func(minute int, roadID uint, speed float64) {
+		s.clock.Elapsed = time.Duration(minute)*time.Minute + 30*time.Second
+		s.accumulateEmissions(&models.Vehicle{VehicleType: defaultVehicleType, RoadID: roadID, Speed: speed}, 1)
+	}
+	emit(0, 1, 0)
+	emit(1, 2, 0)
+	emit(2, 1, 0)
+	emit(2, 3, 60)
+
+	class := s.emissionClassFor(defaultVehicleType)
+	idle := class.Rates(0, 0)
+	whole, err := s.GetEmissionSummary(nil, nil)
+	if err != nil || !near(whole.Total.Fuel, s.emissionTotals.Total.Fuel, 1e-12) || len(whole.Roads) != 3 {
+		t.Fatalf("whole run = %+v, err = %v", whole, err)
+	}
+
+	from, to := 60.0, 150.0
+	window, err := s.GetEmissionSummary(&from, &to)
+	if err != nil {
+		t.Fatalf("window: %v", err)
+	}
+	if len(window.Roads) != 3 || window.Roads[0].RoadID != 3 || window.Roads[1].RoadID != 1 || window.Roads[2].RoadID != 2 {
+		t.Fatalf("roads = %+v, want 3, 1, 2 by CO2", window.Roads)
+	}
+	if window.Roads[2].Emissions != idle || window.Roads[1].Emissions != idle {
+		t.Fatalf("idle roads = %+v", window.Roads)
+	}
+	if !window.From.Equal(s.clock.Start.Add(time.Minute)) || window.VehicleTypes[defaultVehicleType].CO2 != window.Total.CO2 {
+		t.Fatalf("window = %+v", window)
+	}
+
+	if _, err := s.GetEmissionSummary(&to, &from); err == nil {
+		t.Fatal("accepted a window ending before it starts")
+	}
+}
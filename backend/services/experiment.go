@@ -0,0 +1,485 @@
+package services
+
+import (
+	"backend/algorithms"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 扫描参数，带 ":<ID>" 后缀时只作用于指定的OD、路段或信号配时方案
+const (
+	ParamDemandScale     = "demand_scale"      // 发车率系数，可指定OD：demand_scale:<od_id>
+	ParamSpeedLimitScale = "speed_limit_scale" // 全部路段限速系数
+	ParamSpeedLimit      = "speed_limit"       // 指定路段限速 (km/h)：speed_limit:<road_id>
+	ParamGreenScale      = "green_scale"       // 绿灯时间系数，可指定方案：green_scale:<plan_id>
+	ParamInformedShare   = "informed_share"    // 有导航车辆比例
+)
+
+// experimentKPIs 实验比较的指标，顺序即输出顺序
+var experimentKPIs = []string{
+	"vehicle_km", "vehicle_hours", "mean_speed", "delay", "delay_per_km",
+	"trips_completed", "mean_travel_time", "p95_travel_time", "queued",
+	"fuel", "co2", "nox", "alerts",
+}
+
+// Experiment 参数扫描实验：对参数网格的每种组合按多个种子重复运行
+type Experiment struct {
+	Name         string                `json:"name"`
+	Duration     float64               `json:"duration"`     // 每次运行的模拟时长 (s)
+	Parameters   []ExperimentParameter `json:"parameters"`   // 取各参数取值的全部组合，为空时只运行基准场景
+	Seeds        []int64               `json:"seeds"`        // 每种组合使用的种子
+	Replications int                   `json:"replications"` // 未指定种子时的重复次数，种子依次为1..n
+	Workers      int                   `json:"workers"`      // 并行运行数，为0时取CPU核数
+	Confidence   float64               `json:"confidence"`   // 置信水平 0.90、0.95 或 0.99，为0时取0.95
+}
+
+// ExperimentParameter 扫描参数，给出取值列表或 from/to/step 范围
+type ExperimentParameter struct {
+	Name   string    `json:"name"`
+	Values []float64 `json:"values,omitempty"`
+	From   float64   `json:"from,omitempty"`
+	To     float64   `json:"to,omitempty"`
+	Step   float64   `json:"step,omitempty"`
+}
+
+// ExperimentRun 单次运行结果
+type ExperimentRun struct {
+	Combination int                `json:"combination"` // 参数组合序号
+	Parameters  map[string]float64 `json:"parameters"`
+	Seed        int64              `json:"seed"`
+	KPIs        map[string]float64 `json:"kpis"`
+	WallTime    float64            `json:"wall_time"` // 实际耗时 (s)
+	Error       string             `json:"error,omitempty"`
+}
+
+// ExperimentComparison 一种参数组合在各种子下的指标统计
+type ExperimentComparison struct {
+	Combination int                                   `json:"combination"`
+	Parameters  map[string]float64                    `json:"parameters"`
+	Runs        int                                   `json:"runs"`   // 成功的运行数
+	Failed      int                                   `json:"failed"` // 失败的运行数
+	KPIs        map[string]algorithms.SampleStatistic `json:"kpis"`
+}
+
+// ExperimentResult 实验结果
+type ExperimentResult struct {
+	Name       string                 `json:"name"`
+	Confidence float64                `json:"confidence"`
+	Parameters []string               `json:"parameters"` // 参数名，与组合中的顺序一致
+	KPIs       []string               `json:"kpis"`
+	Comparison []ExperimentComparison `json:"comparison"`
+	Runs       []ExperimentRun        `json:"runs"`
+}
+
+// values 展开参数取值
+func (p *ExperimentParameter) values() ([]float64, error) {
+	if len(p.Values) > 0 {
+		return p.Values, nil
+	}
+	if p.Step <= 0 || p.To < p.From {
+		return nil, fmt.Errorf("parameter %s: give values or a range with from <= to and a positive step", p.Name)
+	}
+
+	count := int(math.Floor((p.To-p.From)/p.Step+1e-9)) + 1
+	values := make([]float64, count)
+	for i := range values {
+		// 避免累加误差，取值保留到1e-9
+		values[i] = math.Round((p.From+float64(i)*p.Step)*1e9) / 1e9
+	}
+	return values, nil
+}
+
+// validateExperimentParameter 校验参数名及其指向的对象
+func validateExperimentParameter(name string, scenario *Scenario) error {
+	kind, target, _ := strings.Cut(name, ":")
+	switch kind {
+	case ParamDemandScale:
+		if target != "" && findOD(&scenario.Demand, target) == nil {
+			return fmt.Errorf("parameter %s: od %s not found", name, target)
+		}
+	case ParamSpeedLimitScale, ParamInformedShare:
+		if target != "" {
+			return fmt.Errorf("parameter %s does not take a target", kind)
+		}
+	case ParamSpeedLimit:
+		id, err := strconv.ParseUint(target, 10, 64)
+		if err != nil || findScenarioRoad(scenario, uint(id)) == nil {
+			return fmt.Errorf("parameter %s: road %s not found", name, target)
+		}
+	case ParamGreenScale:
+		if target != "" && findSignalPlan(scenario, target) == nil {
+			return fmt.Errorf("parameter %s: signal plan %s not found", name, target)
+		}
+	default:
+		return fmt.Errorf("unknown parameter %s, expected demand_scale, speed_limit_scale, speed_limit:<road_id>, green_scale or informed_share", name)
+	}
+	return nil
+}
+
+// findOD 按ID查找OD需求
+func findOD(demand *DemandConfig, id string) *ODDemand {
+	for i := range demand.Matrix {
+		if demand.Matrix[i].ID == id {
+			return &demand.Matrix[i]
+		}
+	}
+	return nil
+}
+
+// findScenarioRoad 按ID查找场景路段
+func findScenarioRoad(scenario *Scenario, id uint) *ScenarioRoad {
+	for i := range scenario.Roads {
+		if scenario.Roads[i].ID == id {
+			return &scenario.Roads[i]
+		}
+	}
+	return nil
+}
+
+// findSignalPlan 按ID查找场景信号配时方案
+func findSignalPlan(scenario *Scenario, id string) *SignalPlan {
+	for i := range scenario.Signals {
+		if scenario.Signals[i].ID == id {
+			return &scenario.Signals[i]
+		}
+	}
+	return nil
+}
+
+// applyExperimentParameter 将参数取值应用到场景副本
+func applyExperimentParameter(scenario *Scenario, name string, value float64) {
+	kind, target, _ := strings.Cut(name, ":")
+	switch kind {
+	case ParamDemandScale:
+		for i := range scenario.Demand.Matrix {
+			od := &scenario.Demand.Matrix[i]
+			if target != "" && od.ID != target {
+				continue
+			}
+			od.Rate *= value
+			for j := range od.Periods {
+				od.Periods[j].Rate *= value
+			}
+		}
+	case ParamSpeedLimitScale:
+		for i := range scenario.Roads {
+			scenario.Roads[i].MaxSpeed = int(math.Round(float64(scenario.Roads[i].MaxSpeed) * value))
+		}
+	case ParamSpeedLimit:
+		id, _ := strconv.ParseUint(target, 10, 64)
+		findScenarioRoad(scenario, uint(id)).MaxSpeed = int(math.Round(value))
+	case ParamGreenScale:
+		for i := range scenario.Signals {
+			plan := &scenario.Signals[i]
+			if target != "" && plan.ID != target {
+				continue
+			}
+			for j := range plan.Phases {
+				phase := &plan.Phases[j]
+				phase.Green *= value
+				phase.MinGreen *= value
+				phase.MaxGreen *= value
+			}
+		}
+	case ParamInformedShare:
+		scenario.Rerouting.InformedShare = value
+	}
+}
+
+// cloneScenario 深拷贝场景
+func cloneScenario(scenario *Scenario) (Scenario, error) {
+	data, err := json.Marshal(scenario)
+	if err != nil {
+		return Scenario{}, err
+	}
+	var clone Scenario
+	err = json.Unmarshal(data, &clone)
+	return clone, err
+}
+
+// runKPIs 提取运行指标
+func runKPIs(metrics *RunMetrics) map[string]float64 {
+	alerts := 0
+	for _, count := range metrics.Alerts {
+		alerts += count
+	}
+	return map[string]float64{
+		"vehicle_km":       metrics.VehicleKm,
+		"vehicle_hours":    metrics.VehicleHours,
+		"mean_speed":       metrics.MeanSpeed,
+		"delay":            metrics.Delay,
+		"delay_per_km":     metrics.DelayPerKm,
+		"trips_completed":  float64(metrics.Trips.Completed),
+		"mean_travel_time": metrics.Trips.MeanTravelTime,
+		"p95_travel_time":  metrics.Trips.P95TravelTime,
+		"queued":           float64(metrics.Demand.Queued),
+		"fuel":             metrics.Emissions.Fuel,
+		"co2":              metrics.Emissions.CO2,
+		"nox":              metrics.Emissions.NOx,
+		"alerts":           float64(alerts),
+	}
+}
+
+// RunExperiment 对基准场景运行参数扫描实验，各次运行使用独立的不连接数据库的模拟服务并行执行
+// progress 非空时每完成一次运行调用一次
+func RunExperiment(base Scenario, experiment Experiment, progress func(done, total int)) (*ExperimentResult, error) {
+	if experiment.Duration <= 0 {
+		return nil, errors.New("duration must be positive")
+	}
+	if len(base.Roads) == 0 {
+		return nil, errors.New("experiments need a scenario with roads")
+	}
+
+	// 按加载场景时的规则补全OD默认ID（OD001…），参数目标按补全后的ID查找
+	base, err := cloneScenario(&base)
+	if err != nil {
+		return nil, err
+	}
+	network, err := buildScenarioNetwork(base.Roads)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateDemand(&base.Demand, network); err != nil {
+		return nil, err
+	}
+	if experiment.Confidence == 0 {
+		experiment.Confidence = 0.95
+	}
+	if !algorithms.IsSupportedConfidence(experiment.Confidence) {
+		return nil, errors.New("confidence must be 0.90, 0.95 or 0.99")
+	}
+	if experiment.Workers <= 0 {
+		experiment.Workers = runtime.NumCPU()
+	}
+
+	seeds := experiment.Seeds
+	if len(seeds) == 0 {
+		if experiment.Replications < 0 {
+			return nil, errors.New("replications cannot be negative")
+		}
+		for i := 1; i <= max(experiment.Replications, 1); i++ {
+			seeds = append(seeds, int64(i))
+		}
+	}
+
+	// 展开参数网格
+	names := make([]string, len(experiment.Parameters))
+	grid := [][]float64{{}}
+	for i := range experiment.Parameters {
+		parameter := &experiment.Parameters[i]
+		if err := validateExperimentParameter(parameter.Name, &base); err != nil {
+			return nil, err
+		}
+		for _, name := range names[:i] {
+			if name == parameter.Name {
+				return nil, fmt.Errorf("duplicate parameter %s", name)
+			}
+		}
+		names[i] = parameter.Name
+
+		values, err := parameter.values()
+		if err != nil {
+			return nil, err
+		}
+		expanded := make([][]float64, 0, len(grid)*len(values))
+		for _, combination := range grid {
+			for _, value := range values {
+				expanded = append(expanded, append(append([]float64(nil), combination...), value))
+			}
+		}
+		grid = expanded
+	}
+
+	// 先生成全部场景，参数取值不合法时在运行前返回
+	scenarios := make([]Scenario, len(grid))
+	for i, combination := range grid {
+		scenario, err := cloneScenario(&base)
+		if err != nil {
+			return nil, err
+		}
+		for j, value := range combination {
+			applyExperimentParameter(&scenario, names[j], value)
+		}
+		if err := NewStandaloneTrafficService().LoadScenario(scenario); err != nil {
+			return nil, fmt.Errorf("combination %s: %w", formatCombination(names, combination), err)
+		}
+		scenarios[i] = scenario
+	}
+
+	runs := make([]ExperimentRun, 0, len(grid)*len(seeds))
+	for i, combination := range grid {
+		parameters := make(map[string]float64, len(names))
+		for j, name := range names {
+			parameters[name] = combination[j]
+		}
+		for _, seed := range seeds {
+			runs = append(runs, ExperimentRun{Combination: i, Parameters: parameters, Seed: seed})
+		}
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+	for w := 0; w < min(experiment.Workers, len(runs)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				executeExperimentRun(&runs[i], scenarios[runs[i].Combination], experiment.Duration)
+
+				mu.Lock()
+				done++
+				if progress != nil {
+					progress(done, len(runs))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range runs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	result := &ExperimentResult{
+		Name:       experiment.Name,
+		Confidence: experiment.Confidence,
+		Parameters: names,
+		KPIs:       experimentKPIs,
+		Runs:       runs,
+	}
+	for i := range grid {
+		comparison := ExperimentComparison{Combination: i, KPIs: make(map[string]algorithms.SampleStatistic)}
+		samples := make(map[string][]float64)
+		for _, run := range runs {
+			if run.Combination != i {
+				continue
+			}
+			comparison.Parameters = run.Parameters
+			if run.Error != "" {
+				comparison.Failed++
+				continue
+			}
+			comparison.Runs++
+			for _, kpi := range experimentKPIs {
+				samples[kpi] = append(samples[kpi], run.KPIs[kpi])
+			}
+		}
+		for _, kpi := range experimentKPIs {
+			comparison.KPIs[kpi] = algorithms.MeanConfidenceInterval(samples[kpi], experiment.Confidence)
+		}
+		result.Comparison = append(result.Comparison, comparison)
+	}
+	return result, nil
+}
+
+// executeExperimentRun 在独立的模拟服务中执行一次运行
+func executeExperimentRun(run *ExperimentRun, scenario Scenario, duration float64) {
+	service := NewStandaloneTrafficService()
+	if err := service.LoadScenario(scenario); err != nil {
+		run.Error = err.Error()
+		return
+	}
+
+	seed := run.Seed
+	metrics, err := service.RunBatch(BatchOptions{Duration: duration, Seed: &seed})
+	if err != nil {
+		run.Error = err.Error()
+		return
+	}
+	run.KPIs = runKPIs(metrics)
+	run.WallTime = metrics.WallTime
+}
+
+// formatCombination 参数组合的 name=value 表示
+func formatCombination(names []string, values []float64) string {
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + "=" + formatFloat(values[i])
+	}
+	return strings.Join(parts, ",")
+}
+
+// WriteFiles 将实验结果写入目录：experiment.json 为完整结果，comparison.csv 为各参数组合的指标均值和置信区间，runs.csv 为每次运行的指标
+func (r *ExperimentResult) WriteFiles(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "experiment.json"), append(data, '\n'), 0644); err != nil {
+		return err
+	}
+
+	header := append([]string{"combination"}, r.Parameters...)
+	header = append(header, "runs", "failed")
+	for _, kpi := range r.KPIs {
+		header = append(header, kpi+"_mean", kpi+"_std", kpi+"_lower", kpi+"_upper")
+	}
+	rows := [][]string{header}
+	for _, comparison := range r.Comparison {
+		row := []string{strconv.Itoa(comparison.Combination)}
+		for _, name := range r.Parameters {
+			row = append(row, formatFloat(comparison.Parameters[name]))
+		}
+		row = append(row, strconv.Itoa(comparison.Runs), strconv.Itoa(comparison.Failed))
+		for _, kpi := range r.KPIs {
+			stat := comparison.KPIs[kpi]
+			row = append(row, formatFloat(stat.Mean), formatFloat(stat.StdDev), formatFloat(stat.Lower), formatFloat(stat.Upper))
+		}
+		rows = append(rows, row)
+	}
+	if err := writeCSV(filepath.Join(dir, "comparison.csv"), rows); err != nil {
+		return err
+	}
+
+	header = append([]string{"combination"}, r.Parameters...)
+	header = append(header, "seed", "wall_time", "error")
+	header = append(header, r.KPIs...)
+	rows = [][]string{header}
+	runs := append([]ExperimentRun(nil), r.Runs...)
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].Combination < runs[j].Combination })
+	for _, run := range runs {
+		row := []string{strconv.Itoa(run.Combination)}
+		for _, name := range r.Parameters {
+			row = append(row, formatFloat(run.Parameters[name]))
+		}
+		row = append(row, strconv.FormatInt(run.Seed, 10), formatFloat(run.WallTime), run.Error)
+		for _, kpi := range r.KPIs {
+			value := ""
+			if run.Error == "" {
+				value = formatFloat(run.KPIs[kpi])
+			}
+			row = append(row, value)
+		}
+		rows = append(rows, row)
+	}
+	return writeCSV(filepath.Join(dir, "runs.csv"), rows)
+}
+
+// ReadExperimentFile 读取实验配置文件
+func ReadExperimentFile(path string) (Experiment, error) {
+	var experiment Experiment
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return experiment, err
+	}
+	if err := json.Unmarshal(data, &experiment); err != nil {
+		return experiment, fmt.Errorf("parse experiment: %w", err)
+	}
+	return experiment, nil
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"backend/algorithms"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// 参数范围按步长展开，取值不受浮点累加误差影响；缺少取值或范围非法时报错
+func TestExperimentParameterValues(t *testing.T) {
+	values, err := (&ExperimentParameter{Name: ParamDemandScale, From: 0.8, To: 1.2, Step: 0.1}).values()
+	if err != nil || !slices.Equal(values, []float64{0.8, 0.9, 1, 1.1, 1.2}) {
+		t.Fatalf("values = %v, err = %v", values, err)
+	}
+	for _, parameter := range []ExperimentParameter{{Name: "p"}, {Name: "p", From: 2, To: 1, Step: 1}} {
+		if _, err := parameter.values(); err == nil {
+			t.Fatalf("accepted %+v", parameter)
+		}
+	}
+}
+
+// 参数按目标只作用于指定的OD或路段，未指定目标时作用于全部
+func TestApplyExperimentParameter(t *testing.T) {
+	scenario := checkpointScenario()
+	scenario.Demand.Matrix = append(scenario.Demand.Matrix, ODDemand{ID: "OD002", Origin: "A", Destination: "B", Rate: 600})
+	scenario.Demand.Matrix[0].ID = "OD001"
+
+	applyExperimentParameter(&scenario, ParamDemandScale+":OD002", 2)
+	applyExperimentParameter(&scenario, ParamSpeedLimitScale, 0.5)
+	applyExperimentParameter(&scenario, ParamSpeedLimit+":2", 50)
+	applyExperimentParameter(&scenario, ParamGreenScale, 1.5)
+	applyExperimentParameter(&scenario, ParamInformedShare, 0.4)
+
+	if scenario.Demand.Matrix[0].Rate != 1800 || scenario.Demand.Matrix[1].Rate != 1200 {
+		t.Fatalf("rates = %v, %v", scenario.Demand.Matrix[0].Rate, scenario.Demand.Matrix[1].Rate)
+	}
+	if scenario.Roads[0].MaxSpeed != 30 || scenario.Roads[1].MaxSpeed != 50 || scenario.Roads[2].MaxSpeed != 20 {
+		t.Fatalf("speed limits = %+v", scenario.Roads)
+	}
+	if scenario.Signals[0].Phases[0].Green != 45 || scenario.Rerouting.InformedShare != 0.4 {
+		t.Fatalf("green %v, informed share %v", scenario.Signals[0].Phases[0].Green, scenario.Rerouting.InformedShare)
+	}
+
+	for name, want := range map[string]string{
+		"demand_scale:OD009": "od OD009 not found",
+		"speed_limit:9":      "road 9 not found",
+		"informed_share:x":   "does not take a target",
+		"lane_count":         "unknown parameter",
+	} {
+		if err := validateExperimentParameter(name, &scenario); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("%s: err = %v, want %q", name, err, want)
+		}
+	}
+}
+
+// 每种参数组合按全部种子运行，比较结果为各次运行指标的均值和置信区间；结果与并行数无关
+func TestRunExperimentComparison(t *testing.T) {
+	experiment := Experiment{
+		Name:       "demand",
+		Duration:   120,
+		Parameters: []ExperimentParameter{{Name: ParamDemandScale + ":OD001", Values: []float64{0.5, 1.5}}},
+		Seeds:      []int64{1, 2, 3},
+		Workers:    3,
+	}
+	done := 0
+	result, err := RunExperiment(checkpointScenario(), experiment, func(n, total int) { done = n })
+	if err != nil {
+		t.Fatalf("run experiment: %v", err)
+	}
+	if done != 6 || len(result.Runs) != 6 || len(result.Comparison) != 2 || result.Confidence != 0.95 {
+		t.Fatalf("%d done, %d runs, %d combinations, confidence %v", done, len(result.Runs), len(result.Comparison), result.Confidence)
+	}
+
+	for _, comparison := range result.Comparison {
+		if comparison.Runs != 3 || comparison.Failed != 0 {
+			t.Fatalf("combination %d: %d runs, %d failed", comparison.Combination, comparison.Runs, comparison.Failed)
+		}
+		var completed []float64
+		for _, run := range result.Runs {
+			if run.Combination == comparison.Combination {
+				completed = append(completed, run.KPIs["trips_completed"])
+			}
+		}
+		if want := algorithms.MeanConfidenceInterval(completed, 0.95); comparison.KPIs["trips_completed"] != want {
+			t.Fatalf("combination %d: trips completed %+v, want %+v", comparison.Combination, comparison.KPIs["trips_completed"], want)
+		}
+	}
+	low, high := result.Comparison[0].KPIs["vehicle_km"], result.Comparison[1].KPIs["vehicle_km"]
+	if low.Mean >= high.Mean {
+		t.Fatalf("vehicle km %.2f at demand 0.5, %.2f at demand 1.5", low.Mean, high.Mean)
+	}
+
+	experiment.Workers = 1
+	serial, err := RunExperiment(checkpointScenario(), experiment, nil)
+	if err != nil {
+		t.Fatalf("serial run: %v", err)
+	}
+	for i := range serial.Runs {
+		if serial.Runs[i].Seed != result.Runs[i].Seed || serial.Runs[i].KPIs["co2"] != result.Runs[i].KPIs["co2"] {
+			t.Fatalf("run %d differs between 1 and 3 workers", i)
+		}
+	}
+}
+
+// 非法的实验配置在运行前报错
+func TestRunExperimentValidates(t *testing.T) {
+	tests := []struct {
+		experiment Experiment
+		want       string
+	}{
+		{Experiment{}, "duration must be positive"},
+		{Experiment{Duration: 60, Confidence: 0.8}, "confidence must be"},
+		{Experiment{Duration: 60, Replications: -1}, "replications cannot be negative"},
+		{Experiment{Duration: 60, Parameters: []ExperimentParameter{
+			{Name: ParamInformedShare, Values: []float64{0.5}},
+			{Name: ParamInformedShare, Values: []float64{1}},
+		}}, "duplicate parameter"},
+		{Experiment{Duration: 60, Parameters: []ExperimentParameter{{Name: ParamInformedShare, Values: []float64{2}}}}, "combination informed_share=2"},
+	}
+	for _, tt := range tests {
+		_, err := RunExperiment(checkpointScenario(), tt.experiment, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("%+v: err = %v, want %q", tt.experiment, err, tt.want)
+		}
+	}
+	if _, err := RunExperiment(Scenario{}, Experiment{Duration: 60}, nil); err == nil {
+		t.Fatal("ran an experiment without roads")
+	}
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"backend/algorithms"
+	"time"
+)
+
+// maxFlowRecords 保留的15分钟流量记录数（7天）
+const maxFlowRecords = 7 * profileIntervals
+
+// FlowRecord 15分钟区间进出路网的车辆数
+type FlowRecord struct {
+	Start    time.Time `json:"start"`
+	Incoming int       `json:"incoming"` // 进入路网的车辆数
+	Outgoing int       `json:"outgoing"` // 到达终点离开路网的车辆数
+}
+
+// recordFlow 按模拟时刻累计进出路网的车辆数，记录保持连续，无车辆的区间为0
+// 模拟时刻回退（重新设定起始时刻）时清空记录
+func (s *TrafficService) recordFlow(incoming, outgoing int) {
+	start := s.clock.Now().Truncate(algorithms.FlowIntervalLength)
+	if n := len(s.flowRecords); n > 0 {
+		last := s.flowRecords[n-1].Start
+		if start.Before(last) || start.Sub(last) > maxFlowRecords*algorithms.FlowIntervalLength {
+			s.flowRecords = nil
+		} else {
+			for next := last.Add(algorithms.FlowIntervalLength); !next.After(start); next = next.Add(algorithms.FlowIntervalLength) {
+				s.flowRecords = append(s.flowRecords, FlowRecord{Start: next})
+			}
+		}
+	}
+	if len(s.flowRecords) == 0 {
+		s.flowRecords = append(s.flowRecords, FlowRecord{Start: start})
+	}
+
+	record := &s.flowRecords[len(s.flowRecords)-1]
+	record.Incoming += incoming
+	record.Outgoing += outgoing
+
+	if len(s.flowRecords) > maxFlowRecords {
+		s.flowRecords = append(s.flowRecords[:0], s.flowRecords[len(s.flowRecords)-maxFlowRecords:]...)
+	}
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"backend/models"
+	"testing"
+)
+
+// flowTotals 全部流量记录的进出车辆数
+func flowTotals(s *TrafficService) (incoming, outgoing int) {
+	for _, record := range s.flowRecords {
+		incoming += record.Incoming
+		outgoing += record.Outgoing
+	}
+	return incoming, outgoing
+}
+
+// 场景车辆、手动添加车辆、需求和公交车辆进出路网都计入流量，进出之差等于路网中的车辆数
+func TestFlowRecordsBalanceActiveVehicles(t *testing.T) {
+	scenario := checkpointScenario()
+	scenario.Vehicles = []ScenarioVehicle{{VehicleID: "S1", RoadID: 1, Speed: 40}}
+	s := NewStandaloneTrafficService()
+	if err := s.LoadScenario(scenario); err != nil {
+		t.Fatalf("load scenario: %v", err)
+	}
+	if _, err := s.AddVehicle(models.Vehicle{VehicleID: "M1", RoadID: 3, Speed: 30}); err != nil {
+		t.Fatalf("add vehicle: %v", err)
+	}
+
+	incoming, outgoing := flowTotals(s)
+	if incoming != 2 || outgoing != 0 {
+		t.Fatalf("before stepping: incoming = %d, outgoing = %d, want 2 and 0", incoming, outgoing)
+	}
+
+	for step := 0; step < 20; step++ {
+		stepService(t, s, 60)
+		incoming, outgoing := flowTotals(s)
+		if incoming-outgoing != len(s.vehicles) {
+			t.Fatalf("after %d steps: incoming %d - outgoing %d != %d active vehicles",
+				(step+1)*60, incoming, outgoing, len(s.vehicles))
+		}
+	}
+	if _, outgoing := flowTotals(s); outgoing == 0 {
+		t.Fatal("no vehicle left the network")
+	}
+}
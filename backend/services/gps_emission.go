@@ -0,0 +1,279 @@
+package services
+
+import (
+	"backend/models"
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// maxGPSSamples 保留的GPS上报真值记录数
+const maxGPSSamples = 10000
+
+// GPSSink GPS数据接收端，模拟车辆生成的GPS数据与设备上报的数据经过同一处理流程
+type GPSSink interface {
+	IngestGPSData(gpsData *models.GPSData) (*GPSIngestResult, error)
+}
+
+// GPSEmissionConfig 模拟车辆GPS上报配置
+type GPSEmissionConfig struct {
+	Enabled  bool          `json:"enabled"`
+	Interval float64       `json:"interval"` // 每辆车的采样间隔 (s)
+	Errors   GPSErrorModel `json:"errors"`   // 上报数据的误差模型
+}
+
+// GPSEmissionStats GPS上报统计，按真值评估路段匹配和超速检测
+type GPSEmissionStats struct {
+	Emitted           int     `json:"emitted"`             // 已送达的点数，含重复送达
+	Failed            int     `json:"failed"`              // 处理失败的点数
+	Matched           int     `json:"matched"`             // 匹配到真实所在路段的点数
+	Mismatched        int     `json:"mismatched"`          // 匹配到其他路段或未匹配的点数
+	Speeding          int     `json:"speeding"`            // 真实车速超过路段限速的点数
+	Overspeed         int     `json:"overspeed"`           // 判定为超速的点数
+	Anomalies         int     `json:"anomalies"`           // 检测到的异常数
+	Dropped           int     `json:"dropped"`             // 丢失的点数
+	Outliers          int     `json:"outliers"`            // 送达的多路径离群点数
+	Duplicates        int     `json:"duplicates"`          // 重复送达的点数
+	Delayed           int     `json:"delayed"`             // 延迟送达的点数
+	MeanPositionError float64 `json:"mean_position_error"` // 送达点的平均位置误差 (m)
+}
+
+// GPSSample 上报的GPS点，包含车辆真值、误差模型生成的上报值和处理结果
+type GPSSample struct {
+	VehicleID   string    `json:"vehicle_id"`
+	VehicleType string    `json:"vehicle_type"`
+	Timestamp   time.Time `json:"timestamp"` // 真实采样时刻
+	Lng         float64   `json:"lng"`
+	Lat         float64   `json:"lat"`
+	Speed       float64   `json:"speed"` // 真实车速 (km/h)
+	Direction   float64   `json:"direction"`
+	RoadID      uint      `json:"road_id"`  // 真实所在路段
+	Offset      float64   `json:"offset"`   // 真实位置距路段起点的距离 (m)
+	Lane        int       `json:"lane"`     // 真实所在车道
+	Speeding    bool      `json:"speeding"` // 真实车速是否超过路段限速
+
+	ReportedTime  time.Time `json:"reported_time"` // 设备时钟下的时间戳
+	ReportedLng   float64   `json:"reported_lng"`
+	ReportedLat   float64   `json:"reported_lat"`
+	ReportedSpeed int       `json:"reported_speed"`
+	PositionError float64   `json:"position_error"` // 上报位置与真实位置的距离 (m)
+	Outlier       bool      `json:"outlier,omitempty"`
+	Dropped       bool      `json:"dropped,omitempty"`
+	Duplicate     bool      `json:"duplicate,omitempty"`
+	Delay         float64   `json:"delay,omitempty"` // 送达延迟 (s)
+
+	MatchedRoadID uint   `json:"matched_road_id"`
+	Overspeed     bool   `json:"overspeed"`
+	Anomalies     int    `json:"anomalies"`
+	Error         string `json:"error,omitempty"`
+}
+
+// deliveryTime 送达时刻（模拟时间）
+func (sample *GPSSample) deliveryTime() time.Time {
+	return sample.Timestamp.Add(time.Duration(sample.Delay * float64(time.Second)))
+}
+
+// gpsData 按上报值生成GPS数据
+func (sample *GPSSample) gpsData() models.GPSData {
+	return models.GPSData{
+		VehicleID:   sample.VehicleID,
+		Longitude:   sample.ReportedLng,
+		Latitude:    sample.ReportedLat,
+		Speed:       sample.ReportedSpeed,
+		Direction:   int(math.Round(sample.Direction)),
+		Timestamp:   sample.ReportedTime,
+		VehicleType: sample.VehicleType,
+	}
+}
+
+// validateGPSEmission 校验GPS上报配置
+func validateGPSEmission(config *GPSEmissionConfig) error {
+	if config.Interval < 0 {
+		return errors.New("interval cannot be negative")
+	}
+	if config.Enabled && config.Interval == 0 {
+		return errors.New("interval must be positive when emission is enabled")
+	}
+	return validateGPSErrorModel(&config.Errors)
+}
+
+// sampleGPS 采样本步到期的路网车辆，按误差模型生成上报值后加入待送达队列
+func (s *TrafficService) sampleGPS() {
+	if !s.gpsEmission.Enabled {
+		return
+	}
+
+	now := s.clock.ElapsedSeconds()
+	dt := s.clock.StepSeconds()
+	timestamp := s.clock.Now()
+
+	for i := range s.vehicles {
+		vehicle := &s.vehicles[i]
+		if vehicle.RoadID == 0 || !staggeredDue(vehicle.ID, now, s.gpsEmission.Interval, dt) {
+			continue
+		}
+		sample := GPSSample{
+			VehicleID:   vehicle.VehicleID,
+			VehicleType: vehicle.VehicleType,
+			Timestamp:   timestamp,
+			Lng:         vehicle.X,
+			Lat:         vehicle.Y,
+			Speed:       vehicle.Speed,
+			Direction:   vehicle.Direction,
+			RoadID:      vehicle.RoadID,
+			Offset:      vehicle.Offset,
+			Lane:        vehicle.Lane,
+			Speeding:    vehicle.Speed/3.6 > roadSpeedLimit(s.network, vehicle.RoadID),
+		}
+		for _, delivery := range s.applyGPSErrors(vehicle.ID, sample) {
+			if delivery.Dropped {
+				s.recordGPSSample(delivery)
+				continue
+			}
+			s.gpsPending = append(s.gpsPending, delivery)
+		}
+	}
+}
+
+// dueGPSDeliveries 取出已到送达时刻的点，按送达时刻排序
+func (s *TrafficService) dueGPSDeliveries() []GPSSample {
+	now := s.clock.Now()
+	var due []GPSSample
+	pending := s.gpsPending[:0]
+	for _, sample := range s.gpsPending {
+		if sample.deliveryTime().After(now) {
+			pending = append(pending, sample)
+		} else {
+			due = append(due, sample)
+		}
+	}
+	s.gpsPending = pending
+
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].deliveryTime().Before(due[j].deliveryTime())
+	})
+	return due
+}
+
+// emitGPS 将本步送达的GPS点送入接收端，处理过程可能访问数据库，不在持锁期间进行
+func (s *TrafficService) emitGPS() {
+	s.mu.Lock()
+	sink := s.gpsSink
+	var deliveries []GPSSample
+	if sink != nil {
+		s.sampleGPS()
+		deliveries = s.dueGPSDeliveries()
+	}
+	s.mu.Unlock()
+
+	if len(deliveries) == 0 {
+		return
+	}
+
+	for i := range deliveries {
+		point := deliveries[i].gpsData()
+		result, err := sink.IngestGPSData(&point)
+		if err != nil {
+			deliveries[i].Error = err.Error()
+			continue
+		}
+		deliveries[i].MatchedRoadID = result.RoadID
+		deliveries[i].Overspeed = result.Overspeed
+		deliveries[i].Anomalies = len(result.Anomalies)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sample := range deliveries {
+		s.recordGPSSample(sample)
+	}
+}
+
+// recordGPSSample 记录GPS上报结果并更新统计
+func (s *TrafficService) recordGPSSample(sample GPSSample) {
+	stats := &s.gpsStats
+	if sample.Dropped {
+		stats.Dropped++
+	} else {
+		stats.Emitted++
+		stats.MeanPositionError += (sample.PositionError - stats.MeanPositionError) / float64(stats.Emitted)
+		if sample.Speeding {
+			stats.Speeding++
+		}
+		switch {
+		case sample.Error != "":
+			stats.Failed++
+		case sample.MatchedRoadID == sample.RoadID:
+			stats.Matched++
+		default:
+			stats.Mismatched++
+		}
+		if sample.Overspeed {
+			stats.Overspeed++
+		}
+		if sample.Outlier {
+			stats.Outliers++
+		}
+		if sample.Duplicate {
+			stats.Duplicates++
+		}
+		if sample.Delay > 0 {
+			stats.Delayed++
+		}
+		stats.Anomalies += sample.Anomalies
+	}
+
+	s.gpsSamples = append(s.gpsSamples, sample)
+	if len(s.gpsSamples) > maxGPSSamples {
+		s.gpsSamples = append(s.gpsSamples[:0], s.gpsSamples[len(s.gpsSamples)-maxGPSSamples:]...)
+	}
+}
+
+// SetGPSSink 设置GPS数据接收端，为空时不上报
+func (s *TrafficService) SetGPSSink(sink GPSSink) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gpsSink = sink
+}
+
+// GetGPSEmission 获取GPS上报配置
+func (s *TrafficService) GetGPSEmission() GPSEmissionConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.gpsEmission
+}
+
+// SetGPSEmission 设置GPS上报配置
+func (s *TrafficService) SetGPSEmission(config GPSEmissionConfig) (GPSEmissionConfig, error) {
+	if err := validateGPSEmission(&config); err != nil {
+		return GPSEmissionConfig{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.gpsEmission = config
+	return config, nil
+}
+
+// GetGPSEmissionStats 获取GPS上报统计
+func (s *TrafficService) GetGPSEmissionStats() GPSEmissionStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.gpsStats
+}
+
+// GetGPSSamples 获取最近的GPS上报记录（含丢失的点），按记录时间倒序，vehicleID 为空时返回全部车辆
+func (s *TrafficService) GetGPSSamples(vehicleID string, limit int) []GPSSample {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	samples := make([]GPSSample, 0)
+	for i := len(s.gpsSamples) - 1; i >= 0 && (limit <= 0 || len(samples) < limit); i-- {
+		if vehicleID == "" || s.gpsSamples[i].VehicleID == vehicleID {
+			samples = append(samples, s.gpsSamples[i])
+		}
+	}
+	return samples
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"backend/algorithms"
+	"backend/models"
+	"errors"
+	"testing"
+)
+
+// recordingGPSSink 记录收到的GPS数据，按路网匹配最近路段并按限速判定超速
+type recordingGPSSink struct {
+	network *algorithms.RoadGraph
+	points  []models.GPSData
+	err     error
+}
+
+func (r *recordingGPSSink) IngestGPSData(gpsData *models.GPSData) (*GPSIngestResult, error) {
+	r.points = append(r.points, *gpsData)
+	if r.err != nil {
+		return nil, r.err
+	}
+	roadID, _, _, _ := r.network.NearestSegment(gpsData.Longitude, gpsData.Latitude)
+	road, _ := r.network.Segment(roadID)
+	return &GPSIngestResult{RoadID: roadID, Overspeed: gpsData.Speed > road.MaxSpeed}, nil
+}
+
+// newEmittingService 路段1上一辆车，按5秒间隔上报无误差的GPS点
+func newEmittingService(t *testing.T) (*TrafficService, *recordingGPSSink) {
+	t.Helper()
+	s := newNetworkService(t)
+	if _, err := s.AddVehicle(models.Vehicle{VehicleID: "V1", RoadID: 1, Route: []uint{1, 2}, Speed: 40}); err != nil {
+		t.Fatalf("add vehicle: %v", err)
+	}
+	if _, err := s.SetGPSEmission(GPSEmissionConfig{Enabled: true, Interval: 5}); err != nil {
+		t.Fatalf("set emission: %v", err)
+	}
+	sink := &recordingGPSSink{network: s.network}
+	s.SetGPSSink(sink)
+	return s, sink
+}
+
+// 模拟车辆按采样间隔上报模拟时刻的GPS点，处理结果按真值统计
+func TestGPSEmissionFeedsSink(t *testing.T) {
+	s, sink := newEmittingService(t)
+	start := s.clock.Now()
+	stepService(t, s, 60)
+
+	if len(sink.points) != 12 {
+		t.Fatalf("received %d points in 60s, want 12", len(sink.points))
+	}
+	for i, point := range sink.points {
+		if point.VehicleID != "V1" || point.VehicleType != defaultVehicleType {
+			t.Fatalf("point %d = %+v", i, point)
+		}
+		if i > 0 && point.Timestamp.Sub(sink.points[i-1].Timestamp).Seconds() != 5 {
+			t.Fatalf("point %d at %v, previous at %v", i, point.Timestamp, sink.points[i-1].Timestamp)
+		}
+		if point.Timestamp.Before(start) || point.Timestamp.Sub(start).Seconds() >= 60 {
+			t.Fatalf("point %d timestamp %v outside the simulated minute", i, point.Timestamp)
+		}
+	}
+
+	stats := s.GetGPSEmissionStats()
+	if stats.Emitted != 12 || stats.Matched != 12 || stats.Failed != 0 || stats.Dropped != 0 || stats.MeanPositionError != 0 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	samples := s.GetGPSSamples("V1", 1)
+	if len(samples) != 1 || samples[0].MatchedRoadID != samples[0].RoadID || samples[0].ReportedLng != samples[0].Lng {
+		t.Fatalf("latest sample = %+v", samples)
+	}
+	if last := sink.points[len(sink.points)-1]; samples[0].ReportedSpeed != last.Speed {
+		t.Fatalf("sample speed %d, sink received %d", samples[0].ReportedSpeed, last.Speed)
+	}
+}
+
+// 未启用上报或未设置接收端时不采样，接收端处理失败时计入 Failed
+func TestGPSEmissionDisabledAndFailures(t *testing.T) {
+	s, sink := newEmittingService(t)
+	if _, err := s.SetGPSEmission(GPSEmissionConfig{}); err != nil {
+		t.Fatalf("disable emission: %v", err)
+	}
+	stepService(t, s, 30)
+	if len(sink.points) != 0 {
+		t.Fatalf("disabled emission sent %d points", len(sink.points))
+	}
+
+	if _, err := s.SetGPSEmission(GPSEmissionConfig{Enabled: true, Interval: 5}); err != nil {
+		t.Fatalf("enable emission: %v", err)
+	}
+	s.SetGPSSink(nil)
+	stepService(t, s, 30)
+	if stats := s.GetGPSEmissionStats(); stats.Emitted != 0 || len(s.gpsPending) != 0 {
+		t.Fatalf("without a sink: stats = %+v, %d pending", stats, len(s.gpsPending))
+	}
+
+	sink.err = errors.New("database unavailable")
+	s.SetGPSSink(sink)
+	stepService(t, s, 30)
+	if stats := s.GetGPSEmissionStats(); stats.Emitted != 6 || stats.Failed != 6 || stats.Matched != 0 {
+		t.Fatalf("failing sink: stats = %+v", stats)
+	}
+	if samples := s.GetGPSSamples("", 0); samples[0].Error != "database unavailable" {
+		t.Fatalf("sample error = %q", samples[0].Error)
+	}
+
+	for _, config := range []GPSEmissionConfig{{Interval: -1}, {Enabled: true}} {
+		if _, err := s.SetGPSEmission(config); err == nil {
+			t.Fatalf("accepted invalid config %+v", config)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// 等距投影下每度经纬度对应的距离 (m)，与路网的最近路段查找一致
+const (
+	metersPerDegreeLng = 111320.0 // 赤道处，按纬度余弦缩放
+	metersPerDegreeLat = 110540.0
+)
+
+// GPSErrorModel GPS误差模型，参数均为0时上报的数据与真值一致（车速取整）
+type GPSErrorModel struct {
+	PositionNoise   float64 `json:"position_noise"`   // 位置高斯噪声标准差 (m)
+	OutlierRate     float64 `json:"outlier_rate"`     // 多路径离群点概率 (0-1)
+	OutlierDistance float64 `json:"outlier_distance"` // 离群点平均偏移距离 (m)，按指数分布抽样
+	DropoutRate     float64 `json:"dropout_rate"`     // 丢失概率 (0-1)
+	ClockSkew       float64 `json:"clock_skew"`       // 设备时钟偏差标准差 (s)，每辆车固定
+	DuplicateRate   float64 `json:"duplicate_rate"`   // 重复送达概率 (0-1)
+	DelayRate       float64 `json:"delay_rate"`       // 延迟送达概率 (0-1)，延迟的点可能晚于后续的点送达
+	MaxDelay        float64 `json:"max_delay"`        // 最大送达延迟 (s)，按均匀分布抽样
+	SpeedResolution float64 `json:"speed_resolution"` // 车速量化步长 (km/h)，0表示取整到1 km/h
+}
+
+// validateGPSErrorModel 校验GPS误差模型
+func validateGPSErrorModel(model *GPSErrorModel) error {
+	for _, rate := range []float64{model.OutlierRate, model.DropoutRate, model.DuplicateRate, model.DelayRate} {
+		if rate < 0 || rate > 1 {
+			return errors.New("outlier, dropout, duplicate and delay rates must be between 0 and 1")
+		}
+	}
+	if model.PositionNoise < 0 || model.OutlierDistance < 0 || model.ClockSkew < 0 || model.MaxDelay < 0 || model.SpeedResolution < 0 {
+		return errors.New("noise, distances, clock skew, delay and speed resolution cannot be negative")
+	}
+	if model.OutlierRate > 0 && model.OutlierDistance == 0 {
+		return errors.New("outlier_distance must be positive when outlier_rate is set")
+	}
+	if model.DelayRate > 0 && model.MaxDelay == 0 {
+		return errors.New("max_delay must be positive when delay_rate is set")
+	}
+	return nil
+}
+
+// gpsRandomSource GPS误差使用的随机源，与交通模拟的随机源相互独立，开启误差不改变车辆运行结果
+func gpsRandomSource(seed int64) *rand.PCG {
+	return rand.NewPCG(uint64(seed)^0x6a09e667f3bcc909, uint64(seed))
+}
+
+// clockSkew 车辆设备的时钟偏差 (s)，由种子和车辆编号确定，不占用随机源
+func (s *TrafficService) clockSkew(vehicleID uint) float64 {
+	if s.gpsEmission.Errors.ClockSkew == 0 {
+		return 0
+	}
+	return rand.New(rand.NewPCG(uint64(s.seed), uint64(vehicleID))).NormFloat64() * s.gpsEmission.Errors.ClockSkew
+}
+
+// applyGPSErrors 按误差模型生成上报值，丢失的点只做标记，重复送达时返回两条记录
+func (s *TrafficService) applyGPSErrors(vehicleID uint, sample GPSSample) []GPSSample {
+	model := &s.gpsEmission.Errors
+	rng := s.gpsRng
+
+	// 位置噪声和多路径离群点，按等距投影换算为经纬度
+	dx, dy := 0.0, 0.0
+	if model.PositionNoise > 0 {
+		dx, dy = rng.NormFloat64()*model.PositionNoise, rng.NormFloat64()*model.PositionNoise
+	}
+	if model.OutlierRate > 0 && rng.Float64() < model.OutlierRate {
+		angle := rng.Float64() * 2 * math.Pi
+		distance := rng.ExpFloat64() * model.OutlierDistance
+		dx += distance * math.Cos(angle)
+		dy += distance * math.Sin(angle)
+		sample.Outlier = true
+	}
+	sample.ReportedLng = sample.Lng + dx/(metersPerDegreeLng*math.Cos(sample.Lat*math.Pi/180))
+	sample.ReportedLat = sample.Lat + dy/metersPerDegreeLat
+	sample.PositionError = math.Hypot(dx, dy)
+
+	resolution := model.SpeedResolution
+	if resolution == 0 {
+		resolution = 1
+	}
+	sample.ReportedSpeed = int(math.Round(sample.Speed/resolution) * resolution)
+	sample.ReportedTime = sample.Timestamp.Add(time.Duration(s.clockSkew(vehicleID) * float64(time.Second)))
+
+	if model.DropoutRate > 0 && rng.Float64() < model.DropoutRate {
+		sample.Dropped = true
+		return []GPSSample{sample}
+	}
+	if model.DelayRate > 0 && rng.Float64() < model.DelayRate {
+		sample.Delay = rng.Float64() * model.MaxDelay
+	}
+	deliveries := []GPSSample{sample}
+	if model.DuplicateRate > 0 && rng.Float64() < model.DuplicateRate {
+		duplicate := sample
+		duplicate.Duplicate = true
+		deliveries = append(deliveries, duplicate)
+	}
+	return deliveries
+}
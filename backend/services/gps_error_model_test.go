@@ -0,0 +1,189 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// newErrorModelService 使用指定误差模型的服务
+func newErrorModelService(t *testing.T, model GPSErrorModel) *TrafficService {
+	t.Helper()
+	s := newNetworkService(t)
+	if _, err := s.SetGPSEmission(GPSEmissionConfig{Enabled: true, Interval: 1, Errors: model}); err != nil {
+		t.Fatalf("set emission: %v", err)
+	}
+	return s
+}
+
+// trueSample 位于北京附近的真值采样点
+func trueSample() GPSSample {
+	return GPSSample{
+		VehicleID: "V1",
+		Timestamp: time.Date(2024, 5, 1, 7, 0, 0, 0, time.UTC),
+		Lng:       116.005,
+		Lat:       39.0,
+		Speed:     43.4,
+	}
+}
+
+// 误差参数均为0时上报值与真值一致，车速取整
+func TestGPSErrorModelWithoutErrors(t *testing.T) {
+	s := newErrorModelService(t, GPSErrorModel{})
+	deliveries := s.applyGPSErrors(1, trueSample())
+	if len(deliveries) != 1 {
+		t.Fatalf("deliveries = %+v", deliveries)
+	}
+	sample := deliveries[0]
+	if sample.ReportedLng != sample.Lng || sample.ReportedLat != sample.Lat || sample.PositionError != 0 {
+		t.Fatalf("position changed: %+v", sample)
+	}
+	if sample.ReportedSpeed != 43 || !sample.ReportedTime.Equal(sample.Timestamp) || sample.Delay != 0 {
+		t.Fatalf("reported %+v", sample)
+	}
+}
+
+// 位置噪声按标准差分布，上报坐标与记录的位置误差一致
+func TestGPSErrorModelPositionNoise(t *testing.T) {
+	s := newErrorModelService(t, GPSErrorModel{PositionNoise: 10})
+	const samples = 5000
+	sumSquares := 0.0
+	for i := 0; i < samples; i++ {
+		sample := s.applyGPSErrors(1, trueSample())[0]
+		dx := (sample.ReportedLng - sample.Lng) * metersPerDegreeLng * math.Cos(sample.Lat*math.Pi/180)
+		dy := (sample.ReportedLat - sample.Lat) * metersPerDegreeLat
+		if !near(math.Hypot(dx, dy), sample.PositionError, 1e-6) {
+			t.Fatalf("position error %.3f m, reported offset %.3f m", sample.PositionError, math.Hypot(dx, dy))
+		}
+		sumSquares += sample.PositionError * sample.PositionError
+	}
+	// 二维高斯噪声的均方误差为 2σ²
+	if rms := math.Sqrt(sumSquares / samples); !near(rms, 10*math.Sqrt2, 0.5) {
+		t.Fatalf("rms position error = %.2f m, want %.2f", rms, 10*math.Sqrt2)
+	}
+}
+
+// 离群点、丢失、重复和延迟按概率发生，延迟不超过上限
+func TestGPSErrorModelRates(t *testing.T) {
+	s := newErrorModelService(t, GPSErrorModel{
+		OutlierRate: 0.1, OutlierDistance: 200,
+		DropoutRate: 0.2, DuplicateRate: 0.1,
+		DelayRate: 0.3, MaxDelay: 30,
+	})
+	const samples = 10000
+	var outliers, dropped, duplicates, delayed, delivered int
+	for i := 0; i < samples; i++ {
+		deliveries := s.applyGPSErrors(1, trueSample())
+		if deliveries[0].Outlier {
+			outliers++
+		}
+		if deliveries[0].Dropped {
+			dropped++
+			continue
+		}
+		delivered++
+		if deliveries[0].Delay > 0 {
+			delayed++
+			if deliveries[0].Delay > 30 {
+				t.Fatalf("delay %.1f s exceeds max_delay", deliveries[0].Delay)
+			}
+		}
+		if len(deliveries) == 2 {
+			duplicates++
+			if !deliveries[1].Duplicate || deliveries[1].ReportedLng != deliveries[0].ReportedLng {
+				t.Fatalf("duplicate = %+v", deliveries[1])
+			}
+		}
+	}
+
+	rates := []struct {
+		name      string
+		got, want float64
+	}{
+		{"outlier", float64(outliers) / samples, 0.1},
+		{"dropout", float64(dropped) / samples, 0.2},
+		{"duplicate", float64(duplicates) / float64(delivered), 0.1},
+		{"delay", float64(delayed) / float64(delivered), 0.3},
+	}
+	for _, rate := range rates {
+		if !near(rate.got, rate.want, 0.02) {
+			t.Fatalf("%s rate = %.3f, want %.2f", rate.name, rate.got, rate.want)
+		}
+	}
+}
+
+// 车速按量化步长上报，每辆车的时钟偏差固定且不同车辆互不相同
+func TestGPSErrorModelSpeedAndClock(t *testing.T) {
+	s := newErrorModelService(t, GPSErrorModel{SpeedResolution: 5, ClockSkew: 2})
+	first := s.applyGPSErrors(1, trueSample())[0]
+	if first.ReportedSpeed != 45 {
+		t.Fatalf("speed %.1f with resolution 5 reported as %d", first.Speed, first.ReportedSpeed)
+	}
+
+	skew := first.ReportedTime.Sub(first.Timestamp)
+	if skew == 0 {
+		t.Fatal("clock skew not applied")
+	}
+	if again := s.applyGPSErrors(1, trueSample())[0]; again.ReportedTime.Sub(again.Timestamp) != skew {
+		t.Fatal("clock skew of a vehicle changed between fixes")
+	}
+	if other := s.applyGPSErrors(2, trueSample())[0]; other.ReportedTime.Sub(other.Timestamp) == skew {
+		t.Fatal("two vehicles share the same clock skew")
+	}
+}
+
+// 延迟的点按送达时刻排序送达，可能晚于之后采样的点
+func TestDueGPSDeliveriesOrder(t *testing.T) {
+	s := newErrorModelService(t, GPSErrorModel{})
+	now := s.clock.Now()
+	late := trueSample()
+	late.VehicleID, late.Timestamp, late.Delay = "late", now.Add(-10*time.Second), 8
+	onTime := trueSample()
+	onTime.VehicleID, onTime.Timestamp = "on-time", now.Add(-5*time.Second)
+	future := trueSample()
+	future.VehicleID, future.Timestamp, future.Delay = "future", now.Add(-1*time.Second), 10
+	s.gpsPending = []GPSSample{late, onTime, future}
+
+	due := s.dueGPSDeliveries()
+	if len(due) != 2 || due[0].VehicleID != "on-time" || due[1].VehicleID != "late" {
+		t.Fatalf("due = %+v, want on-time then late", due)
+	}
+	if len(s.gpsPending) != 1 || s.gpsPending[0].VehicleID != "future" {
+		t.Fatalf("pending = %+v", s.gpsPending)
+	}
+}
+
+// GPS误差使用独立的随机源，开启误差不改变车辆运行结果
+func TestGPSErrorsDoNotChangeTraffic(t *testing.T) {
+	clean := newCheckpointService(t)
+	scenario := checkpointScenario()
+	scenario.GPSEmission.Errors = GPSErrorModel{PositionNoise: 15, DropoutRate: 0.3, DuplicateRate: 0.1}
+	noisy := NewStandaloneTrafficService()
+	if err := noisy.LoadScenario(scenario); err != nil {
+		t.Fatalf("load scenario: %v", err)
+	}
+	noisy.SetGPSSink(stubGPSSink{})
+
+	stepService(t, clean, 300)
+	stepService(t, noisy, 300)
+	if runState(t, clean) != runState(t, noisy) {
+		t.Fatal("GPS errors changed the simulated traffic")
+	}
+	if noisy.GetGPSEmissionStats().Dropped == 0 {
+		t.Fatal("no fixes dropped")
+	}
+}
+
+// 非法误差参数被拒绝
+func TestValidateGPSErrorModel(t *testing.T) {
+	for _, model := range []GPSErrorModel{
+		{DropoutRate: 1.5},
+		{PositionNoise: -1},
+		{OutlierRate: 0.1},
+		{DelayRate: 0.1},
+	} {
+		if err := validateGPSErrorModel(&model); err == nil {
+			t.Fatalf("accepted %+v", model)
+		}
+	}
+}
@@ -1,24 +1,102 @@
 package services
 
 import (
+	"backend/algorithms"
 	"backend/models"
 	"backend/repositories"
+	"errors"
+	"fmt"
+	"sync"
 	"time"
+
+	"github.com/beego/beego/v2/core/logs"
 )
 
+// roadCacheTTL 路段匹配使用的路网缓存时长，过期后重新加载以包含新增路段
+const roadCacheTTL = time.Minute
+
+// ErrInvalidGPSData GPS数据不完整
+var ErrInvalidGPSData = errors.New("invalid GPS data")
+
+// GPSIngestResult GPS数据处理结果
+type GPSIngestResult struct {
+	RoadID    uint                         `json:"road_id"`   // 匹配到的路段，0表示未匹配
+	Overspeed bool                         `json:"overspeed"` // 是否判定为超速
+	Anomalies []algorithms.DetectionRecord `json:"anomalies"` // 检测到的异常
+}
+
 // GPSService GPS服务
+// 设备上报和模拟车辆生成的GPS数据都经过 IngestGPSData：校验、路段匹配、超速和异常检测后入库
 type GPSService struct {
-	gpsRepo *repositories.GPSRepository
+	gpsRepo         *repositories.GPSRepository
+	matcher         *algorithms.RoadMatcher
+	speedDetector   *algorithms.SpeedDetector
+	anomalyDetector *algorithms.AnomalyDetector
+
+	mu          sync.Mutex // 保护路网缓存和检测器的历史记录
+	roadsLoaded time.Time
 }
 
 func NewGPSService() *GPSService {
 	return &GPSService{
-		gpsRepo: repositories.NewGPSRepository(),
+		gpsRepo:         repositories.NewGPSRepository(),
+		matcher:         algorithms.NewRoadMatcher(),
+		speedDetector:   algorithms.NewSpeedDetector(),
+		anomalyDetector: algorithms.NewAnomalyDetector(),
 	}
 }
 
-func (s *GPSService) CreateGPSData(gpsData *models.GPSData) error {
-	return s.gpsRepo.Create(gpsData)
+// IngestGPSData 处理一条GPS数据：校验后匹配路段，检测超速和异常，入库并生成超速告警
+// 未指定时间戳时取当前时间，已指定路段时不再匹配
+func (s *GPSService) IngestGPSData(gpsData *models.GPSData) (*GPSIngestResult, error) {
+	if gpsData.VehicleID == "" {
+		return nil, fmt.Errorf("%w: vehicle ID cannot be empty", ErrInvalidGPSData)
+	}
+	if gpsData.Longitude == 0 || gpsData.Latitude == 0 {
+		return nil, fmt.Errorf("%w: longitude and latitude cannot be zero", ErrInvalidGPSData)
+	}
+	if gpsData.Timestamp.IsZero() {
+		gpsData.Timestamp = time.Now()
+	}
+
+	result, err := s.analyze(gpsData)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.gpsRepo.Create(gpsData); err != nil {
+		return nil, err
+	}
+	if result.Overspeed {
+		if err := s.speedDetector.CreateSpeedAlert(*gpsData, gpsData.RoadSegment); err != nil {
+			logs.Warn("创建超速告警失败: ", err)
+		}
+	}
+	return result, nil
+}
+
+// analyze 匹配路段并运行超速和异常检测
+func (s *GPSService) analyze(gpsData *models.GPSData) (*GPSIngestResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if gpsData.RoadSegment == nil {
+		if time.Since(s.roadsLoaded) > roadCacheTTL {
+			if err := s.matcher.LoadRoads(); err != nil {
+				return nil, fmt.Errorf("load roads for matching: %w", err)
+			}
+			s.roadsLoaded = time.Now()
+		}
+		gpsData.RoadSegment, _ = s.matcher.FindNearestRoad(gpsData.Longitude, gpsData.Latitude)
+	}
+
+	result := &GPSIngestResult{
+		Overspeed: s.speedDetector.CheckOverspeed(*gpsData, gpsData.RoadSegment),
+		Anomalies: s.anomalyDetector.DetectAnomalies(*gpsData, gpsData.RoadSegment),
+	}
+	if gpsData.RoadSegment != nil {
+		result.RoadID = gpsData.RoadSegment.ID
+	}
+	return result, nil
 }
 
 func (s *GPSService) GetRecentGPSData(limit, minutes int) ([]models.GPSData, error) {
@@ -47,3 +125,31 @@ func (s *GPSService) GetRealTimeStats() (map[string]interface{}, error) {
 	// 这里可以添加实际的统计计算逻辑
 	return stats, nil
 }
+
+// FlowProfile 15分钟区间流量及高峰小时
+type FlowProfile struct {
+	Intervals []algorithms.FlowInterval `json:"intervals"`
+	PeakHour  *algorithms.PeakHour      `json:"peak_hour"` // 无流量时为空
+}
+
+// GetFlowProfile 按GPS数据统计最近若干小时每15分钟观测到的车辆数和高峰小时，roadId 为0时统计全部路段
+func (s *GPSService) GetFlowProfile(hours int, roadId uint) (*FlowProfile, error) {
+	until := time.Now()
+	since := until.Add(-time.Duration(hours) * time.Hour).Truncate(algorithms.FlowIntervalLength)
+	gpsData, err := s.gpsRepo.FindObservations(since, until, roadId)
+	if err != nil {
+		return nil, err
+	}
+
+	times := make([]time.Time, len(gpsData))
+	vehicles := make([]string, len(gpsData))
+	for i := range gpsData {
+		times[i], vehicles[i] = gpsData[i].Timestamp, gpsData[i].VehicleID
+	}
+
+	profile := &FlowProfile{Intervals: algorithms.BinFlow(since, until, times, vehicles)}
+	if peak, ok := algorithms.FindPeakHour(profile.Intervals); ok {
+		profile.PeakHour = &peak
+	}
+	return profile, nil
+}
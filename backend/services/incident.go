@@ -0,0 +1,216 @@
+package services
+
+import (
+	"backend/algorithms"
+	"backend/models"
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	incidentWarningDistance  = 200.0 // 车辆开始驶离封闭车道的距离 (m)
+	incidentUpstreamDistance = 150.0 // 事件上游减速区长度 (m)
+	incidentDownstream       = 50.0  // 事件下游减速区长度 (m)
+	minIncidentSpeedFactor   = 0.2   // 减速区期望速度下限（相对正常值）
+)
+
+// Incident 交通事件（事故、施工占道等）
+type Incident struct {
+	ID                string  `json:"id"`
+	Type              string  `json:"type"`               // accident、roadwork 等，默认 accident
+	RoadID            uint    `json:"road_id"`            // 所在路段
+	Position          float64 `json:"position"`           // 距路段起点的距离 (m)
+	Lanes             []int   `json:"lanes"`              // 封闭车道，为空表示不封闭车道
+	Start             float64 `json:"start"`              // 生效时刻，相对模拟开始 (s)
+	End               float64 `json:"end"`                // 结束时刻，相对模拟开始 (s)，0表示直至手动清除
+	CapacityReduction float64 `json:"capacity_reduction"` // 通行能力折减比例 (0-1)，事件附近车辆按比例降低期望速度
+	Description       string  `json:"description"`
+	Active            bool    `json:"active"`   // 是否已生效
+	AlertID           uint    `json:"alert_id"` // 对应告警ID
+}
+
+// blocksLane 判断事件是否封闭指定车道
+func (i *Incident) blocksLane(lane int) bool {
+	for _, blocked := range i.Lanes {
+		if blocked == lane {
+			return true
+		}
+	}
+	return false
+}
+
+// validateIncident 校验事件并补全默认值
+func validateIncident(incident *Incident, network *algorithms.RoadGraph) error {
+	if incident.Type == "" {
+		incident.Type = "accident"
+	}
+	if _, ok := network.Segment(incident.RoadID); !ok {
+		return fmt.Errorf("road %d not found", incident.RoadID)
+	}
+	if incident.Position < 0 || incident.Position > network.SegmentLength(incident.RoadID) {
+		return fmt.Errorf("position must be between 0 and %.1f", network.SegmentLength(incident.RoadID))
+	}
+	for _, lane := range incident.Lanes {
+		if lane < 0 || lane >= network.LaneCount(incident.RoadID) {
+			return fmt.Errorf("lane %d does not exist on road %d", lane, incident.RoadID)
+		}
+	}
+	if incident.Start < 0 {
+		return errors.New("start cannot be negative")
+	}
+	if incident.End != 0 && incident.End <= incident.Start {
+		return errors.New("end must be after start")
+	}
+	if incident.CapacityReduction < 0 || incident.CapacityReduction > 1 {
+		return errors.New("capacity_reduction must be between 0 and 1")
+	}
+	return nil
+}
+
+// updateIncidents 按模拟时间激活和结束事件
+func (s *TrafficService) updateIncidents() {
+	now := s.clock.ElapsedSeconds()
+	remaining := s.incidents[:0]
+	var activated []Incident
+	for _, incident := range s.incidents {
+		if incident.End > 0 && now >= incident.End {
+			s.resolveIncidentAlert(&incident)
+			continue
+		}
+		if !incident.Active && now >= incident.Start {
+			s.activateIncident(&incident)
+			activated = append(activated, incident)
+		}
+		remaining = append(remaining, incident)
+	}
+	s.incidents = remaining
+
+	// 事件全部生效后再重新规划，使路段代价计入新事件
+	for i := range activated {
+		s.rerouteAroundIncident(&activated[i])
+	}
+}
+
+// activateIncident 事件生效并生成告警
+func (s *TrafficService) activateIncident(incident *Incident) {
+	incident.Active = true
+
+	severity := "high"
+	if len(incident.Lanes) >= s.network.LaneCount(incident.RoadID) {
+		severity = "critical"
+	}
+
+	alert := models.TrafficAlert{
+		ID:         s.newAlertID(),
+		AlertType:  "incident",
+		AlertValue: incident.CapacityReduction,
+		Message:    fmt.Sprintf("路段%d发生交通事件(%s)，封闭%d条车道", incident.RoadID, incident.Type, len(incident.Lanes)),
+		Severity:   severity,
+		Timestamp:  s.clock.Now(),
+	}
+	if road, ok := s.network.Segment(incident.RoadID); ok {
+		segment := *road
+		alert.RoadSegment = &segment
+	}
+	if incident.Description != "" {
+		alert.Message += "：" + incident.Description
+	}
+
+	s.addAlert(alert)
+	incident.AlertID = alert.ID
+}
+
+// resolveIncidentAlert 事件结束时解除对应告警
+func (s *TrafficService) resolveIncidentAlert(incident *Incident) {
+	if !incident.Active {
+		return
+	}
+	for i := range s.alerts {
+		if s.alerts[i].ID == incident.AlertID && s.alerts[i].AlertType == "incident" {
+			s.alerts[i].Resolve()
+		}
+	}
+}
+
+// incidentAhead 查找车道前方最近的封闭点，返回与车辆的距离
+func (s *TrafficService) incidentAhead(roadID uint, lane int, offset float64) (float64, bool) {
+	distance, found := math.Inf(1), false
+	for i := range s.incidents {
+		incident := &s.incidents[i]
+		if !incident.Active || incident.RoadID != roadID || incident.Position < offset || !incident.blocksLane(lane) {
+			continue
+		}
+		if d := incident.Position - offset; d < distance {
+			distance, found = d, true
+		}
+	}
+	return distance, found
+}
+
+// laneBlockedAhead 判断车道在前方指定距离内是否被事件封闭
+func (s *TrafficService) laneBlockedAhead(roadID uint, lane int, offset float64) bool {
+	distance, found := s.incidentAhead(roadID, lane, offset)
+	return found && distance <= incidentWarningDistance
+}
+
+// incidentSpeedFactor 事件附近的期望速度系数，多个事件取最小值
+func (s *TrafficService) incidentSpeedFactor(roadID uint, offset float64) float64 {
+	factor := 1.0
+	for i := range s.incidents {
+		incident := &s.incidents[i]
+		if !incident.Active || incident.RoadID != roadID || incident.CapacityReduction <= 0 {
+			continue
+		}
+		if offset >= incident.Position-incidentUpstreamDistance && offset <= incident.Position+incidentDownstream {
+			factor = math.Min(factor, math.Max(1-incident.CapacityReduction, minIncidentSpeedFactor))
+		}
+	}
+	return factor
+}
+
+// GetIncidents 获取全部事件（含尚未生效的）
+func (s *TrafficService) GetIncidents() []Incident {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Incident{}, s.incidents...)
+}
+
+// CreateIncident 创建事件，开始时刻已到时立即生效
+func (s *TrafficService) CreateIncident(incident Incident) (Incident, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := validateIncident(&incident, s.network); err != nil {
+		return Incident{}, err
+	}
+
+	s.nextIncidentID++
+	incident.ID = fmt.Sprintf("I%03d", s.nextIncidentID)
+	incident.Active = false
+	incident.AlertID = 0
+	if s.clock.ElapsedSeconds() >= incident.Start {
+		s.activateIncident(&incident)
+	}
+
+	s.incidents = append(s.incidents, incident)
+	if incident.Active {
+		s.rerouteAroundIncident(&incident)
+	}
+	return incident, nil
+}
+
+// ClearIncident 清除事件并解除对应告警
+func (s *TrafficService) ClearIncident(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.incidents {
+		if s.incidents[i].ID == id {
+			s.resolveIncidentAlert(&s.incidents[i])
+			s.incidents = append(s.incidents[:i], s.incidents[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
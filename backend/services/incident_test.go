@@ -0,0 +1,148 @@
+package services
+
+import (
+	"backend/models"
+	"strings"
+	"testing"
+)
+
+// incidentAlert 查找事件对应的告警
+func incidentAlert(t *testing.T, s *TrafficService, id uint) models.TrafficAlert {
+	t.Helper()
+	for _, alert := range s.alerts {
+		if alert.ID == id && alert.AlertType == "incident" {
+			return alert
+		}
+	}
+	t.Fatalf("no incident alert %d", id)
+	return models.TrafficAlert{}
+}
+
+// 计划事件在开始时刻的步长生效并产生告警，结束时刻的步长移除并解除告警
+func TestScheduledIncidentLifecycle(t *testing.T) {
+	s := newNetworkService(t)
+	incident, err := s.CreateIncident(Incident{RoadID: 1, Position: 400, Lanes: []int{0}, Start: 30, End: 90})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if incident.ID != "I001" || incident.Type != "accident" || incident.Active || len(s.alerts) != 0 {
+		t.Fatalf("before start: incident = %+v, %d alerts", incident, len(s.alerts))
+	}
+
+	stepService(t, s, 30)
+	if incidents := s.GetIncidents(); incidents[0].Active {
+		t.Fatal("incident active before its start time")
+	}
+	stepService(t, s, 1)
+	incidents := s.GetIncidents()
+	if len(incidents) != 1 || !incidents[0].Active {
+		t.Fatalf("at start: incidents = %+v", incidents)
+	}
+	alert := incidentAlert(t, s, incidents[0].AlertID)
+	if alert.Severity != "high" || alert.Resolved || alert.RoadSegment == nil || alert.RoadSegment.ID != 1 {
+		t.Fatalf("one of two lanes closed: alert = %+v", alert)
+	}
+
+	stepService(t, s, 60)
+	if incidents := s.GetIncidents(); len(incidents) != 0 {
+		t.Fatalf("after end: incidents = %+v", incidents)
+	}
+	if alert := incidentAlert(t, s, alert.ID); !alert.Resolved {
+		t.Fatal("alert not resolved when the incident ended")
+	}
+}
+
+// 封闭全部车道的事件为严重告警，车辆在事件前停车排队，清除后继续行驶
+func TestVehiclesQueueBehindClosure(t *testing.T) {
+	s := newNetworkService(t)
+	for _, id := range []string{"V1", "V2"} {
+		if _, err := s.AddVehicle(models.Vehicle{VehicleID: id, RoadID: 3, Speed: 40}); err != nil {
+			t.Fatalf("add vehicle: %v", err)
+		}
+	}
+	s.vehicles[1].Offset = 0
+	s.vehicles[0].Offset = 30
+	incident, err := s.CreateIncident(Incident{RoadID: 3, Position: 300, Lanes: []int{0}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if alert := incidentAlert(t, s, incident.AlertID); alert.Severity != "critical" {
+		t.Fatalf("all lanes closed: severity = %s", alert.Severity)
+	}
+
+	stepService(t, s, 120)
+	for _, vehicle := range s.vehicles {
+		if vehicle.RoadID != 3 || vehicle.Offset >= 300 || vehicle.Speed > 1 {
+			t.Fatalf("vehicle %s on road %d at %.1f m, %.1f km/h, want stopped before 300 m",
+				vehicle.VehicleID, vehicle.RoadID, vehicle.Offset, vehicle.Speed)
+		}
+	}
+	if leader, follower := s.vehicles[0], s.vehicles[1]; follower.Offset >= leader.Offset {
+		t.Fatalf("follower at %.1f m passed leader at %.1f m", follower.Offset, leader.Offset)
+	}
+
+	if !s.ClearIncident(incident.ID) || s.ClearIncident(incident.ID) {
+		t.Fatal("clear should succeed once")
+	}
+	if alert := incidentAlert(t, s, incident.AlertID); !alert.Resolved {
+		t.Fatal("alert not resolved when the incident was cleared")
+	}
+	stepService(t, s, 60)
+	for _, vehicle := range s.vehicles {
+		if vehicle.RoadID == 3 && vehicle.Offset < 300 {
+			t.Fatalf("vehicle %s still held at %.1f m after clearing", vehicle.VehicleID, vehicle.Offset)
+		}
+	}
+}
+
+// 通行能力折减只作用于事件上下游的减速区，系数不低于下限
+func TestIncidentSpeedFactor(t *testing.T) {
+	s := newNetworkService(t)
+	if _, err := s.CreateIncident(Incident{RoadID: 1, Position: 500, CapacityReduction: 0.5}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := s.CreateIncident(Incident{RoadID: 2, Position: 500, CapacityReduction: 0.95}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	tests := []struct {
+		road   uint
+		offset float64
+		want   float64
+	}{
+		{1, 300, 1},
+		{1, 350, 0.5},
+		{1, 550, 0.5},
+		{1, 551, 1},
+		{2, 500, minIncidentSpeedFactor},
+		{3, 500, 1},
+	}
+	for _, tt := range tests {
+		if got := s.incidentSpeedFactor(tt.road, tt.offset); got != tt.want {
+			t.Fatalf("road %d offset %v: factor = %v, want %v", tt.road, tt.offset, got, tt.want)
+		}
+	}
+}
+
+// 非法事件被拒绝
+func TestValidateIncident(t *testing.T) {
+	s := newNetworkService(t)
+	tests := []struct {
+		incident Incident
+		want     string
+	}{
+		{Incident{RoadID: 9}, "road 9 not found"},
+		{Incident{RoadID: 1, Position: -1}, "position must be between"},
+		{Incident{RoadID: 3, Lanes: []int{1}}, "lane 1 does not exist"},
+		{Incident{RoadID: 1, Start: 60, End: 30}, "end must be after start"},
+		{Incident{RoadID: 1, CapacityReduction: 1.5}, "capacity_reduction"},
+	}
+	for _, tt := range tests {
+		if _, err := s.CreateIncident(tt.incident); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("incident %+v: err = %v, want %q", tt.incident, err, tt.want)
+		}
+	}
+	if len(s.GetIncidents()) != 0 {
+		t.Fatal("invalid incident was added")
+	}
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"backend/models"
+	"sort"
+)
+
+const (
+	laneChangeThreshold = 0.2 // 换道激励阈值 (m/s²)
+	safeDeceleration    = 4.0 // 新后车可接受的最大减速度 (m/s²)
+	mandatoryLaneBias   = 2.0 // 强制换道（车道结束、车道禁行、事件封闭、公交进站）激励 (m/s²)
+)
+
+// LaneRule 车型车道规则
+type LaneRule struct {
+	MaxLane       int     `json:"max_lane"`        // 允许使用的最内侧车道，-1表示不限
+	KeepRightBias float64 `json:"keep_right_bias"` // 靠右行驶倾向 (m/s²)
+	Politeness    float64 `json:"politeness"`      // MOBIL礼让系数
+}
+
+// LaneOccupancy 车道占用情况
+type LaneOccupancy struct {
+	RoadID       uint    `json:"road_id"`
+	Lane         int     `json:"lane"`
+	VehicleCount int     `json:"vehicle_count"`
+	AverageSpeed float64 `json:"average_speed"`
+	Density      float64 `json:"density"` // 车辆/公里
+}
+
+// laneKey 车道标识
+type laneKey struct {
+	roadID uint
+	lane   int
+}
+
+// laneAllowed 判断车型是否允许使用该车道
+func (s *TrafficService) laneAllowed(vehicleType string, lane int) bool {
+	rule := s.laneRuleFor(vehicleType)
+	return rule.MaxLane < 0 || lane <= rule.MaxLane
+}
+
+// highestAllowedLane 获取车辆在路段上可使用的最内侧车道
+func (s *TrafficService) highestAllowedLane(vehicleType string, roadID uint) int {
+	lanes := s.network.LaneCount(roadID)
+	if rule := s.laneRuleFor(vehicleType); rule.MaxLane >= 0 && rule.MaxLane < lanes-1 {
+		return rule.MaxLane
+	}
+	return lanes - 1
+}
+
+// assignLane 为车辆随机分配一条允许使用的车道
+func (s *TrafficService) assignLane(vehicle *models.Vehicle) {
+	vehicle.Lane = s.rng.IntN(s.highestAllowedLane(vehicle.VehicleType, vehicle.RoadID) + 1)
+}
+
+// clampLane 驶入新路段后将车道限制在有效范围内
+func (s *TrafficService) clampLane(vehicle *models.Vehicle) {
+	if highest := s.network.LaneCount(vehicle.RoadID) - 1; vehicle.Lane > highest {
+		vehicle.Lane = highest
+	}
+	if vehicle.Lane < 0 {
+		vehicle.Lane = 0
+	}
+}
+
+// buildLaneIndex 按车道分组，组内按行驶距离升序排列
+func (s *TrafficService) buildLaneIndex() map[laneKey][]int {
+	index := make(map[laneKey][]int)
+	for i := range s.vehicles {
+		if s.vehicles[i].RoadID == 0 {
+			continue
+		}
+		key := laneKey{roadID: s.vehicles[i].RoadID, lane: s.vehicles[i].Lane}
+		index[key] = append(index[key], i)
+	}
+
+	for _, indices := range index {
+		sort.SliceStable(indices, func(a, b int) bool {
+			return s.vehicles[indices[a]].Offset < s.vehicles[indices[b]].Offset
+		})
+	}
+	return index
+}
+
+// laneNeighbors 查找车道内位于指定车辆前后的车辆（-1表示不存在），跳过车辆自身
+func (s *TrafficService) laneNeighbors(indices []int, self int) (int, int) {
+	offset := s.vehicles[self].Offset
+	leader, follower := -1, -1
+	for _, idx := range indices {
+		if idx == self {
+			continue
+		}
+		if s.vehicles[idx].Offset >= offset {
+			leader = idx
+			break
+		}
+		follower = idx
+	}
+	return leader, follower
+}
+
+// changeLanes 按MOBIL模型（激励准则+安全准则）执行换道
+func (s *TrafficService) changeLanes() {
+	index := s.buildLaneIndex()
+
+	for i := range s.vehicles {
+		vehicle := &s.vehicles[i]
+		if vehicle.RoadID == 0 || s.network.LaneCount(vehicle.RoadID) < 2 {
+			continue
+		}
+
+		bestLane := vehicle.Lane
+		bestGain := 0.0
+		for _, target := range []int{vehicle.Lane - 1, vehicle.Lane + 1} {
+			if target < 0 || target >= s.network.LaneCount(vehicle.RoadID) {
+				continue
+			}
+			if gain, ok := s.laneChangeIncentive(index, i, target); ok && gain > bestGain {
+				bestLane = target
+				bestGain = gain
+			}
+		}
+
+		if bestLane != vehicle.Lane {
+			s.moveToLane(index, i, bestLane)
+		}
+	}
+}
+
+// laneChangeIncentive 计算换道到目标车道的净激励，不满足安全准则时返回false
+func (s *TrafficService) laneChangeIncentive(index map[laneKey][]int, idx, target int) (float64, bool) {
+	vehicle := &s.vehicles[idx]
+	leavingForbidden := !s.laneAllowed(vehicle.VehicleType, vehicle.Lane) && target < vehicle.Lane
+	if !s.laneAllowed(vehicle.VehicleType, target) && !leavingForbidden {
+		return 0, false
+	}
+	if s.laneBlockedAhead(vehicle.RoadID, target, vehicle.Offset) {
+		return 0, false
+	}
+	// 停站中的公交车不换道，即将进站的公交车只向外侧车道换道
+	approachingStop := s.approachingStop(vehicle)
+	if s.dwelling(vehicle) || (approachingStop && target > vehicle.Lane) {
+		return 0, false
+	}
+
+	rule := s.laneRuleFor(vehicle.VehicleType)
+	current := laneKey{roadID: vehicle.RoadID, lane: vehicle.Lane}
+	targetKey := laneKey{roadID: vehicle.RoadID, lane: target}
+
+	currentLeader, currentFollower := s.laneNeighbors(index[current], idx)
+	targetLeader, targetFollower := s.laneNeighbors(index[targetKey], idx)
+
+	// 安全准则：目标车道前后均有足够空间，且新后车无需紧急制动
+	length := s.idmParamsFor(vehicle.VehicleType).Length
+	if targetLeader >= 0 && s.vehicles[targetLeader].Offset-s.idmParamsFor(s.vehicles[targetLeader].VehicleType).Length <= vehicle.Offset {
+		return 0, false
+	}
+	if targetFollower >= 0 && vehicle.Offset-length <= s.vehicles[targetFollower].Offset {
+		return 0, false
+	}
+
+	newFollowerGain := 0.0
+	if targetFollower >= 0 {
+		newFollowerAcc := s.accelerationInLane(targetFollower, target, idx)
+		if newFollowerAcc < -safeDeceleration {
+			return 0, false
+		}
+		newFollowerGain = newFollowerAcc - s.accelerationInLane(targetFollower, target, targetLeader)
+	}
+
+	oldFollowerGain := 0.0
+	if currentFollower >= 0 {
+		oldFollowerGain = s.accelerationInLane(currentFollower, vehicle.Lane, currentLeader) -
+			s.accelerationInLane(currentFollower, vehicle.Lane, idx)
+	}
+
+	// 激励准则
+	gain := s.accelerationInLane(idx, target, targetLeader) - s.accelerationInLane(idx, vehicle.Lane, currentLeader) +
+		rule.Politeness*(newFollowerGain+oldFollowerGain) - laneChangeThreshold
+
+	if target < vehicle.Lane {
+		gain += rule.KeepRightBias
+	} else {
+		gain -= rule.KeepRightBias
+	}
+
+	// 强制换道：离开即将结束的车道或该车型禁行的车道
+	continuing := s.network.ContinuingLanes(vehicle.RoadID)
+	if vehicle.Lane >= continuing && target < vehicle.Lane {
+		gain += mandatoryLaneBias
+	}
+	if leavingForbidden {
+		gain += mandatoryLaneBias
+	}
+	if s.laneBlockedAhead(vehicle.RoadID, vehicle.Lane, vehicle.Offset) {
+		gain += mandatoryLaneBias
+	}
+	if approachingStop {
+		gain += mandatoryLaneBias
+	}
+
+	return gain, true
+}
+
+// moveToLane 执行换道并更新车道索引
+func (s *TrafficService) moveToLane(index map[laneKey][]int, idx, target int) {
+	vehicle := &s.vehicles[idx]
+	current := laneKey{roadID: vehicle.RoadID, lane: vehicle.Lane}
+	targetKey := laneKey{roadID: vehicle.RoadID, lane: target}
+
+	indices := index[current]
+	for k, i := range indices {
+		if i == idx {
+			index[current] = append(indices[:k], indices[k+1:]...)
+			break
+		}
+	}
+
+	targetIndices := index[targetKey]
+	pos := sort.Search(len(targetIndices), func(k int) bool {
+		return s.vehicles[targetIndices[k]].Offset >= vehicle.Offset
+	})
+	targetIndices = append(targetIndices, 0)
+	copy(targetIndices[pos+1:], targetIndices[pos:])
+	targetIndices[pos] = idx
+	index[targetKey] = targetIndices
+
+	vehicle.Lane = target
+}
+
+// GetLaneOccupancy 获取车道占用情况
+// roadID 大于0时返回该路段全部车道，否则返回所有有车的车道
+func (s *TrafficService) GetLaneOccupancy(roadID uint) []LaneOccupancy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	occupancy := make(map[laneKey]*LaneOccupancy)
+	if roadID > 0 {
+		for lane := 0; lane < s.network.LaneCount(roadID); lane++ {
+			key := laneKey{roadID: roadID, lane: lane}
+			occupancy[key] = &LaneOccupancy{RoadID: roadID, Lane: lane}
+		}
+	}
+
+	for _, vehicle := range s.vehicles {
+		if vehicle.RoadID == 0 || (roadID > 0 && vehicle.RoadID != roadID) {
+			continue
+		}
+		key := laneKey{roadID: vehicle.RoadID, lane: vehicle.Lane}
+		if occupancy[key] == nil {
+			occupancy[key] = &LaneOccupancy{RoadID: vehicle.RoadID, Lane: vehicle.Lane}
+		}
+		occupancy[key].VehicleCount++
+		occupancy[key].AverageSpeed += vehicle.Speed
+	}
+
+	result := make([]LaneOccupancy, 0, len(occupancy))
+	for _, item := range occupancy {
+		if item.VehicleCount > 0 {
+			item.AverageSpeed /= float64(item.VehicleCount)
+			item.Density = float64(item.VehicleCount) / (s.network.SegmentLength(item.RoadID) / 1000)
+		}
+		result = append(result, *item)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].RoadID != result[j].RoadID {
+			return result[i].RoadID < result[j].RoadID
+		}
+		return result[i].Lane < result[j].Lane
+	})
+	return result
+}
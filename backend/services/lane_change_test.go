@@ -0,0 +1,98 @@
+package services
+
+import "testing"
+
+// newLaneService 一条约1.7公里、限速60的多车道路段上放置指定车辆
+func newLaneService(t *testing.T, lanes int, vehicles ...ScenarioVehicle) *TrafficService {
+	t.Helper()
+	s := NewStandaloneTrafficService()
+	scenario := Scenario{
+		Name:     "lanes",
+		Roads:    []ScenarioRoad{{ID: 1, StartLng: 116.0, StartLat: 39.0, EndLng: 116.02, EndLat: 39.0, MaxSpeed: 60, Lanes: lanes}},
+		Vehicles: vehicles,
+	}
+	if err := s.LoadScenario(scenario); err != nil {
+		t.Fatalf("load scenario: %v", err)
+	}
+	return s
+}
+
+func laneOf(t *testing.T, s *TrafficService, vehicleID string) int {
+	t.Helper()
+	for _, vehicle := range s.vehicles {
+		if vehicle.VehicleID == vehicleID {
+			return vehicle.Lane
+		}
+	}
+	t.Fatalf("vehicle %s not found", vehicleID)
+	return -1
+}
+
+// 紧跟慢车的小汽车换到空闲的内侧车道超车，不礼让的慢车留在原车道
+func TestMOBILOvertakesSlowLeader(t *testing.T) {
+	s := newLaneService(t, 2,
+		ScenarioVehicle{VehicleID: "slow", VehicleType: "truck", RoadID: 1, Offset: 120, Speed: 10},
+		ScenarioVehicle{VehicleID: "fast", RoadID: 1, Offset: 100, Speed: 55},
+	)
+	truck, _ := s.GetVehicleProfile("truck")
+	truck.Politeness = 0
+	if _, err := s.SetVehicleProfile(truck); err != nil {
+		t.Fatalf("set profile: %v", err)
+	}
+	s.changeLanes()
+	if lane := laneOf(t, s, "fast"); lane != 1 {
+		t.Fatalf("fast car stayed in lane %d", lane)
+	}
+	if lane := laneOf(t, s, "slow"); lane != 0 {
+		t.Fatalf("truck moved to lane %d", lane)
+	}
+}
+
+// 目标车道相邻位置有车时不满足安全准则，不换道
+func TestMOBILRejectsUnsafeGap(t *testing.T) {
+	s := newLaneService(t, 2,
+		ScenarioVehicle{VehicleID: "slow", VehicleType: "truck", RoadID: 1, Offset: 120, Speed: 10},
+		ScenarioVehicle{VehicleID: "fast", RoadID: 1, Offset: 100, Speed: 55},
+		ScenarioVehicle{VehicleID: "beside", RoadID: 1, Lane: 1, Offset: 102, Speed: 55},
+	)
+	s.changeLanes()
+	if lane := laneOf(t, s, "fast"); lane != 0 {
+		t.Fatalf("fast car cut into lane %d beside another vehicle", lane)
+	}
+}
+
+// 货车不能使用最外侧两条车道以外的车道，位于禁行车道时强制换出
+func TestLaneRuleRestrictsTrucks(t *testing.T) {
+	s := newLaneService(t, 3, ScenarioVehicle{VehicleID: "truck", VehicleType: "truck", RoadID: 1, Lane: 2, Offset: 100, Speed: 50})
+	if highest := s.highestAllowedLane("truck", 1); highest != 1 {
+		t.Fatalf("highest truck lane = %d, want 1", highest)
+	}
+	s.changeLanes()
+	if lane := laneOf(t, s, "truck"); lane != 1 {
+		t.Fatalf("truck in lane %d, want 1", lane)
+	}
+	for i := 0; i < 50; i++ {
+		vehicle := s.vehicles[0]
+		s.assignLane(&vehicle)
+		if vehicle.Lane > 1 {
+			t.Fatalf("truck assigned to lane %d", vehicle.Lane)
+		}
+	}
+}
+
+func TestGetLaneOccupancy(t *testing.T) {
+	s := newLaneService(t, 2,
+		ScenarioVehicle{VehicleID: "a", RoadID: 1, Lane: 0, Offset: 100, Speed: 40},
+		ScenarioVehicle{VehicleID: "b", RoadID: 1, Lane: 0, Offset: 300, Speed: 60},
+	)
+	occupancy := s.GetLaneOccupancy(1)
+	if len(occupancy) != 2 {
+		t.Fatalf("occupancy = %+v, want both lanes", occupancy)
+	}
+	if lane := occupancy[0]; lane.VehicleCount != 2 || lane.AverageSpeed != 50 || !near(lane.Density, 2/(s.network.SegmentLength(1)/1000), 1e-9) {
+		t.Fatalf("lane 0 = %+v", lane)
+	}
+	if occupancy[1].VehicleCount != 0 {
+		t.Fatalf("lane 1 = %+v, want empty", occupancy[1])
+	}
+}
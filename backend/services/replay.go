@@ -0,0 +1,338 @@
+package services
+
+import (
+	"backend/models"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	maxReplayPoints = 500000             // 单次回放最多加载的GPS点数
+	maxReplayRange  = 7 * 24 * time.Hour // 回放时间范围上限
+	replayGap       = 2 * time.Minute    // 相邻点间隔超过该值视为轨迹中断，中断期间车辆不显示
+	replayLinger    = 30 * time.Second   // 轨迹最后一点之后车辆继续显示的时长
+)
+
+// ErrTooManyReplayPoints 回放范围内GPS点过多
+var ErrTooManyReplayPoints = fmt.Errorf("more than %d GPS points in range, narrow the time range or filter by vehicle or road", maxReplayPoints)
+
+// ReplayOptions 回放参数
+type ReplayOptions struct {
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+	VehicleIDs []string  `json:"vehicle_ids,omitempty"` // 为空时回放全部车辆
+	RoadID     uint      `json:"road_id,omitempty"`     // 只回放匹配到该路段的GPS点，为0时不限
+	Speed      *float64  `json:"speed"`                 // 回放倍速，0表示尽可能快，默认1
+	TimeStep   *float64  `json:"time_step"`             // 回放步长 (s)，默认1
+}
+
+// Validate 校验回放时间范围
+func (o *ReplayOptions) Validate() error {
+	if o.From.IsZero() || o.To.IsZero() {
+		return errors.New("from and to are required")
+	}
+	if !o.To.After(o.From) {
+		return errors.New("to must be later than from")
+	}
+	if o.To.Sub(o.From) > maxReplayRange {
+		return fmt.Errorf("replay range cannot exceed %s", maxReplayRange)
+	}
+	return nil
+}
+
+// ReplayStatus 回放状态
+type ReplayStatus struct {
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+	VehicleIDs []string  `json:"vehicle_ids,omitempty"`
+	RoadID     uint      `json:"road_id,omitempty"`
+	Points     int       `json:"points"`   // 加载的GPS点数
+	Vehicles   int       `json:"vehicles"` // 轨迹数
+	Progress   float64   `json:"progress"` // 已回放比例 0-1
+	Finished   bool      `json:"finished"`
+}
+
+// replayTrack 一辆车的GPS轨迹
+type replayTrack struct {
+	vehicleID   string
+	vehicleType string
+	points      []models.GPSData // 按时间排序
+	next        int              // 第一个晚于当前回放时刻的点
+}
+
+// replayState 回放运行状态
+type replayState struct {
+	options  ReplayOptions
+	tracks   []*replayTrack
+	points   int
+	saved    SimulationSnapshot // 回放开始前的模拟状态，结束回放时恢复
+	finished bool
+}
+
+// GetReplayTrace 按回放参数查询GPS点
+func (s *GPSService) GetReplayTrace(options ReplayOptions) ([]models.GPSData, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	var roadIDs []uint
+	if options.RoadID != 0 {
+		roadIDs = []uint{options.RoadID}
+	}
+	points, err := s.gpsRepo.FindTrace(options.From, options.To, options.VehicleIDs, roadIDs, maxReplayPoints+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) > maxReplayPoints {
+		return nil, ErrTooManyReplayPoints
+	}
+	return points, nil
+}
+
+// StartReplay 用GPS点回放车辆轨迹，替代模拟驱动车辆位置，车辆、告警、流量和状态接口与模拟时相同
+// 回放开始前保存当前模拟状态，StopReplay 时恢复；回放中可暂停、恢复、单步和修改倍速，回放到结束时刻后自动停止
+func (s *TrafficService) StartReplay(options ReplayOptions, points []models.GPSData) (ReplayStatus, error) {
+	if err := options.Validate(); err != nil {
+		return ReplayStatus{}, err
+	}
+	if len(points) == 0 {
+		return ReplayStatus{}, errors.New("no GPS data in the selected range")
+	}
+
+	clock := NewSimulationClock(options.From)
+	if err := clock.Apply(ClockOptions{TimeStep: options.TimeStep, Speed: options.Speed}); err != nil {
+		return ReplayStatus{}, err
+	}
+
+	s.mu.RLock()
+	running := s.simulating
+	replaying := s.replay != nil
+	s.mu.RUnlock()
+	if running {
+		return ReplayStatus{}, errors.New("stop the simulation before starting a replay")
+	}
+
+	// 已在回放时保留最初保存的模拟状态
+	var saved SimulationSnapshot
+	if !replaying {
+		var err error
+		if saved, err = s.Snapshot(); err != nil {
+			return ReplayStatus{}, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.simulating {
+		return ReplayStatus{}, errors.New("stop the simulation before starting a replay")
+	}
+	if s.replay != nil {
+		saved = s.replay.saved
+	}
+
+	replay := &replayState{options: options, points: len(points), saved: saved}
+	tracks := make(map[string]*replayTrack)
+	for _, point := range points {
+		track, ok := tracks[point.VehicleID]
+		if !ok {
+			track = &replayTrack{vehicleID: point.VehicleID, vehicleType: point.VehicleType}
+			if track.vehicleType == "" {
+				track.vehicleType = defaultVehicleType
+			}
+			tracks[point.VehicleID] = track
+			replay.tracks = append(replay.tracks, track)
+		}
+		track.points = append(track.points, point)
+	}
+
+	// 模拟产生的运行状态在回放期间清空，结束回放时从保存的状态恢复
+	s.replay = replay
+	s.clock = clock
+	s.vehicles = make([]models.Vehicle, 0)
+	s.departures = nil
+	s.alerts = make([]models.TrafficAlert, 0)
+	s.nextAlertID = 0
+	s.alertCounts = nil
+	s.incidents = nil
+	s.demandStats = DemandStats{}
+	s.flowRecords = nil
+	s.trips = nil
+	s.tripStats = TripStats{}
+	s.emissionTotals = EmissionTotals{}
+	s.emissionRecords = nil
+	s.trajectories = nil
+	s.reroutingStats = ReroutingStats{}
+	s.gpsStats = GPSEmissionStats{}
+	s.gpsSamples = nil
+	s.gpsPending = nil
+	s.transitTrips = make(map[string]*TransitTrip)
+	s.stopEvents = nil
+	s.positionReplay()
+
+	s.simulating = true
+	s.paused = false
+	s.stopChan = make(chan struct{})
+	go s.runSimulation(s.stopChan)
+	return s.replayStatus(), nil
+}
+
+// StopReplay 结束回放并恢复回放开始前的模拟状态
+func (s *TrafficService) StopReplay() error {
+	s.mu.Lock()
+	if s.replay == nil {
+		s.mu.Unlock()
+		return errors.New("no replay in progress")
+	}
+	if s.simulating {
+		s.simulating = false
+		s.paused = false
+		close(s.stopChan)
+	}
+	saved := s.replay.saved
+	s.mu.Unlock()
+
+	return s.Restore(saved)
+}
+
+// GetReplayStatus 获取回放状态，未在回放时返回false
+func (s *TrafficService) GetReplayStatus() (ReplayStatus, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.replay == nil {
+		return ReplayStatus{}, false
+	}
+	return s.replayStatus(), true
+}
+
+// replaying 是否处于回放模式
+func (s *TrafficService) replaying() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.replay != nil
+}
+
+// replayStatus 当前回放状态，调用方持有锁
+func (s *TrafficService) replayStatus() ReplayStatus {
+	options := s.replay.options
+	progress := s.clock.Now().Sub(options.From).Seconds() / options.To.Sub(options.From).Seconds()
+	return ReplayStatus{
+		From:       options.From,
+		To:         options.To,
+		VehicleIDs: options.VehicleIDs,
+		RoadID:     options.RoadID,
+		Points:     s.replay.points,
+		Vehicles:   len(s.replay.tracks),
+		Progress:   math.Max(0, math.Min(1, progress)),
+		Finished:   s.replay.finished,
+	}
+}
+
+// updateReplay 推进一个回放步长，到达结束时刻后停止运行
+func (s *TrafficService) updateReplay() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 停止回放与正在执行的步长并发时回放可能已结束
+	if s.replay == nil {
+		return
+	}
+
+	s.clock.Advance()
+	s.positionReplay()
+
+	if !s.clock.Now().Before(s.replay.options.To) {
+		s.replay.finished = true
+		if s.simulating {
+			s.simulating = false
+			s.paused = false
+			close(s.stopChan)
+		}
+	}
+}
+
+// positionReplay 按当前回放时刻更新车辆，车辆出现和消失计入流量
+func (s *TrafficService) positionReplay() {
+	now := s.clock.Now()
+	present := make(map[string]bool, len(s.vehicles))
+	for _, vehicle := range s.vehicles {
+		present[vehicle.VehicleID] = true
+	}
+
+	incoming := 0
+	vehicles := s.vehicles[:0]
+	for i, track := range s.replay.tracks {
+		vehicle, ok := track.position(now)
+		if !ok {
+			continue
+		}
+		if vehicle.RoadID != 0 {
+			if _, ok := s.network.Segment(vehicle.RoadID); !ok {
+				vehicle.RoadID = 0
+			}
+		}
+		vehicle.ID = uint(i + 1)
+		vehicle.Status = s.vehicleStatus(&vehicle)
+		vehicle.UpdatedAt = now
+
+		if present[vehicle.VehicleID] {
+			delete(present, vehicle.VehicleID)
+		} else {
+			incoming++
+		}
+		vehicles = append(vehicles, vehicle)
+	}
+	s.vehicles = vehicles
+
+	if incoming > 0 || len(present) > 0 {
+		s.recordFlow(incoming, len(present))
+	}
+}
+
+// position 轨迹在指定时刻的车辆状态，相邻两点之间线性插值
+// 早于第一个点、处于轨迹中断期间或超过最后一点的保留时长时返回false
+func (t *replayTrack) position(now time.Time) (models.Vehicle, bool) {
+	// 回放时刻回退时重新查找
+	if t.next > 0 && t.points[t.next-1].Timestamp.After(now) {
+		t.next = 0
+	}
+	for t.next < len(t.points) && !t.points[t.next].Timestamp.After(now) {
+		t.next++
+	}
+	if t.next == 0 {
+		return models.Vehicle{}, false
+	}
+
+	first := &t.points[0]
+	prev := &t.points[t.next-1]
+	vehicle := models.Vehicle{
+		VehicleID:     t.vehicleID,
+		VehicleType:   t.vehicleType,
+		X:             prev.Longitude,
+		Y:             prev.Latitude,
+		Speed:         float64(prev.Speed),
+		Direction:     float64(prev.Direction),
+		RoadID:        prev.RoadSegmentID(),
+		Origin:        first.RoadSegmentID(),
+		DepartureTime: first.Timestamp,
+		CreatedAt:     first.Timestamp,
+	}
+
+	if t.next < len(t.points) {
+		next := &t.points[t.next]
+		if gap := next.Timestamp.Sub(prev.Timestamp); gap <= replayGap {
+			ratio := now.Sub(prev.Timestamp).Seconds() / gap.Seconds()
+			vehicle.X += (next.Longitude - prev.Longitude) * ratio
+			vehicle.Y += (next.Latitude - prev.Latitude) * ratio
+			vehicle.Speed += float64(next.Speed-prev.Speed) * ratio
+			return vehicle, true
+		}
+	}
+	if now.Sub(prev.Timestamp) > replayLinger {
+		return models.Vehicle{}, false
+	}
+	return vehicle, true
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"backend/models"
+	"testing"
+	"time"
+)
+
+// gpsPoint 回放起点之后 seconds 秒的GPS点
+func gpsPoint(vehicleID string, seconds int, lng float64, speed int, roadID uint) models.GPSData {
+	point := models.GPSData{
+		VehicleID: vehicleID,
+		Longitude: lng,
+		Latitude:  39.0,
+		Speed:     speed,
+		Timestamp: time.Date(2024, 5, 1, 7, 0, seconds, 0, time.UTC),
+	}
+	if roadID != 0 {
+		point.RoadSegment = &models.RoadSegment{ID: roadID}
+	}
+	return point
+}
+
+// 相邻两点之间按时间线性插值位置和车速，间隔超过中断阈值时停留在前一点，超过保留时长后不显示
+func TestReplayTrackPosition(t *testing.T) {
+	track := &replayTrack{vehicleID: "V1", vehicleType: defaultVehicleType, points: []models.GPSData{
+		gpsPoint("V1", 0, 116.000, 30, 1),
+		gpsPoint("V1", 10, 116.001, 50, 1),
+		gpsPoint("V1", 300, 116.005, 20, 2),
+	}}
+	at := func(seconds int) time.Time { return time.Date(2024, 5, 1, 7, 0, seconds, 0, time.UTC) }
+
+	if _, ok := track.position(at(-1)); ok {
+		t.Fatal("vehicle shown before its first point")
+	}
+	vehicle, ok := track.position(at(5))
+	if !ok || !near(vehicle.X, 116.0005, 1e-9) || vehicle.Speed != 40 || vehicle.RoadID != 1 || vehicle.Origin != 1 {
+		t.Fatalf("midway = %+v, ok = %v", vehicle, ok)
+	}
+	if !vehicle.DepartureTime.Equal(at(0)) || vehicle.VehicleType != defaultVehicleType {
+		t.Fatalf("midway trip fields = %+v", vehicle)
+	}
+
+	// 10s 与 300s 之间的间隔超过 replayGap，视为轨迹中断
+	tests := []struct {
+		seconds int
+		visible bool
+		x       float64
+	}{
+		{20, true, 116.001},
+		{40, true, 116.001},
+		{41, false, 0},
+		{300, true, 116.005},
+		{330, true, 116.005},
+		{331, false, 0},
+	}
+	for _, tt := range tests {
+		vehicle, ok := track.position(at(tt.seconds))
+		if ok != tt.visible || (ok && vehicle.X != tt.x) {
+			t.Fatalf("at %ds: vehicle = %+v, ok = %v, want visible %v at %v", tt.seconds, vehicle, ok, tt.visible, tt.x)
+		}
+	}
+
+	// 回放时刻回退时重新查找
+	if vehicle, ok := track.position(at(5)); !ok || !near(vehicle.X, 116.0005, 1e-9) {
+		t.Fatalf("after rewind = %+v, ok = %v", vehicle, ok)
+	}
+}
+
+// 回放期间车辆按GPS轨迹出现和消失并计入流量，结束回放后恢复回放前的模拟状态
+func TestReplayDrivesVehicles(t *testing.T) {
+	s := newNetworkService(t)
+	if _, err := s.AddVehicle(models.Vehicle{VehicleID: "SIM", RoadID: 1, Speed: 30}); err != nil {
+		t.Fatalf("add vehicle: %v", err)
+	}
+	before := runState(t, s)
+
+	from := time.Date(2024, 5, 1, 7, 0, 0, 0, time.UTC)
+	points := []models.GPSData{
+		gpsPoint("A", 0, 116.000, 30, 1),
+		gpsPoint("A", 20, 116.002, 30, 1),
+		gpsPoint("B", 10, 116.012, 40, 2),
+		gpsPoint("B", 20, 116.013, 40, 9), // 路网中不存在的路段
+	}
+	options := ReplayOptions{From: from, To: from.Add(time.Minute)}
+	status, err := s.StartReplay(options, points)
+	if err != nil {
+		t.Fatalf("start replay: %v", err)
+	}
+	if err := s.PauseSimulation(); err != nil {
+		t.Fatalf("pause replay: %v", err)
+	}
+	if status.Points != 4 || status.Vehicles != 2 || len(s.GetVehicles()) != 1 {
+		t.Fatalf("status = %+v, %d vehicles", status, len(s.GetVehicles()))
+	}
+	if _, err := s.AddVehicle(models.Vehicle{VehicleID: "X"}); err == nil {
+		t.Fatal("added a vehicle during the replay")
+	}
+
+	if err := s.StepSimulation(20); err != nil {
+		t.Fatalf("step: %v", err)
+	}
+	vehicles := s.GetVehicles()
+	if len(vehicles) != 2 || vehicles[0].VehicleID != "A" || vehicles[0].X != 116.002 || vehicles[1].RoadID != 0 {
+		t.Fatalf("vehicles at 20s = %+v", vehicles)
+	}
+
+	// 最后一点之后保留30秒，之后离开路网
+	if err := s.StepSimulation(40); err != nil {
+		t.Fatalf("step: %v", err)
+	}
+	status, _ = s.GetReplayStatus()
+	if len(s.GetVehicles()) != 0 || !status.Finished || status.Progress != 1 {
+		t.Fatalf("at the end: status = %+v, %d vehicles", status, len(s.GetVehicles()))
+	}
+	if flow := s.GetVehicleFlow(); flow.IncomingVehicles != 2 || flow.OutgoingVehicles != 2 {
+		t.Fatalf("flow = %+v", flow)
+	}
+
+	if err := s.StopReplay(); err != nil {
+		t.Fatalf("stop replay: %v", err)
+	}
+	if _, ok := s.GetReplayStatus(); ok || runState(t, s) != before {
+		t.Fatal("simulation state not restored after the replay")
+	}
+	if err := s.StopReplay(); err == nil {
+		t.Fatal("stopped a replay that is not running")
+	}
+}
+
+// 非法的回放范围和空轨迹被拒绝
+func TestStartReplayValidates(t *testing.T) {
+	s := newNetworkService(t)
+	from := time.Date(2024, 5, 1, 7, 0, 0, 0, time.UTC)
+	points := []models.GPSData{gpsPoint("A", 0, 116, 30, 1)}
+	tests := []struct {
+		options ReplayOptions
+		points  []models.GPSData
+	}{
+		{ReplayOptions{From: from}, points},
+		{ReplayOptions{From: from, To: from}, points},
+		{ReplayOptions{From: from, To: from.Add(8 * 24 * time.Hour)}, points},
+		{ReplayOptions{From: from, To: from.Add(time.Hour)}, nil},
+	}
+	for _, tt := range tests {
+		if _, err := s.StartReplay(tt.options, tt.points); err == nil {
+			t.Fatalf("accepted replay %+v with %d points", tt.options, len(tt.points))
+		}
+	}
+	if _, ok := s.GetReplayStatus(); ok {
+		t.Fatal("replay started")
+	}
+}
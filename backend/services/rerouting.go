@@ -0,0 +1,203 @@
+package services
+
+import (
+	"backend/models"
+	"errors"
+	"math"
+)
+
+// ReroutingConfig 动态路径诱导配置
+// 有导航的车辆（informed）按当前路段车速定期重新规划路径，事件发生在其剩余路径上时立即重新规划
+type ReroutingConfig struct {
+	InformedShare  float64 `json:"informed_share"`  // 新发车辆中有导航车辆的比例 (0-1)
+	Interval       float64 `json:"interval"`        // 定期重新规划间隔 (s)，0表示不定期规划
+	OnIncident     bool    `json:"on_incident"`     // 剩余路径上发生事件时是否立即重新规划
+	MinImprovement float64 `json:"min_improvement"` // 新路径行程时间至少缩短的比例 (0-1)，避免路径来回切换
+}
+
+// ReroutingStats 路径诱导统计
+type ReroutingStats struct {
+	Informed         int `json:"informed"`          // 路网中有导航的车辆数
+	Evaluations      int `json:"evaluations"`       // 重新规划次数
+	Reroutes         int `json:"reroutes"`          // 改变路径次数
+	IncidentReroutes int `json:"incident_reroutes"` // 其中因事件改变路径的次数
+}
+
+// validateRerouting 校验路径诱导配置
+func validateRerouting(config *ReroutingConfig) error {
+	if config.InformedShare < 0 || config.InformedShare > 1 {
+		return errors.New("informed_share must be between 0 and 1")
+	}
+	if config.Interval < 0 {
+		return errors.New("interval cannot be negative")
+	}
+	if config.MinImprovement < 0 || config.MinImprovement >= 1 {
+		return errors.New("min_improvement must be between 0 and 1")
+	}
+	return nil
+}
+
+// assignInformed 按比例决定新发车辆是否有导航，比例为0时不消耗随机数
+func (s *TrafficService) assignInformed(vehicle *models.Vehicle) {
+	if s.rerouting.InformedShare > 0 && len(vehicle.Route) > 0 {
+		vehicle.Informed = s.rng.Float64() < s.rerouting.InformedShare
+	}
+}
+
+// segmentCosts 按路网上车辆的当前平均车速计算路段行程时间
+func (s *TrafficService) segmentCosts() routeCosts {
+	totals := make(map[uint]float64)
+	counts := make(map[uint]int)
+	for i := range s.vehicles {
+		if roadID := s.vehicles[i].RoadID; roadID != 0 {
+			totals[roadID] += s.vehicles[i].Speed
+			counts[roadID]++
+		}
+	}
+
+	speeds := make(map[uint]float64, len(totals))
+	for roadID, total := range totals {
+		speeds[roadID] = total / float64(counts[roadID])
+	}
+	return routeCosts{network: s.network, speeds: speeds}
+}
+
+// rerouteCost 重新规划使用的路段代价：当前行程时间，并计入已生效事件
+// 封闭全部车道的路段不可通行，部分封闭时按通行能力折减增加行程时间
+func (s *TrafficService) rerouteCost(costs routeCosts) func(uint) float64 {
+	return func(roadID uint) float64 {
+		cost := costs.currentTime(roadID)
+		for i := range s.incidents {
+			incident := &s.incidents[i]
+			if !incident.Active || incident.RoadID != roadID {
+				continue
+			}
+			if len(incident.Lanes) >= s.network.LaneCount(roadID) {
+				return math.Inf(1)
+			}
+			cost /= math.Max(1-incident.CapacityReduction, minIncidentSpeedFactor)
+		}
+		return cost
+	}
+}
+
+// reroute 从当前路段重新规划到目的路段的路径，新路径足够快时替换剩余路径，返回是否改变路径
+func (s *TrafficService) reroute(vehicle *models.Vehicle, cost func(uint) float64) bool {
+	if vehicle.RouteIndex >= len(vehicle.Route)-1 {
+		return false
+	}
+	s.reroutingStats.Evaluations++
+
+	current := 0.0
+	for _, roadID := range vehicle.Route[vehicle.RouteIndex+1:] {
+		current += cost(roadID)
+	}
+
+	// 当前路段两条路径共有，不计入比较
+	remaining := func(roadID uint) float64 {
+		if roadID == vehicle.RoadID {
+			return 0
+		}
+		return cost(roadID)
+	}
+	destination := vehicle.Route[len(vehicle.Route)-1]
+	path, candidate := s.network.FindPath(vehicle.RoadID, destination, remaining, nil)
+	if path == nil || math.IsInf(candidate, 1) || candidate >= current*(1-s.rerouting.MinImprovement) {
+		return false
+	}
+
+	route := append([]uint(nil), vehicle.Route[:vehicle.RouteIndex]...)
+	vehicle.Route = append(route, path...)
+	s.reroutingStats.Reroutes++
+	return true
+}
+
+// updateRerouting 有导航的车辆按间隔错开时刻定期重新规划路径
+func (s *TrafficService) updateRerouting(dt float64) {
+	if s.rerouting.Interval <= 0 {
+		return
+	}
+
+	now := s.clock.ElapsedSeconds()
+	var cost func(uint) float64
+	for i := range s.vehicles {
+		vehicle := &s.vehicles[i]
+		if !vehicle.Informed || vehicle.RoadID == 0 {
+			continue
+		}
+		if !staggeredDue(vehicle.ID, now, s.rerouting.Interval, dt) {
+			continue
+		}
+		if cost == nil {
+			cost = s.rerouteCost(s.segmentCosts())
+		}
+		s.reroute(vehicle, cost)
+	}
+}
+
+// rerouteAroundIncident 事件生效时，剩余路径经过事件位置的有导航车辆立即重新规划
+func (s *TrafficService) rerouteAroundIncident(incident *Incident) {
+	if !s.rerouting.OnIncident {
+		return
+	}
+
+	var cost func(uint) float64
+	for i := range s.vehicles {
+		vehicle := &s.vehicles[i]
+		if !vehicle.Informed || vehicle.RoadID == 0 || !s.incidentOnRoute(vehicle, incident) {
+			continue
+		}
+		if cost == nil {
+			cost = s.rerouteCost(s.segmentCosts())
+		}
+		if s.reroute(vehicle, cost) {
+			s.reroutingStats.IncidentReroutes++
+		}
+	}
+}
+
+// incidentOnRoute 判断事件是否位于车辆尚未驶过的路径上（不含当前路段）
+// 当前路段上的事件无法绕行，由换道处理
+func (s *TrafficService) incidentOnRoute(vehicle *models.Vehicle, incident *Incident) bool {
+	for _, roadID := range vehicle.Route[min(vehicle.RouteIndex+1, len(vehicle.Route)):] {
+		if roadID == incident.RoadID {
+			return true
+		}
+	}
+	return false
+}
+
+// GetRerouting 获取路径诱导配置
+func (s *TrafficService) GetRerouting() ReroutingConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rerouting
+}
+
+// SetRerouting 设置路径诱导配置，有导航车辆的比例对之后发出的车辆生效
+func (s *TrafficService) SetRerouting(config ReroutingConfig) (ReroutingConfig, error) {
+	if err := validateRerouting(&config); err != nil {
+		return ReroutingConfig{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rerouting = config
+	return config, nil
+}
+
+// GetReroutingStats 获取路径诱导统计
+func (s *TrafficService) GetReroutingStats() ReroutingStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := s.reroutingStats
+	stats.Informed = 0
+	for i := range s.vehicles {
+		if s.vehicles[i].Informed {
+			stats.Informed++
+		}
+	}
+	return stats
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"backend/models"
+	"slices"
+	"testing"
+)
+
+// newDetourService 路段5之后可经 1、2 或 3、4 到达路段6，两条路径的限速相同
+func newDetourService(t *testing.T, config ReroutingConfig) *TrafficService {
+	t.Helper()
+	scenario := Scenario{
+		Roads: []ScenarioRoad{
+			{ID: 1, StartLng: 116.00, StartLat: 39.00, EndLng: 116.01, EndLat: 39.00, MaxSpeed: 60, Lanes: 1},
+			{ID: 2, StartLng: 116.01, StartLat: 39.00, EndLng: 116.01, EndLat: 39.01, MaxSpeed: 60, Lanes: 1},
+			{ID: 3, StartLng: 116.00, StartLat: 39.00, EndLng: 116.00, EndLat: 39.01, MaxSpeed: 60, Lanes: 1},
+			{ID: 4, StartLng: 116.00, StartLat: 39.01, EndLng: 116.01, EndLat: 39.01, MaxSpeed: 60, Lanes: 1},
+			{ID: 5, StartLng: 115.99, StartLat: 39.00, EndLng: 116.00, EndLat: 39.00, MaxSpeed: 60, Lanes: 1},
+			{ID: 6, StartLng: 116.01, StartLat: 39.01, EndLng: 116.02, EndLat: 39.01, MaxSpeed: 60, Lanes: 1},
+		},
+		Rerouting: config,
+	}
+	s := NewStandaloneTrafficService()
+	if err := s.LoadScenario(scenario); err != nil {
+		t.Fatalf("load scenario: %v", err)
+	}
+	return s
+}
+
+// 剩余路径上的事件封闭全部车道时，有导航的车辆立即绕行，无导航的车辆保持原路径
+func TestRerouteAroundIncident(t *testing.T) {
+	s := newDetourService(t, ReroutingConfig{OnIncident: true})
+	s.vehicles = []models.Vehicle{
+		{ID: 1, VehicleID: "I1", RoadID: 5, Route: []uint{5, 1, 2, 6}, Informed: true},
+		{ID: 2, VehicleID: "U1", RoadID: 5, Route: []uint{5, 1, 2, 6}},
+	}
+	if _, err := s.CreateIncident(Incident{RoadID: 1, Position: 400, Lanes: []int{0}}); err != nil {
+		t.Fatalf("create incident: %v", err)
+	}
+
+	if route := s.vehicles[0].Route; !slices.Equal(route, []uint{5, 3, 4, 6}) {
+		t.Fatalf("informed route = %v, want [5 3 4 6]", route)
+	}
+	if route := s.vehicles[1].Route; !slices.Equal(route, []uint{5, 1, 2, 6}) {
+		t.Fatalf("uninformed route = %v, want unchanged", route)
+	}
+	if stats := s.GetReroutingStats(); stats.Reroutes != 1 || stats.IncidentReroutes != 1 || stats.Informed != 1 {
+		t.Fatalf("stats = %+v", stats)
+	}
+
+	// 当前路段上的事件无法绕行，不重新规划
+	if _, err := s.CreateIncident(Incident{RoadID: 5, Position: 800, Lanes: []int{0}}); err != nil {
+		t.Fatalf("create incident: %v", err)
+	}
+	if stats := s.GetReroutingStats(); stats.Evaluations != 1 {
+		t.Fatalf("evaluations = %d, want 1", stats.Evaluations)
+	}
+}
+
+// 按当前车速规划：拥堵路段上的慢车使新路径足够快时改变路径，未达到最小改善比例时保持
+func TestRerouteUsesCurrentSpeeds(t *testing.T) {
+	s := newDetourService(t, ReroutingConfig{Interval: 60})
+	s.vehicles = []models.Vehicle{
+		{ID: 1, VehicleID: "I1", RoadID: 5, Route: []uint{5, 1, 2, 6}, Informed: true},
+		{ID: 2, VehicleID: "S1", RoadID: 1, Speed: 10},
+		{ID: 3, VehicleID: "S2", RoadID: 1, Speed: 20},
+	}
+
+	s.rerouting.MinImprovement = 0.9
+	if s.reroute(&s.vehicles[0], s.rerouteCost(s.segmentCosts())) {
+		t.Fatal("rerouted although the improvement is below min_improvement")
+	}
+
+	s.rerouting.MinImprovement = 0.1
+	if !s.reroute(&s.vehicles[0], s.rerouteCost(s.segmentCosts())) {
+		t.Fatal("did not reroute around the congested road")
+	}
+	if route := s.vehicles[0].Route; !slices.Equal(route, []uint{5, 3, 4, 6}) {
+		t.Fatalf("route = %v, want [5 3 4 6]", route)
+	}
+
+	// 已驶入目的路段的车辆不再规划
+	vehicle := models.Vehicle{ID: 4, RoadID: 6, Route: []uint{5, 3, 4, 6}, RouteIndex: 3, Informed: true}
+	if s.reroute(&vehicle, s.rerouteCost(s.segmentCosts())) || !slices.Equal(vehicle.Route, []uint{5, 3, 4, 6}) {
+		t.Fatalf("route = %v, want unchanged", vehicle.Route)
+	}
+}
+
+// 定期重新规划的时刻按车辆错开，每辆车每个间隔恰好规划一次
+func TestStaggeredReroutingIsDueOncePerInterval(t *testing.T) {
+	const interval, dt = 60.0, 1.0
+	phases := make(map[float64]bool)
+	for id := uint(1); id <= 20; id++ {
+		due := 0
+		for now := 0.0; now < 10*interval; now += dt {
+			if staggeredDue(id, now, interval, dt) {
+				due++
+				if now < interval {
+					phases[now] = true
+				}
+			}
+		}
+		if due != 10 {
+			t.Fatalf("vehicle %d due %d times in 10 intervals", id, due)
+		}
+	}
+	if len(phases) < 10 {
+		t.Fatalf("only %d distinct rerouting times for 20 vehicles", len(phases))
+	}
+}
+
+// 按比例分配有导航的车辆，没有路径的车辆不分配
+func TestAssignInformed(t *testing.T) {
+	s := newDetourService(t, ReroutingConfig{InformedShare: 1})
+	vehicle := models.Vehicle{Route: []uint{5, 1}}
+	s.assignInformed(&vehicle)
+	if !vehicle.Informed {
+		t.Fatal("share 1: vehicle not informed")
+	}
+	vehicle = models.Vehicle{}
+	s.assignInformed(&vehicle)
+	if vehicle.Informed {
+		t.Fatal("vehicle without a route is informed")
+	}
+
+	s.rerouting.InformedShare = 0.3
+	informed := 0
+	for i := 0; i < 2000; i++ {
+		vehicle := models.Vehicle{Route: []uint{5, 1}}
+		s.assignInformed(&vehicle)
+		if vehicle.Informed {
+			informed++
+		}
+	}
+	if share := float64(informed) / 2000; !near(share, 0.3, 0.04) {
+		t.Fatalf("informed share = %.3f, want 0.3", share)
+	}
+
+	for _, config := range []ReroutingConfig{{InformedShare: 1.5}, {Interval: -1}, {MinImprovement: 1}} {
+		if _, err := s.SetRerouting(config); err == nil {
+			t.Fatalf("accepted invalid config %+v", config)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"backend/algorithms"
+	"backend/repositories"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// 路径规划权重
+const (
+	RouteWeightDistance   = "distance"   // 最短距离
+	RouteWeightTime       = "time"       // 自由流行程时间最短
+	RouteWeightCongestion = "congestion" // 按当前拥堵状况的行程时间最短
+)
+
+const (
+	congestionWindow  = 5 * time.Minute // 统计当前车速使用的GPS数据时间范围
+	minCongestedSpeed = 5.0             // 拥堵路段车速下限 (km/h)
+)
+
+// ErrNoRoute 起终点之间不可达
+var ErrNoRoute = errors.New("no route between the given points")
+
+// RoutePoint 路径起终点及其在路网上的投影
+type RoutePoint struct {
+	Lng          float64 `json:"lng"`
+	Lat          float64 `json:"lat"`
+	RoadID       uint    `json:"road_id"`
+	Offset       float64 `json:"offset"`        // 投影点距路段起点的距离 (m)
+	SnapDistance float64 `json:"snap_distance"` // 坐标到路段的距离 (m)
+}
+
+// RouteSegment 路径中的路段，起止路段只计经过的部分
+type RouteSegment struct {
+	RoadID       uint    `json:"road_id"`
+	Name         string  `json:"name"`
+	Length       float64 `json:"length"`         // 经过的长度 (m)
+	FreeFlowTime float64 `json:"free_flow_time"` // 按限速行驶的时间 (s)
+	CurrentTime  float64 `json:"current_time"`   // 按当前车速行驶的时间 (s)
+	CurrentSpeed float64 `json:"current_speed"`  // 当前车速 (km/h)，无GPS数据时取限速
+}
+
+// Route 路径规划结果
+type Route struct {
+	From         RoutePoint     `json:"from"`
+	To           RoutePoint     `json:"to"`
+	Weight       string         `json:"weight"`
+	Segments     []RouteSegment `json:"segments"`
+	Geometry     [][]float64    `json:"geometry"`       // [lng, lat] 坐标序列
+	Length       float64        `json:"length"`         // 总长度 (m)
+	FreeFlowTime float64        `json:"free_flow_time"` // 自由流行程时间 (s)
+	CurrentTime  float64        `json:"current_time"`   // 当前行程时间 (s)
+}
+
+// RouteService 路径规划服务
+type RouteService struct {
+	roadRepo   *repositories.RoadRepository
+	congestion *algorithms.CongestionCalculator
+}
+
+// NewRouteService 创建路径规划服务
+func NewRouteService() *RouteService {
+	return &RouteService{
+		roadRepo:   repositories.NewRoadRepository(),
+		congestion: algorithms.NewCongestionCalculator(),
+	}
+}
+
+// FindRoute 在数据库路网上规划两点间的路径，坐标投影到最近的路段
+func (s *RouteService) FindRoute(fromLng, fromLat, toLng, toLat float64, weight string) (*Route, error) {
+	roads, err := s.roadRepo.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("load road network: %w", err)
+	}
+	speeds, err := s.congestion.RecentAverageSpeeds(congestionWindow)
+	if err != nil {
+		return nil, fmt.Errorf("load current speeds: %w", err)
+	}
+	return planRoute(algorithms.NewRoadGraph(roads), speeds, fromLng, fromLat, toLng, toLat, weight)
+}
+
+// routeCosts 路段行程时间计算
+type routeCosts struct {
+	network *algorithms.RoadGraph
+	speeds  map[uint]float64 // 当前平均车速 (km/h)
+}
+
+// freeFlowTime 按限速驶完路段的时间 (s)
+func (c routeCosts) freeFlowTime(id uint) float64 {
+	return c.network.SegmentLength(id) / roadSpeedLimit(c.network, id)
+}
+
+// currentSpeed 路段当前车速 (m/s)，不超过限速
+func (c routeCosts) currentSpeed(id uint) float64 {
+	limit := roadSpeedLimit(c.network, id)
+	speed, ok := c.speeds[id]
+	if !ok {
+		return limit
+	}
+	return math.Min(math.Max(speed, minCongestedSpeed)/3.6, limit)
+}
+
+// currentTime 按当前车速驶完路段的时间 (s)
+func (c routeCosts) currentTime(id uint) float64 {
+	return c.network.SegmentLength(id) / c.currentSpeed(id)
+}
+
+// weights 获取权重对应的路段代价和A*估价
+func (c routeCosts) weights(weight string, to uint) (algorithms.EdgeWeight, algorithms.EdgeWeight, error) {
+	distanceBound := func(id uint) float64 { return c.network.DistanceBound(id, to) }
+
+	// 时间估价按路网最高限速换算，当前车速不超过限速，估价对两种时间权重均不会偏大
+	maxSpeed := 0.0
+	for _, id := range c.network.SegmentIDs() {
+		maxSpeed = math.Max(maxSpeed, roadSpeedLimit(c.network, id))
+	}
+	timeBound := func(id uint) float64 { return distanceBound(id) / maxSpeed }
+
+	switch weight {
+	case RouteWeightDistance:
+		return c.network.SegmentLength, distanceBound, nil
+	case "", RouteWeightTime:
+		return c.freeFlowTime, timeBound, nil
+	case RouteWeightCongestion:
+		return c.currentTime, timeBound, nil
+	}
+	return nil, nil, fmt.Errorf("unknown weight %s, expected distance, time or congestion", weight)
+}
+
+// planRoute 规划路径，speeds 为各路段当前平均车速 (km/h)
+func planRoute(network *algorithms.RoadGraph, speeds map[uint]float64, fromLng, fromLat, toLng, toLat float64, weight string) (*Route, error) {
+	if weight == "" {
+		weight = RouteWeightTime
+	}
+	fromID, fromOffset, fromDistance, ok := network.NearestSegment(fromLng, fromLat)
+	if !ok {
+		return nil, errors.New("road network is empty")
+	}
+	toID, toOffset, toDistance, _ := network.NearestSegment(toLng, toLat)
+
+	costs := routeCosts{network: network, speeds: speeds}
+	edgeWeight, heuristic, err := costs.weights(weight, toID)
+	if err != nil {
+		return nil, err
+	}
+
+	var path []uint
+	switch {
+	case fromID == toID && toOffset >= fromOffset:
+		path = []uint{fromID}
+	case fromID == toID:
+		// 终点在同一路段后方，需要驶出路段后绕回
+		best := math.Inf(1)
+		for _, next := range network.NextSegments(fromID) {
+			if candidate, cost := network.FindPath(next, toID, edgeWeight, heuristic); candidate != nil && cost < best {
+				path, best = append([]uint{fromID}, candidate...), cost
+			}
+		}
+	default:
+		// 起止路段必经，只计部分长度不影响路径选择
+		path, _ = network.FindPath(fromID, toID, edgeWeight, heuristic)
+	}
+	if path == nil {
+		return nil, ErrNoRoute
+	}
+
+	route := &Route{
+		From:     RoutePoint{Lng: fromLng, Lat: fromLat, RoadID: fromID, Offset: fromOffset, SnapDistance: fromDistance},
+		To:       RoutePoint{Lng: toLng, Lat: toLat, RoadID: toID, Offset: toOffset, SnapDistance: toDistance},
+		Weight:   weight,
+		Segments: make([]RouteSegment, 0, len(path)),
+	}
+
+	lng, lat := network.PositionOnSegment(fromID, fromOffset)
+	route.Geometry = append(route.Geometry, []float64{lng, lat})
+	for i, id := range path {
+		start, end := 0.0, network.SegmentLength(id)
+		if i == 0 {
+			start = fromOffset
+		}
+		if i == len(path)-1 {
+			end = toOffset
+		}
+		lng, lat := network.PositionOnSegment(id, end)
+		route.Geometry = append(route.Geometry, []float64{lng, lat})
+
+		fraction := (end - start) / network.SegmentLength(id)
+		segment := RouteSegment{
+			RoadID:       id,
+			Length:       end - start,
+			FreeFlowTime: costs.freeFlowTime(id) * fraction,
+			CurrentTime:  costs.currentTime(id) * fraction,
+			CurrentSpeed: costs.currentSpeed(id) * 3.6,
+		}
+		if road, ok := network.Segment(id); ok {
+			segment.Name = road.Name
+		}
+		route.Segments = append(route.Segments, segment)
+		route.Length += segment.Length
+		route.FreeFlowTime += segment.FreeFlowTime
+		route.CurrentTime += segment.CurrentTime
+	}
+	return route, nil
+}
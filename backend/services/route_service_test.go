@@ -0,0 +1,116 @@
+package services
+
+import (
+	"backend/algorithms"
+	"backend/models"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// squareRoads 约1公里见方的路网：A(1)B(2)C 限速60，A(3)D(4)C 限速30，路段5从C折返A
+func squareRoads() *algorithms.RoadGraph {
+	return algorithms.NewRoadGraph([]models.RoadSegment{
+		{ID: 1, StartLng: 116.00, StartLat: 39.00, EndLng: 116.01, EndLat: 39.00, MaxSpeed: 60, Lanes: 1},
+		{ID: 2, StartLng: 116.01, StartLat: 39.00, EndLng: 116.01, EndLat: 39.01, MaxSpeed: 60, Lanes: 1},
+		{ID: 3, StartLng: 116.00, StartLat: 39.00, EndLng: 116.00, EndLat: 39.01, MaxSpeed: 30, Lanes: 1},
+		{ID: 4, StartLng: 116.00, StartLat: 39.01, EndLng: 116.01, EndLat: 39.01, MaxSpeed: 30, Lanes: 1},
+		{ID: 5, StartLng: 116.01, StartLat: 39.01, EndLng: 116.00, EndLat: 39.00, MaxSpeed: 60, Lanes: 1},
+	})
+}
+
+// roadIDs 路径的路段序列
+func roadIDs(route *Route) []uint {
+	ids := make([]uint, 0, len(route.Segments))
+	for _, segment := range route.Segments {
+		ids = append(ids, segment.RoadID)
+	}
+	return ids
+}
+
+// 终点在起点所在路段后方时驶出路段绕回；按自由流时间走限速高的路径，拥堵时改走另一条
+func TestPlanRouteWeights(t *testing.T) {
+	network := squareRoads()
+	length5 := network.SegmentLength(5)
+	fromLng, fromLat := network.PositionOnSegment(5, length5-100)
+	toLng, toLat := network.PositionOnSegment(5, 100)
+
+	tests := []struct {
+		weight string
+		speeds map[uint]float64
+		want   []uint
+	}{
+		{"", nil, []uint{5, 1, 2, 5}},
+		{RouteWeightTime, map[uint]float64{1: 5}, []uint{5, 1, 2, 5}},
+		{RouteWeightCongestion, nil, []uint{5, 1, 2, 5}},
+		{RouteWeightCongestion, map[uint]float64{1: 5}, []uint{5, 3, 4, 5}},
+	}
+	for _, tt := range tests {
+		route, err := planRoute(network, tt.speeds, fromLng, fromLat, toLng, toLat, tt.weight)
+		if err != nil {
+			t.Fatalf("weight %q speeds %v: %v", tt.weight, tt.speeds, err)
+		}
+		if got := roadIDs(route); !slices.Equal(got, tt.want) {
+			t.Fatalf("weight %q speeds %v: path = %v, want %v", tt.weight, tt.speeds, got, tt.want)
+		}
+	}
+
+	if _, err := planRoute(network, nil, fromLng, fromLat, toLng, toLat, "scenic"); err == nil {
+		t.Fatal("accepted an unknown weight")
+	}
+}
+
+// 起止路段只计经过的部分，几何包含起终点投影和途经路段终点
+func TestPlanRouteLengthAndTimes(t *testing.T) {
+	network := squareRoads()
+	fromLng, fromLat := network.PositionOnSegment(1, 100)
+	toLng, toLat := network.PositionOnSegment(2, 300)
+
+	route, err := planRoute(network, map[uint]float64{2: 30}, fromLng, fromLat, toLng, toLat, RouteWeightDistance)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if got := roadIDs(route); !slices.Equal(got, []uint{1, 2}) {
+		t.Fatalf("path = %v, want [1 2]", got)
+	}
+	if want := network.SegmentLength(1) - 100 + 300; !near(route.Length, want, 1e-6) {
+		t.Fatalf("length = %.1f, want %.1f", route.Length, want)
+	}
+	if len(route.Geometry) != 3 || route.Geometry[2][0] != toLng || !near(route.Geometry[2][1], toLat, 1e-9) {
+		t.Fatalf("geometry = %v", route.Geometry)
+	}
+
+	// 路段1无车速数据按限速计，路段2当前车速为限速的一半
+	first, second := route.Segments[0], route.Segments[1]
+	if first.CurrentTime != first.FreeFlowTime || !near(first.CurrentSpeed, 60, 1e-9) {
+		t.Fatalf("road 1 without speeds: %+v", first)
+	}
+	if !near(second.CurrentTime, 2*second.FreeFlowTime, 1e-9) || !near(second.CurrentSpeed, 30, 1e-9) {
+		t.Fatalf("road 2 at half speed: %+v", second)
+	}
+	if !near(route.FreeFlowTime, first.FreeFlowTime+second.FreeFlowTime, 1e-9) {
+		t.Fatalf("free flow time = %.1f", route.FreeFlowTime)
+	}
+
+	// 同一路段前方的终点只经过该路段
+	toLng, toLat = network.PositionOnSegment(1, 400)
+	route, err = planRoute(network, nil, fromLng, fromLat, toLng, toLat, RouteWeightTime)
+	if err != nil || !slices.Equal(roadIDs(route), []uint{1}) || !near(route.Length, 300, 1e-6) {
+		t.Fatalf("same road: route = %+v, err = %v", route, err)
+	}
+}
+
+// 空路网和不可达的起终点报错
+func TestPlanRouteErrors(t *testing.T) {
+	if _, err := planRoute(algorithms.NewRoadGraph(nil), nil, 116, 39, 116.01, 39, ""); err == nil {
+		t.Fatal("planned a route on an empty network")
+	}
+
+	network := algorithms.NewRoadGraph([]models.RoadSegment{
+		{ID: 1, StartLng: 116.00, StartLat: 39.00, EndLng: 116.01, EndLat: 39.00, MaxSpeed: 60},
+		{ID: 2, StartLng: 116.02, StartLat: 39.00, EndLng: 116.03, EndLat: 39.00, MaxSpeed: 60},
+	})
+	if _, err := planRoute(network, nil, 116.001, 39, 116.025, 39, ""); !errors.Is(err, ErrNoRoute) {
+		t.Fatalf("disconnected roads: err = %v, want ErrNoRoute", err)
+	}
+}
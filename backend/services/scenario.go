@@ -0,0 +1,549 @@
+package services
+
+import (
+	"backend/algorithms"
+	"backend/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"time"
+)
+
+// Scenario 模拟场景，完整描述一次模拟的路网、车辆、需求、信号配时和模拟参数
+// 以JSON文件保存，便于纳入版本管理
+type Scenario struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Simulation  SimulationOptions `json:"simulation"`
+	Roads       []ScenarioRoad    `json:"roads,omitempty"` // 为空时使用数据库中的路网
+	Vehicles    []ScenarioVehicle `json:"vehicles"`
+	Demand      DemandConfig      `json:"demand"`
+	Signals     []SignalPlan      `json:"signals"`
+	Incidents   []Incident        `json:"incidents,omitempty"`        // 计划事件，开始和结束时刻相对模拟开始
+	Profiles    []VehicleProfile  `json:"vehicle_profiles,omitempty"` // 车型参数，覆盖同名默认车型
+	Transit     []TransitLine     `json:"transit_lines,omitempty"`    // 公交线路，首班发车时刻相对模拟开始
+	Rerouting   ReroutingConfig   `json:"rerouting"`
+	GPSEmission GPSEmissionConfig `json:"gps_emission"`
+	Weather     []WeatherChange   `json:"weather,omitempty"` // 天气计划，时刻相对模拟开始
+}
+
+// UnmarshalJSON 解析场景，车型参数与新建车型一样从车道不限开始，未指定 max_lane 时不限制车道
+func (s *Scenario) UnmarshalJSON(data []byte) error {
+	type plain Scenario
+	var decoded struct {
+		plain
+		Profiles []json.RawMessage `json:"vehicle_profiles"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*s = Scenario(decoded.plain)
+	s.Profiles = nil
+	for _, raw := range decoded.Profiles {
+		profile := NewVehicleProfile("")
+		if err := json.Unmarshal(raw, &profile); err != nil {
+			return err
+		}
+		s.Profiles = append(s.Profiles, profile)
+	}
+	return nil
+}
+
+// ScenarioRoad 场景中的路段
+type ScenarioRoad struct {
+	ID       uint    `json:"id"`
+	Name     string  `json:"name"`
+	StartLng float64 `json:"start_lng"`
+	StartLat float64 `json:"start_lat"`
+	EndLng   float64 `json:"end_lng"`
+	EndLat   float64 `json:"end_lat"`
+	MaxSpeed int     `json:"max_speed"`
+	Capacity int     `json:"capacity"`
+	Lanes    int     `json:"lanes"`
+	Length   float64 `json:"length,omitempty"` // 公里，为空时按坐标计算
+	RoadType string  `json:"road_type,omitempty"`
+}
+
+// ScenarioVehicle 场景中的车辆
+type ScenarioVehicle struct {
+	VehicleID   string  `json:"vehicle_id"`
+	VehicleType string  `json:"vehicle_type"`
+	Speed       float64 `json:"speed"`
+	RoadID      uint    `json:"road_id,omitempty"` // 为空时随机放置
+	Offset      float64 `json:"offset,omitempty"`
+	Lane        int     `json:"lane,omitempty"`
+	Route       []uint  `json:"route,omitempty"`
+	RouteIndex  int     `json:"route_index,omitempty"`
+	Informed    bool    `json:"informed,omitempty"`
+	X           float64 `json:"x,omitempty"` // 无路网时使用的坐标
+	Y           float64 `json:"y,omitempty"`
+	Direction   float64 `json:"direction,omitempty"`
+}
+
+// toModel 转换为路段模型
+func (r ScenarioRoad) toModel() models.RoadSegment {
+	return models.RoadSegment{
+		ID:       r.ID,
+		Name:     r.Name,
+		StartLng: r.StartLng,
+		StartLat: r.StartLat,
+		EndLng:   r.EndLng,
+		EndLat:   r.EndLat,
+		MaxSpeed: r.MaxSpeed,
+		Capacity: r.Capacity,
+		Lanes:    r.Lanes,
+		Length:   r.Length,
+		RoadType: r.RoadType,
+	}
+}
+
+// scenarioRoadFromModel 由路段模型生成场景路段
+func scenarioRoadFromModel(road models.RoadSegment) ScenarioRoad {
+	return ScenarioRoad{
+		ID:       road.ID,
+		Name:     road.Name,
+		StartLng: road.StartLng,
+		StartLat: road.StartLat,
+		EndLng:   road.EndLng,
+		EndLat:   road.EndLat,
+		MaxSpeed: road.MaxSpeed,
+		Capacity: road.Capacity,
+		Lanes:    road.Lanes,
+		Length:   road.Length,
+		RoadType: road.RoadType,
+	}
+}
+
+// ReadScenarioFile 读取场景文件
+func ReadScenarioFile(path string) (Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Scenario{}, err
+	}
+
+	var scenario Scenario
+	if err := json.Unmarshal(data, &scenario); err != nil {
+		return Scenario{}, fmt.Errorf("parse scenario %s: %w", path, err)
+	}
+	return scenario, nil
+}
+
+// WriteScenarioFile 写入场景文件，缩进输出以便比较差异
+func WriteScenarioFile(path string, scenario Scenario) error {
+	data, err := json.MarshalIndent(scenario, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// buildScenarioNetwork 根据场景路段构建路网
+func buildScenarioNetwork(roads []ScenarioRoad) (*algorithms.RoadGraph, error) {
+	segments := make([]models.RoadSegment, 0, len(roads))
+	seen := make(map[uint]bool)
+	for _, road := range roads {
+		if road.ID == 0 {
+			return nil, errors.New("road id is required")
+		}
+		if seen[road.ID] {
+			return nil, fmt.Errorf("duplicate road %d", road.ID)
+		}
+		seen[road.ID] = true
+		if road.Lanes < 0 || road.MaxSpeed < 0 || road.Capacity < 0 {
+			return nil, fmt.Errorf("road %d: lanes, max_speed and capacity cannot be negative", road.ID)
+		}
+		segments = append(segments, road.toModel())
+	}
+	return algorithms.NewRoadGraph(segments), nil
+}
+
+// validateScenarioVehicles 校验场景车辆
+func validateScenarioVehicles(vehicles []ScenarioVehicle, network *algorithms.RoadGraph, profiles map[string]VehicleProfile) error {
+	seen := make(map[string]bool)
+	for _, vehicle := range vehicles {
+		if vehicle.VehicleID == "" {
+			continue
+		}
+		if seen[vehicle.VehicleID] {
+			return fmt.Errorf("duplicate vehicle %s", vehicle.VehicleID)
+		}
+		seen[vehicle.VehicleID] = true
+	}
+
+	for _, vehicle := range vehicles {
+		if err := checkVehicleType(profiles, vehicle.VehicleType); err != nil {
+			return fmt.Errorf("vehicle %s: %w", vehicle.VehicleID, err)
+		}
+		if vehicle.RoadID != 0 {
+			if _, ok := network.Segment(vehicle.RoadID); !ok {
+				return fmt.Errorf("vehicle %s: road %d not found", vehicle.VehicleID, vehicle.RoadID)
+			}
+		}
+		for _, roadID := range vehicle.Route {
+			if _, ok := network.Segment(roadID); !ok {
+				return fmt.Errorf("vehicle %s: route road %d not found", vehicle.VehicleID, roadID)
+			}
+		}
+		if len(vehicle.Route) > 0 && (vehicle.RouteIndex < 0 || vehicle.RouteIndex >= len(vehicle.Route)) {
+			return fmt.Errorf("vehicle %s: route_index out of range", vehicle.VehicleID)
+		}
+	}
+	return nil
+}
+
+// LoadScenario 加载场景，替换当前的路网、车辆、需求、信号配时和时钟
+// 模拟运行中不能加载；场景不含路段时使用数据库中的路网
+func (s *TrafficService) LoadScenario(scenario Scenario) error {
+	return s.loadScenario(scenario, nil)
+}
+
+// loadScenario 加载场景，snapshot 非空时在同一次加锁内用快照覆盖运行状态
+func (s *TrafficService) loadScenario(scenario Scenario, snapshot *SimulationSnapshot) error {
+	var network *algorithms.RoadGraph
+	if len(scenario.Roads) > 0 {
+		var err error
+		if network, err = buildScenarioNetwork(scenario.Roads); err != nil {
+			return err
+		}
+	} else if s.roadRepo != nil {
+		roads, err := s.roadRepo.GetAll()
+		if err != nil {
+			return fmt.Errorf("load road network: %w", err)
+		}
+		network = algorithms.NewRoadGraph(roads)
+	} else {
+		network = algorithms.NewRoadGraph(nil)
+	}
+
+	// 等待正在执行的步长完成，避免其后半段写入新加载的状态
+	s.stepMu.Lock()
+	defer s.stepMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.simulating {
+		return errors.New("stop the simulation before loading a scenario")
+	}
+
+	// 先校验全部内容，任何一项失败都不改变当前状态
+	clock := NewSimulationClock(time.Now())
+	if err := clock.Apply(scenario.Simulation.ClockOptions); err != nil {
+		return err
+	}
+
+	profiles := defaultVehicleProfiles()
+	for _, profile := range scenario.Profiles {
+		if err := validateVehicleProfile(&profile); err != nil {
+			return fmt.Errorf("vehicle profile %s: %w", profile.Type, err)
+		}
+		profiles[profile.Type] = profile
+	}
+
+	demand := scenario.Demand
+	if err := validateDemand(&demand, network); err != nil {
+		return err
+	}
+	if err := checkVehicleMix(&demand, profiles); err != nil {
+		return err
+	}
+
+	signals := NewSignalManager()
+	for _, plan := range scenario.Signals {
+		if err := validateSignalPlan(&plan, network); err != nil {
+			return err
+		}
+		if _, err := signals.Add(plan); err != nil {
+			return err
+		}
+	}
+
+	if err := validateScenarioVehicles(scenario.Vehicles, network, profiles); err != nil {
+		return err
+	}
+
+	rerouting := scenario.Rerouting
+	if err := validateRerouting(&rerouting); err != nil {
+		return err
+	}
+	weather := append([]WeatherChange(nil), scenario.Weather...)
+	if err := validateWeatherSchedule(weather); err != nil {
+		return err
+	}
+	gpsEmission := scenario.GPSEmission
+	if err := validateGPSEmission(&gpsEmission); err != nil {
+		return fmt.Errorf("gps_emission: %w", err)
+	}
+
+	incidents := make([]Incident, 0, len(scenario.Incidents))
+	for i, incident := range scenario.Incidents {
+		if err := validateIncident(&incident, network); err != nil {
+			return fmt.Errorf("incident %d: %w", i+1, err)
+		}
+		incident.ID = fmt.Sprintf("I%03d", i+1)
+		incident.Active = false
+		incident.AlertID = 0
+		incidents = append(incidents, incident)
+	}
+
+	transit := make([]TransitLine, 0, len(scenario.Transit))
+	lineIDs := make(map[string]bool)
+	for i, line := range scenario.Transit {
+		if line.ID == "" {
+			line.ID = fmt.Sprintf("L%03d", i+1)
+		}
+		if lineIDs[line.ID] {
+			return fmt.Errorf("duplicate transit line %s", line.ID)
+		}
+		lineIDs[line.ID] = true
+		if err := validateTransitLine(&line, network, profiles); err != nil {
+			return fmt.Errorf("transit line %s: %w", line.ID, err)
+		}
+		line.Dispatched = 0
+		transit = append(transit, line)
+	}
+
+	var rngSource, gpsRngSource *rand.PCG
+	if snapshot != nil {
+		var err error
+		if rngSource, gpsRngSource, err = snapshot.validate(network, signals, profiles); err != nil {
+			return err
+		}
+	}
+
+	s.scenarioName = scenario.Name
+	s.scenarioDescription = scenario.Description
+	s.network = network
+	s.scenarioNetwork = len(scenario.Roads) > 0
+	s.clock = clock
+	s.signals = signals
+	s.profiles = profiles
+	s.demand = demand
+	s.demandStats = DemandStats{}
+	s.flowRecords = nil
+	s.trips = nil
+	s.tripStats = TripStats{}
+	s.emissionTotals = EmissionTotals{}
+	s.emissionRecords = nil
+	s.trajectories = nil
+	s.departures = nil
+	s.alerts = make([]models.TrafficAlert, 0)
+	s.nextAlertID = 0
+	s.alertCounts = nil
+	s.incidents = incidents
+	s.nextIncidentID = len(incidents)
+	s.transitLines = transit
+	s.rerouting = rerouting
+	s.reroutingStats = ReroutingStats{}
+	s.weatherSchedule = weather
+	s.gpsEmission = gpsEmission
+	s.gpsStats = GPSEmissionStats{}
+	s.gpsSamples = nil
+	s.gpsPending = nil
+	s.transitTrips = make(map[string]*TransitTrip)
+	s.stopEvents = nil
+	s.replay = nil
+	if scenario.Simulation.Seed != nil {
+		s.reseed(*scenario.Simulation.Seed)
+	} else {
+		s.reseed(time.Now().UnixNano())
+	}
+	s.keepRandomState = scenario.Simulation.Seed != nil
+
+	s.vehicles = make([]models.Vehicle, 0, len(scenario.Vehicles))
+	s.nextVehicleID = 0
+	for _, item := range scenario.Vehicles {
+		s.nextVehicleID++
+		vehicle := models.Vehicle{
+			ID:          s.nextVehicleID,
+			VehicleID:   item.VehicleID,
+			X:           item.X,
+			Y:           item.Y,
+			Speed:       item.Speed,
+			Direction:   item.Direction,
+			VehicleType: item.VehicleType,
+			Status:      "normal",
+			RoadID:      item.RoadID,
+			Offset:      item.Offset,
+			Lane:        item.Lane,
+			Route:       item.Route,
+			RouteIndex:  item.RouteIndex,
+			Informed:    item.Informed,
+		}
+		if vehicle.VehicleID == "" {
+			vehicle.VehicleID = fmt.Sprintf("V%03d", vehicle.ID)
+		}
+		if vehicle.VehicleType == "" {
+			vehicle.VehicleType = defaultVehicleType
+		}
+		if len(vehicle.Route) > 0 {
+			vehicle.RoadID = vehicle.Route[vehicle.RouteIndex]
+		}
+		if vehicle.RoadID != 0 {
+			vehicle.Offset = min(max(vehicle.Offset, 0), s.network.SegmentLength(vehicle.RoadID))
+		}
+		s.placeOnNetwork(&vehicle)
+		s.departVehicle(&vehicle)
+		s.vehicles = append(s.vehicles, vehicle)
+	}
+
+	if snapshot != nil {
+		s.applySnapshot(snapshot, rngSource, gpsRngSource)
+	}
+	s.updateWeather()
+	return nil
+}
+
+// ExportScenario 将当前状态导出为场景，模拟参数取当前时钟和种子
+// 场景中的相对时刻以当前模拟时刻为零点重新计算，加载后从导出时的状态继续
+func (s *TrafficService) ExportScenario() Scenario {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	scenario := s.exportScenario()
+	s.rebaseScenario(&scenario)
+	return scenario
+}
+
+// rebaseScenario 将场景中相对模拟开始的时刻平移到当前模拟时刻，丢弃已结束的事件、天气变化和需求时段，调用方需持有锁
+func (s *TrafficService) rebaseScenario(scenario *Scenario) {
+	elapsed := s.clock.ElapsedSeconds()
+
+	incidents := scenario.Incidents[:0]
+	for _, incident := range scenario.Incidents {
+		if incident.End > 0 {
+			if incident.End <= elapsed {
+				continue
+			}
+			incident.End -= elapsed
+		}
+		incident.Start = math.Max(incident.Start-elapsed, 0)
+		incidents = append(incidents, incident)
+	}
+	scenario.Incidents = incidents
+
+	// 当前天气作为零时刻的变化，首次变化之前默认为晴天
+	var weather []WeatherChange
+	if s.weather != models.WeatherClear {
+		weather = append(weather, WeatherChange{At: 0, Condition: s.weather})
+	}
+	for _, change := range scenario.Weather {
+		if change.At > elapsed {
+			weather = append(weather, WeatherChange{At: change.At - elapsed, Condition: change.Condition})
+		}
+	}
+	scenario.Weather = weather
+
+	matrix := make([]ODDemand, 0, len(scenario.Demand.Matrix))
+	for _, od := range scenario.Demand.Matrix {
+		var periods []DemandPeriod
+		for _, period := range od.Periods {
+			if period.End <= elapsed {
+				continue
+			}
+			periods = append(periods, DemandPeriod{
+				Start: math.Max(period.Start-elapsed, 0),
+				End:   period.End - elapsed,
+				Rate:  period.Rate,
+			})
+		}
+		od.Periods = periods
+		matrix = append(matrix, od)
+	}
+	scenario.Demand.Matrix = matrix
+
+	// 公交线路从下一个未发出的班次开始，末班已发出的线路不再导出
+	transit := scenario.Transit[:0]
+	for _, line := range scenario.Transit {
+		next := line.departureTime(line.Dispatched)
+		if line.LastDeparture > 0 {
+			if next > line.LastDeparture {
+				continue
+			}
+			line.LastDeparture -= elapsed
+		}
+		line.FirstDeparture = math.Max(next-elapsed, 0)
+		line.Dispatched = 0
+		transit = append(transit, line)
+	}
+	scenario.Transit = transit
+
+	// 固定配时方案的相位差计入已运行时间，加载后相位与导出时一致
+	for i, runtime := range s.signals.Runtime() {
+		plan := &scenario.Signals[i]
+		if cycle := plan.CycleLength(); plan.Type == SignalFixedTime && cycle > 0 {
+			plan.Offset = math.Mod(plan.Offset+runtime.Clock, cycle)
+		}
+	}
+}
+
+// exportScenario 导出场景，调用方需持有锁
+func (s *TrafficService) exportScenario() Scenario {
+	seed := s.seed
+	timeStep := s.clock.StepSeconds()
+	speed := s.clock.Speed
+	startTime := s.clock.Now()
+
+	scenario := Scenario{
+		Name:        s.scenarioName,
+		Description: s.scenarioDescription,
+		Simulation: SimulationOptions{
+			Seed: &seed,
+			ClockOptions: ClockOptions{
+				TimeStep:  &timeStep,
+				Speed:     &speed,
+				StartTime: &startTime,
+			},
+		},
+		Vehicles:    make([]ScenarioVehicle, 0, len(s.vehicles)),
+		Demand:      s.demand,
+		Signals:     s.signals.Plans(),
+		Profiles:    sortedProfiles(s.profiles),
+		Rerouting:   s.rerouting,
+		GPSEmission: s.gpsEmission,
+		Weather:     append([]WeatherChange(nil), s.weatherSchedule...),
+	}
+
+	// 公交线路按时刻表导出，已发班次数由 ExportScenario 平移首班时刻后清零
+	for _, line := range s.transitLines {
+		scenario.Transit = append(scenario.Transit, line)
+	}
+
+	// 事件按配置导出，加载后按开始时刻重新生效，ExportScenario 平移开始和结束时刻
+	for _, incident := range s.incidents {
+		incident.Active = false
+		incident.AlertID = 0
+		scenario.Incidents = append(scenario.Incidents, incident)
+	}
+
+	for _, road := range s.network.Segments() {
+		scenario.Roads = append(scenario.Roads, scenarioRoadFromModel(road))
+	}
+
+	for _, vehicle := range s.vehicles {
+		// 公交班次由线路重新发出，不作为普通车辆导出
+		if _, ok := s.transitTrips[vehicle.VehicleID]; ok {
+			continue
+		}
+		item := ScenarioVehicle{
+			VehicleID:   vehicle.VehicleID,
+			VehicleType: vehicle.VehicleType,
+			Speed:       vehicle.Speed,
+			RoadID:      vehicle.RoadID,
+			Offset:      vehicle.Offset,
+			Lane:        vehicle.Lane,
+			Route:       vehicle.Route,
+			RouteIndex:  vehicle.RouteIndex,
+			Informed:    vehicle.Informed,
+		}
+		if vehicle.RoadID == 0 {
+			item.X, item.Y, item.Direction = vehicle.X, vehicle.Y, vehicle.Direction
+		}
+		scenario.Vehicles = append(scenario.Vehicles, item)
+	}
+
+	return scenario
+}
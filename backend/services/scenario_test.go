@@ -0,0 +1,104 @@
+package services
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// scenarioJSON 场景的序列化结果
+func scenarioJSON(t *testing.T, scenario Scenario) string {
+	t.Helper()
+	data, err := json.Marshal(scenario)
+	if err != nil {
+		t.Fatalf("marshal scenario: %v", err)
+	}
+	return string(data)
+}
+
+// 导出的场景写入文件后重新加载，再次导出的场景与原场景一致
+func TestScenarioFileRoundTrip(t *testing.T) {
+	s := newCheckpointService(t)
+	stepService(t, s, 120)
+	exported := s.ExportScenario()
+	if len(exported.Vehicles) == 0 || len(exported.Roads) != 3 {
+		t.Fatalf("exported %d vehicles and %d roads", len(exported.Vehicles), len(exported.Roads))
+	}
+
+	path := filepath.Join(t.TempDir(), "scenario.json")
+	if err := WriteScenarioFile(path, exported); err != nil {
+		t.Fatalf("write scenario: %v", err)
+	}
+	read, err := ReadScenarioFile(path)
+	if err != nil {
+		t.Fatalf("read scenario: %v", err)
+	}
+
+	loaded := NewStandaloneTrafficService()
+	if err := loaded.LoadScenario(read); err != nil {
+		t.Fatalf("load exported scenario: %v", err)
+	}
+	if got, want := scenarioJSON(t, loaded.ExportScenario()), scenarioJSON(t, exported); got != want {
+		t.Fatalf("round trip changed the scenario:\ngot  %s\nwant %s", got, want)
+	}
+	if loaded.clock.Now() != s.clock.Now() {
+		t.Fatalf("clock = %v, want %v", loaded.clock.Now(), s.clock.Now())
+	}
+}
+
+// 无法解析的场景文件报告文件路径
+func TestReadScenarioFileInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.json")
+	if err := os.WriteFile(path, []byte(`{"roads": [`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadScenarioFile(path); err == nil || !strings.Contains(err.Error(), path) {
+		t.Fatalf("err = %v, want parse error naming %s", err, path)
+	}
+}
+
+// 场景校验失败时不改变当前状态，模拟运行中不能加载场景
+func TestLoadScenarioRejectsInvalidScenario(t *testing.T) {
+	s := newCheckpointService(t)
+	stepService(t, s, 60)
+	before := scenarioJSON(t, s.ExportScenario())
+
+	tests := []struct {
+		name   string
+		modify func(*Scenario)
+		want   string
+	}{
+		{"unknown vehicle road", func(sc *Scenario) {
+			sc.Vehicles = []ScenarioVehicle{{VehicleID: "S1", RoadID: 9}}
+		}, "road 9 not found"},
+		{"duplicate vehicle", func(sc *Scenario) {
+			sc.Vehicles = []ScenarioVehicle{{VehicleID: "S1", RoadID: 1}, {VehicleID: "S1", RoadID: 2}}
+		}, "duplicate vehicle S1"},
+		{"duplicate road", func(sc *Scenario) {
+			sc.Roads = append(sc.Roads, sc.Roads[0])
+		}, "duplicate road 1"},
+		{"unknown zone", func(sc *Scenario) {
+			sc.Demand.Matrix[0].Destination = "C"
+		}, "unknown zone C"},
+	}
+	for _, tt := range tests {
+		scenario := checkpointScenario()
+		tt.modify(&scenario)
+		if err := s.LoadScenario(scenario); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+		if after := scenarioJSON(t, s.ExportScenario()); after != before {
+			t.Fatalf("%s: failed load changed the state", tt.name)
+		}
+	}
+
+	if _, err := s.StartSimulation(SimulationOptions{}); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer s.StopSimulation()
+	if err := s.LoadScenario(checkpointScenario()); err == nil {
+		t.Fatal("loaded a scenario while the simulation was running")
+	}
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultSessionID 默认会话ID，未指定会话的接口使用该会话
+const DefaultSessionID = "default"
+
+// ErrSessionNotFound 会话不存在
+var ErrSessionNotFound = errors.New("session not found")
+
+// sessionIDPattern 会话ID格式
+var sessionIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// SimulationSession 模拟会话，每个会话拥有独立的车辆、时钟、告警和参数
+type SimulationSession struct {
+	ID        string
+	Name      string
+	CreatedAt time.Time
+	Service   *TrafficService
+}
+
+// SessionInfo 会话摘要
+type SessionInfo struct {
+	ID        string                 `json:"id"`
+	Name      string                 `json:"name"`
+	CreatedAt time.Time              `json:"created_at"`
+	Status    map[string]interface{} `json:"status"`
+}
+
+// SessionOptions 创建会话参数
+type SessionOptions struct {
+	ID       string    `json:"id"` // 为空时自动生成
+	Name     string    `json:"name"`
+	Scenario *Scenario `json:"scenario"` // 为空时使用数据库路网和默认车辆
+}
+
+// SessionManager 模拟会话管理
+type SessionManager struct {
+	mu         sync.RWMutex
+	sessions   map[string]*SimulationSession
+	newService func() *TrafficService
+}
+
+// NewSessionManager 创建会话管理，defaultService 作为默认会话
+func NewSessionManager(defaultService *TrafficService) *SessionManager {
+	return &SessionManager{
+		sessions: map[string]*SimulationSession{
+			DefaultSessionID: {
+				ID:        DefaultSessionID,
+				Name:      "默认会话",
+				CreatedAt: time.Now(),
+				Service:   defaultService,
+			},
+		},
+		newService: NewTrafficService,
+	}
+}
+
+// info 生成会话摘要
+func (session *SimulationSession) info() SessionInfo {
+	return SessionInfo{
+		ID:        session.ID,
+		Name:      session.Name,
+		CreatedAt: session.CreatedAt,
+		Status:    session.Service.GetSimulationStatus(),
+	}
+}
+
+// Get 获取会话的模拟服务
+func (m *SessionManager) Get(id string) (*TrafficService, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, ok := m.sessions[id]
+	if !ok {
+		return nil, false
+	}
+	return session.Service, true
+}
+
+// Info 获取会话摘要
+func (m *SessionManager) Info(id string) (SessionInfo, bool) {
+	m.mu.RLock()
+	session, ok := m.sessions[id]
+	m.mu.RUnlock()
+
+	if !ok {
+		return SessionInfo{}, false
+	}
+	return session.info(), true
+}
+
+// List 获取全部会话，按创建时间排序
+func (m *SessionManager) List() []SessionInfo {
+	m.mu.RLock()
+	sessions := make([]*SimulationSession, 0, len(m.sessions))
+	for _, session := range m.sessions {
+		sessions = append(sessions, session)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].CreatedAt.Equal(sessions[j].CreatedAt) {
+			return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+		}
+		return sessions[i].ID < sessions[j].ID
+	})
+
+	infos := make([]SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		infos = append(infos, session.info())
+	}
+	return infos
+}
+
+// Create 创建会话，可同时加载场景
+func (m *SessionManager) Create(options SessionOptions) (SessionInfo, error) {
+	id := options.ID
+	if id == "" {
+		id = newSessionID()
+	}
+	if !sessionIDPattern.MatchString(id) {
+		return SessionInfo{}, errors.New("session id may only contain letters, digits, '_' and '-'")
+	}
+
+	m.mu.RLock()
+	_, exists := m.sessions[id]
+	m.mu.RUnlock()
+	if exists {
+		return SessionInfo{}, fmt.Errorf("session %s already exists", id)
+	}
+
+	// 创建服务会访问数据库，不在持锁期间进行
+	service := m.newService()
+	if options.Scenario != nil {
+		if err := service.LoadScenario(*options.Scenario); err != nil {
+			return SessionInfo{}, err
+		}
+	}
+
+	session := &SimulationSession{
+		ID:        id,
+		Name:      options.Name,
+		CreatedAt: time.Now(),
+		Service:   service,
+	}
+	if session.Name == "" {
+		session.Name = id
+	}
+
+	m.mu.Lock()
+	if _, exists := m.sessions[id]; exists {
+		m.mu.Unlock()
+		return SessionInfo{}, fmt.Errorf("session %s already exists", id)
+	}
+	m.sessions[id] = session
+	m.mu.Unlock()
+
+	return session.info(), nil
+}
+
+// Delete 停止并删除会话，默认会话不能删除
+func (m *SessionManager) Delete(id string) error {
+	if id == DefaultSessionID {
+		return errors.New("the default session cannot be deleted")
+	}
+
+	m.mu.Lock()
+	session, ok := m.sessions[id]
+	delete(m.sessions, id)
+	m.mu.Unlock()
+
+	if !ok {
+		return ErrSessionNotFound
+	}
+	session.Service.StopSimulation()
+	return nil
+}
+
+// SetGPSSink 设置默认会话的GPS数据接收端
+// 各会话的车辆ID相互独立，其他会话不上报，避免不同会话的同名车辆写入同一条GPS轨迹
+func (m *SessionManager) SetGPSSink(sink GPSSink) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	m.sessions[DefaultSessionID].Service.SetGPSSink(sink)
+}
+
+// newSessionID 生成随机会话ID
+func newSessionID() string {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("s%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"backend/models"
+	"errors"
+	"testing"
+)
+
+// newSessionManager 会话使用不访问数据库的模拟服务
+func newSessionManager() *SessionManager {
+	sessions := NewSessionManager(NewStandaloneTrafficService())
+	sessions.newService = NewStandaloneTrafficService
+	return sessions
+}
+
+// 各会话的车辆和模拟状态相互独立
+func TestSessionsAreIsolated(t *testing.T) {
+	sessions := newSessionManager()
+	scenario := checkpointScenario()
+	info, err := sessions.Create(SessionOptions{ID: "peak-a", Scenario: &scenario})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if info.Name != "peak-a" {
+		t.Fatalf("name = %s, want the session id", info.Name)
+	}
+
+	session, ok := sessions.Get("peak-a")
+	if !ok {
+		t.Fatal("created session not found")
+	}
+	defaultService, _ := sessions.Get(DefaultSessionID)
+	defaultVehicles := len(defaultService.GetVehicles())
+	if _, err := session.AddVehicle(models.Vehicle{VehicleID: "V1", RoadID: 1}); err != nil {
+		t.Fatalf("add vehicle: %v", err)
+	}
+	stepService(t, session, 60)
+
+	if len(defaultService.GetVehicles()) != defaultVehicles || defaultService.clock.ElapsedSeconds() != 0 {
+		t.Fatal("stepping one session changed the default session")
+	}
+	if session.clock.ElapsedSeconds() != 60 || len(session.GetVehicles()) == 0 {
+		t.Fatal("session did not advance")
+	}
+
+	infos := sessions.List()
+	if len(infos) != 2 || infos[0].ID != DefaultSessionID || infos[1].ID != "peak-a" {
+		t.Fatalf("list = %+v", infos)
+	}
+}
+
+// 会话ID须合法且不能重复，场景加载失败时不创建会话
+func TestCreateSessionRejectsInvalidOptions(t *testing.T) {
+	sessions := newSessionManager()
+	invalid := checkpointScenario()
+	invalid.Roads = append(invalid.Roads, invalid.Roads[0])
+
+	for _, options := range []SessionOptions{
+		{ID: DefaultSessionID},
+		{ID: "a/b"},
+		{ID: "broken", Scenario: &invalid},
+	} {
+		if _, err := sessions.Create(options); err == nil {
+			t.Fatalf("created session %q", options.ID)
+		}
+	}
+	if len(sessions.List()) != 1 {
+		t.Fatalf("sessions = %+v", sessions.List())
+	}
+
+	info, err := sessions.Create(SessionOptions{Name: "自动编号"})
+	if err != nil || !sessionIDPattern.MatchString(info.ID) {
+		t.Fatalf("generated id %q, err = %v", info.ID, err)
+	}
+}
+
+// 删除会话时停止其模拟，默认会话不能删除
+func TestDeleteSession(t *testing.T) {
+	sessions := newSessionManager()
+	scenario := checkpointScenario()
+	if _, err := sessions.Create(SessionOptions{ID: "peak-a", Scenario: &scenario}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	session, _ := sessions.Get("peak-a")
+	if _, err := session.StartSimulation(SimulationOptions{}); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	if err := sessions.Delete("peak-a"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if session.GetSimulationStatus()["simulating"] != false {
+		t.Fatal("deleted session is still simulating")
+	}
+	if _, ok := sessions.Get("peak-a"); ok {
+		t.Fatal("deleted session still exists")
+	}
+	if err := sessions.Delete("peak-a"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("delete twice: err = %v", err)
+	}
+	if err := sessions.Delete(DefaultSessionID); err == nil {
+		t.Fatal("deleted the default session")
+	}
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// 时间步长允许范围
+const (
+	minTimeStep = 10 * time.Millisecond
+	maxTimeStep = time.Minute
+)
+
+// maxSteps 单次单步推进的最大步数
+const maxSteps = 3600
+
+// ErrTooManySteps 单步推进的步数超过上限
+var ErrTooManySteps = fmt.Errorf("steps cannot exceed %d per request", maxSteps)
+
+// SimulationClock 模拟时钟，与墙上时间解耦
+type SimulationClock struct {
+	Start   time.Time     `json:"start"`   // 模拟起始时刻
+	Elapsed time.Duration `json:"elapsed"` // 已模拟时长
+	Step    time.Duration `json:"step"`    // 时间步长
+	Speed   float64       `json:"speed"`   // 相对墙上时间的加速倍数，0表示尽可能快
+}
+
+// NewSimulationClock 创建模拟时钟，默认步长1秒、实时运行
+func NewSimulationClock(start time.Time) *SimulationClock {
+	return &SimulationClock{
+		Start: start,
+		Step:  time.Second,
+		Speed: 1,
+	}
+}
+
+// Now 当前模拟时刻
+func (c *SimulationClock) Now() time.Time {
+	return c.Start.Add(c.Elapsed)
+}
+
+// StepSeconds 时间步长（秒）
+func (c *SimulationClock) StepSeconds() float64 {
+	return c.Step.Seconds()
+}
+
+// ElapsedSeconds 已模拟时长（秒）
+func (c *SimulationClock) ElapsedSeconds() float64 {
+	return c.Elapsed.Seconds()
+}
+
+// Advance 推进一个时间步长
+func (c *SimulationClock) Advance() {
+	c.Elapsed += c.Step
+}
+
+// WallInterval 每步对应的墙上时间间隔，尽可能快运行时为0
+func (c *SimulationClock) WallInterval() time.Duration {
+	if c.Speed <= 0 {
+		return 0
+	}
+	return time.Duration(float64(c.Step) / c.Speed)
+}
+
+// staggeredDue 判断按车辆ID错开时刻的周期任务在当前步长内是否到期
+// 避免同一步内全部车辆同时执行
+func staggeredDue(id uint, now, interval, dt float64) bool {
+	phase := math.Mod(float64(id)*7.3, interval)
+	return math.Mod(now+phase, interval) < dt
+}
+
+// ClockOptions 时钟参数，字段为空表示保持不变
+type ClockOptions struct {
+	TimeStep  *float64   `json:"time_step"`  // 时间步长 (s)
+	Speed     *float64   `json:"speed"`      // 加速倍数，0表示尽可能快
+	StartTime *time.Time `json:"start_time"` // 重新设定模拟起始时刻，已模拟时长清零
+}
+
+// SimulationOptions 启动参数
+type SimulationOptions struct {
+	Seed *int64 `json:"seed"`
+	ClockOptions
+}
+
+// Apply 校验并应用时钟参数，校验失败时时钟保持不变
+func (c *SimulationClock) Apply(options ClockOptions) error {
+	step := c.Step
+	if options.TimeStep != nil {
+		step = time.Duration(*options.TimeStep * float64(time.Second))
+		if step < minTimeStep || step > maxTimeStep {
+			return errors.New("time_step must be between 0.01 and 60 seconds")
+		}
+	}
+	if options.Speed != nil && *options.Speed < 0 {
+		return errors.New("speed cannot be negative")
+	}
+
+	c.Step = step
+	if options.Speed != nil {
+		c.Speed = *options.Speed
+	}
+	if options.StartTime != nil {
+		c.Start = *options.StartTime
+		c.Elapsed = 0
+	}
+	return nil
+}
+
+// applyClockOptions 校验并应用时钟参数
+func (s *TrafficService) applyClockOptions(options ClockOptions) error {
+	return s.clock.Apply(options)
+}
+
+// UpdateClock 修改时钟参数，运行中修改立即生效
+func (s *TrafficService) UpdateClock(options ClockOptions) (SimulationClock, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.applyClockOptions(options); err != nil {
+		return SimulationClock{}, err
+	}
+	s.notifyRunner()
+	return *s.clock, nil
+}
+
+// PauseSimulation 暂停模拟
+func (s *TrafficService) PauseSimulation() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.simulating {
+		return errors.New("simulation is not running")
+	}
+	s.paused = true
+	s.notifyRunner()
+	return nil
+}
+
+// ResumeSimulation 恢复模拟
+func (s *TrafficService) ResumeSimulation() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.simulating {
+		return errors.New("simulation is not running")
+	}
+	s.paused = false
+	s.notifyRunner()
+	return nil
+}
+
+// StepSimulation 单步推进模拟，仅在模拟停止或暂停时可用
+// 推进期间持有步长锁，期间恢复运行的模拟协程等待推进完成后继续
+func (s *TrafficService) StepSimulation(steps int) error {
+	if steps > maxSteps {
+		return ErrTooManySteps
+	}
+	if steps < 1 {
+		steps = 1
+	}
+
+	s.stepMu.Lock()
+	defer s.stepMu.Unlock()
+
+	s.mu.RLock()
+	running := s.simulating && !s.paused
+	s.mu.RUnlock()
+	if running {
+		return errors.New("pause the simulation before stepping")
+	}
+
+	for i := 0; i < steps; i++ {
+		s.advance()
+	}
+	return nil
+}
+
+// notifyRunner 通知模拟协程参数已变化
+func (s *TrafficService) notifyRunner() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// runSimulation 按时钟节奏推进模拟，直至 stop 关闭
+func (s *TrafficService) runSimulation(stop <-chan struct{}) {
+	for {
+		s.mu.RLock()
+		paused := s.paused
+		interval := s.clock.WallInterval()
+		s.mu.RUnlock()
+
+		if paused {
+			select {
+			case <-stop:
+				return
+			case <-s.wake:
+				continue
+			}
+		}
+
+		if interval > 0 {
+			timer := time.NewTimer(interval)
+			select {
+			case <-stop:
+				timer.Stop()
+				return
+			case <-s.wake:
+				timer.Stop()
+				continue
+			case <-timer.C:
+			}
+		} else {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+		}
+
+		s.step()
+	}
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+// 模拟协程等待步长锁期间模拟被暂停或停止，取得锁后不再推进
+func TestRunnerStepSkipsWhenNotRunning(t *testing.T) {
+	s := newCheckpointService(t)
+	for _, state := range []struct {
+		simulating, paused bool
+	}{{false, false}, {true, true}} {
+		s.mu.Lock()
+		s.simulating, s.paused = state.simulating, state.paused
+		s.mu.Unlock()
+		s.step()
+		if elapsed := s.clock.ElapsedSeconds(); elapsed != 0 {
+			t.Fatalf("simulating=%v paused=%v: runner stepped to %.0fs", state.simulating, state.paused, elapsed)
+		}
+	}
+
+	s.mu.Lock()
+	s.paused = false
+	s.mu.Unlock()
+	s.step()
+	if elapsed := s.clock.ElapsedSeconds(); elapsed != s.clock.StepSeconds() {
+		t.Fatalf("running: elapsed = %.0fs, want one step", elapsed)
+	}
+}
+
+// 暂停后单步推进只前进请求的步数，且不接受超过上限的步数
+func TestStepSimulationWhilePaused(t *testing.T) {
+	s := newCheckpointService(t)
+	if err := s.StepSimulation(maxSteps + 1); err != ErrTooManySteps {
+		t.Fatalf("err = %v, want ErrTooManySteps", err)
+	}
+	if _, err := s.StartSimulation(SimulationOptions{}); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer s.StopSimulation()
+	if err := s.StepSimulation(1); err == nil {
+		t.Fatal("stepping a running simulation succeeded")
+	}
+	if err := s.PauseSimulation(); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+
+	// 取得步长锁后模拟协程不会再推进
+	s.stepMu.Lock()
+	before := s.clock.ElapsedSeconds()
+	s.stepMu.Unlock()
+	stepService(t, s, 10)
+	time.Sleep(20 * time.Millisecond)
+	s.stepMu.Lock()
+	elapsed := s.clock.ElapsedSeconds()
+	s.stepMu.Unlock()
+	if want := before + 10*s.clock.StepSeconds(); elapsed != want {
+		t.Fatalf("elapsed = %.0fs, want %.0fs", elapsed, want)
+	}
+}
@@ -1,10 +1,15 @@
 package services
 
 import (
+	"backend/algorithms"
 	"backend/models"
-	"math/rand"
+	"backend/repositories"
+	"errors"
+	"math/rand/v2"
 	"sync"
 	"time"
+
+	"github.com/beego/beego/v2/core/logs"
 )
 
 // TrafficService 交通服务
@@ -12,18 +17,102 @@ type TrafficService struct {
 	vehicles   []models.Vehicle
 	alerts     []models.TrafficAlert
 	simulating bool
+	paused     bool
 	mu         sync.RWMutex
-	stopChan   chan bool
+	stepMu     sync.Mutex // 串行化模拟步长，快照和加载场景在两步之间进行
+	stopChan   chan struct{}
+	wake       chan struct{}
+	clock      *SimulationClock
+	roadRepo   *repositories.RoadRepository
+	network    *algorithms.RoadGraph
+	signals    *SignalManager
+	profiles   map[string]VehicleProfile
+
+	// 当前场景，路网来自场景文件时不再从数据库重新加载
+	scenarioName        string
+	scenarioDescription string
+	scenarioNetwork     bool
+
+	demand        DemandConfig
+	demandStats   DemandStats
+	departures    []pendingDeparture
+	nextVehicleID uint
+
+	incidents      []Incident
+	nextIncidentID int
+	nextAlertID    uint
+	alertCounts    map[string]int // 按类型累计的告警数
+
+	weather         models.WeatherCondition
+	weatherSchedule []WeatherChange
+
+	flowRecords []FlowRecord
+	trips       []TripRecord
+	tripStats   TripStats
+
+	emissionTotals  EmissionTotals
+	emissionRecords []EmissionRecord
+
+	trajectories []TrajectoryPoint
+
+	rerouting      ReroutingConfig
+	reroutingStats ReroutingStats
+
+	transitLines []TransitLine
+	transitTrips map[string]*TransitTrip // 按车辆ID索引，含尚未进入路网的班次
+	stopEvents   []StopEvent
+
+	gpsEmission GPSEmissionConfig
+	gpsStats    GPSEmissionStats
+	gpsSamples  []GPSSample
+	gpsPending  []GPSSample // 等待送达的GPS点，延迟送达时晚于后续的点
+	gpsSink     GPSSink
+
+	replay *replayState // 回放GPS轨迹时非空，车辆位置由GPS点驱动
+
+	// 每次模拟使用独立的随机源，相同种子和相同初始场景得到相同结果
+	seed      int64
+	rngSource *rand.PCG
+	rng       *rand.Rand
+	// GPS误差使用独立的随机源
+	gpsRngSource *rand.PCG
+	gpsRng       *rand.Rand
+	// 随机状态来自场景种子或检查点，下次启动时不重新生成
+	keepRandomState bool
 }
 
-// NewTrafficService 创建交通服务
+// NewTrafficService 创建交通服务，路网从数据库加载
 func NewTrafficService() *TrafficService {
+	return newTrafficService(repositories.NewRoadRepository())
+}
+
+// NewStandaloneTrafficService 创建不连接数据库的交通服务，路网只来自场景文件，用于命令行批量运行
+func NewStandaloneTrafficService() *TrafficService {
+	return newTrafficService(nil)
+}
+
+// newTrafficService 创建交通服务，roadRepo 为空时不从数据库加载路网
+func newTrafficService(roadRepo *repositories.RoadRepository) *TrafficService {
 	service := &TrafficService{
 		vehicles:   make([]models.Vehicle, 0),
 		alerts:     make([]models.TrafficAlert, 0),
 		simulating: false,
-		stopChan:   make(chan bool),
+		wake:       make(chan struct{}, 1),
+		clock:      NewSimulationClock(time.Now()),
+		roadRepo:   roadRepo,
+		network:    algorithms.NewRoadGraph(nil),
+		signals:    NewSignalManager(),
+		profiles:   defaultVehicleProfiles(),
+		weather:    models.WeatherClear,
+
+		transitTrips: make(map[string]*TransitTrip),
+
+		scenarioName: "default",
 	}
+	service.reseed(time.Now().UnixNano())
+
+	// 加载路网
+	service.loadRoadNetwork()
 
 	// 初始化一些默认车辆
 	service.initializeDefaultVehicles()
@@ -31,6 +120,63 @@ func NewTrafficService() *TrafficService {
 	return service
 }
 
+// 使用指定种子重建随机源
+func (s *TrafficService) reseed(seed int64) {
+	s.seed = seed
+	s.rngSource = rand.NewPCG(uint64(seed), uint64(seed)^0x9e3779b97f4a7c15)
+	s.rng = rand.New(s.rngSource)
+	s.gpsRngSource = gpsRandomSource(seed)
+	s.gpsRng = rand.New(s.gpsRngSource)
+}
+
+// 从数据库加载路网，加载失败时保留原路网
+func (s *TrafficService) loadRoadNetwork() {
+	s.mu.RLock()
+	fixed := s.scenarioNetwork || s.roadRepo == nil
+	s.mu.RUnlock()
+	if fixed {
+		return
+	}
+
+	roads, err := s.roadRepo.GetAll()
+	if err != nil {
+		logs.Warn("加载路网失败，车辆将在无路网模式下运行: ", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.network = algorithms.NewRoadGraph(roads)
+	for i := range s.vehicles {
+		s.placeOnNetwork(&s.vehicles[i])
+	}
+}
+
+// 将车辆放置到路网上，已在有效路段上的车辆保持不变
+func (s *TrafficService) placeOnNetwork(vehicle *models.Vehicle) {
+	if s.network.IsEmpty() {
+		vehicle.RoadID = 0
+		return
+	}
+
+	if _, ok := s.network.Segment(vehicle.RoadID); !ok {
+		ids := s.network.SegmentIDs()
+		vehicle.RoadID = ids[s.rng.IntN(len(ids))]
+		vehicle.Offset = s.rng.Float64() * s.network.SegmentLength(vehicle.RoadID)
+		s.assignLane(vehicle)
+	}
+	s.clampLane(vehicle)
+
+	s.syncPosition(vehicle)
+}
+
+// 根据路段位置更新车辆坐标和方向
+func (s *TrafficService) syncPosition(vehicle *models.Vehicle) {
+	vehicle.X, vehicle.Y = s.network.PositionOnSegment(vehicle.RoadID, vehicle.Offset)
+	vehicle.Direction = s.network.SegmentHeading(vehicle.RoadID)
+}
+
 // 初始化默认车辆
 func (s *TrafficService) initializeDefaultVehicles() {
 	s.mu.Lock()
@@ -46,7 +192,7 @@ func (s *TrafficService) initializeDefaultVehicles() {
 			Direction:   0.0,
 			VehicleType: "car",
 			Status:      "normal",
-			CreatedAt:   time.Now(),
+			CreatedAt:   s.clock.Now(),
 		},
 		{
 			ID:          2,
@@ -57,7 +203,7 @@ func (s *TrafficService) initializeDefaultVehicles() {
 			Direction:   90.0,
 			VehicleType: "truck",
 			Status:      "overspeed",
-			CreatedAt:   time.Now(),
+			CreatedAt:   s.clock.Now(),
 		},
 		{
 			ID:          3,
@@ -68,11 +214,16 @@ func (s *TrafficService) initializeDefaultVehicles() {
 			Direction:   180.0,
 			VehicleType: "bus",
 			Status:      "normal",
-			CreatedAt:   time.Now(),
+			CreatedAt:   s.clock.Now(),
 		},
 	}
 
 	s.vehicles = vehicles
+	s.nextVehicleID = uint(len(vehicles))
+	for i := range s.vehicles {
+		s.placeOnNetwork(&s.vehicles[i])
+		s.departVehicle(&s.vehicles[i])
+	}
 }
 
 // TrafficSummary 交通摘要
@@ -108,10 +259,12 @@ type CongestionData struct {
 // VehicleFlow 车流数据
 type VehicleFlow struct {
 	TotalVehicles    int     `json:"total_vehicles"`
-	IncomingVehicles int     `json:"incoming_vehicles"`
-	OutgoingVehicles int     `json:"outgoing_vehicles"`
-	FlowRate         float64 `json:"flow_rate"`
-	PeakHour         string  `json:"peak_hour"`
+	IncomingVehicles int     `json:"incoming_vehicles"` // 最近一小时进入路网的车辆数
+	OutgoingVehicles int     `json:"outgoing_vehicles"` // 最近一小时离开路网的车辆数
+	FlowRate         float64 `json:"flow_rate"`         // 离开与进入车辆数之比
+	PeakHour         string  `json:"peak_hour"`         // 按进入路网车辆数计算的高峰小时 "HH:MM-HH:MM"，无数据时为空
+	PeakHourVolume   int     `json:"peak_hour_volume"`
+	PeakHourFactor   float64 `json:"peak_hour_factor"`
 }
 
 // GetRealTimeSummary 获取实时摘要
@@ -124,6 +277,10 @@ func (s *TrafficService) GetRealTimeSummary() TrafficSummary {
 	for _, v := range s.vehicles {
 		totalSpeed += v.Speed
 	}
+	averageSpeed := 0.0
+	if totalVehicles > 0 {
+		averageSpeed = totalSpeed / float64(totalVehicles)
+	}
 
 	activeAlerts := 0
 	for _, a := range s.alerts {
@@ -134,7 +291,7 @@ func (s *TrafficService) GetRealTimeSummary() TrafficSummary {
 
 	return TrafficSummary{
 		TotalVehicles:   totalVehicles,
-		AverageSpeed:    totalSpeed / float64(totalVehicles),
+		AverageSpeed:    averageSpeed,
 		CongestionLevel: 1,
 		ActiveAlerts:    activeAlerts,
 		LastUpdate:      time.Now().Format("2006-01-02 15:04:05"),
@@ -152,10 +309,14 @@ func (s *TrafficService) GetTrafficStats() TrafficStats {
 
 	for _, v := range s.vehicles {
 		totalSpeed += v.Speed
-		if v.Speed > 80 {
+		if v.Speed > s.profileFor(v.VehicleType).AlertSpeed {
 			overspeedCount++
 		}
 	}
+	averageSpeed := 0.0
+	if totalVehicles > 0 {
+		averageSpeed = totalSpeed / float64(totalVehicles)
+	}
 
 	activeAlerts := 0
 	for _, a := range s.alerts {
@@ -164,14 +325,21 @@ func (s *TrafficService) GetTrafficStats() TrafficStats {
 		}
 	}
 
+	accidentCount := 0
+	for _, incident := range s.incidents {
+		if incident.Active && incident.Type == "accident" {
+			accidentCount++
+		}
+	}
+
 	return TrafficStats{
 		TotalRoads:      25,
 		TotalVehicles:   totalVehicles,
-		AverageSpeed:    totalSpeed / float64(totalVehicles),
+		AverageSpeed:    averageSpeed,
 		CongestionLevel: 1,
 		ActiveAlerts:    activeAlerts,
 		OverspeedCount:  overspeedCount,
-		AccidentCount:   1,
+		AccidentCount:   accidentCount,
 	}
 }
 
@@ -208,15 +376,30 @@ func (s *TrafficService) GetCongestionData() []CongestionData {
 	}
 }
 
-// GetVehicleFlow 获取车流数据
+// GetVehicleFlow 获取车流数据，按模拟中记录的15分钟流量统计
 func (s *TrafficService) GetVehicleFlow() VehicleFlow {
-	return VehicleFlow{
-		TotalVehicles:    len(s.vehicles),
-		IncomingVehicles: 45,
-		OutgoingVehicles: 38,
-		FlowRate:         0.85,
-		PeakHour:         "17:00-18:00",
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	flow := VehicleFlow{TotalVehicles: len(s.vehicles)}
+	since := s.clock.Now().Add(-time.Hour)
+	intervals := make([]algorithms.FlowInterval, len(s.flowRecords))
+	for i, record := range s.flowRecords {
+		if !record.Start.Before(since) {
+			flow.IncomingVehicles += record.Incoming
+			flow.OutgoingVehicles += record.Outgoing
+		}
+		intervals[i] = algorithms.FlowInterval{Start: record.Start, Count: record.Incoming}
+	}
+	if flow.IncomingVehicles > 0 {
+		flow.FlowRate = float64(flow.OutgoingVehicles) / float64(flow.IncomingVehicles)
+	}
+	if peak, ok := algorithms.FindPeakHour(intervals); ok {
+		flow.PeakHour = peak.Label()
+		flow.PeakHourVolume = peak.Volume
+		flow.PeakHourFactor = peak.Factor
 	}
+	return flow
 }
 
 // GetVehicles 获取车辆列表
@@ -226,19 +409,31 @@ func (s *TrafficService) GetVehicles() []models.Vehicle {
 	return s.vehicles
 }
 
-// AddVehicle 添加车辆
-func (s *TrafficService) AddVehicle(vehicle models.Vehicle) models.Vehicle {
+// AddVehicle 添加车辆，车型必须已定义
+func (s *TrafficService) AddVehicle(vehicle models.Vehicle) (models.Vehicle, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	vehicle.ID = uint(len(s.vehicles) + 1)
-	vehicle.CreatedAt = time.Now()
+	if s.replay != nil {
+		return models.Vehicle{}, errors.New("vehicles cannot be added during a replay")
+	}
+	if err := checkVehicleType(s.profiles, vehicle.VehicleType); err != nil {
+		return models.Vehicle{}, err
+	}
+	if vehicle.VehicleType == "" {
+		vehicle.VehicleType = defaultVehicleType
+	}
+
+	s.nextVehicleID++
+	vehicle.ID = s.nextVehicleID
+	s.placeOnNetwork(&vehicle)
+	s.departVehicle(&vehicle)
 	s.vehicles = append(s.vehicles, vehicle)
 
-	return vehicle
+	return vehicle, nil
 }
 
-// RemoveVehicle 移除车辆
+// RemoveVehicle 移除车辆，未完成的行程不记录
 func (s *TrafficService) RemoveVehicle(vehicleID string) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -246,23 +441,42 @@ func (s *TrafficService) RemoveVehicle(vehicleID string) bool {
 	for i, v := range s.vehicles {
 		if v.VehicleID == vehicleID {
 			s.vehicles = append(s.vehicles[:i], s.vehicles[i+1:]...)
+			s.finishTransitTrip(vehicleID)
 			return true
 		}
 	}
 	return false
 }
 
-// StartSimulation 开始模拟
-func (s *TrafficService) StartSimulation() {
+// StartSimulation 开始模拟，返回本次模拟使用的种子
+// 未指定种子时沿用场景或检查点给出的随机状态，否则随机生成
+func (s *TrafficService) StartSimulation(options SimulationOptions) (int64, error) {
+	// 重新加载路网，使通过接口新增的路段生效
+	s.loadRoadNetwork()
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if s.simulating {
-		return
+		return s.seed, nil
 	}
 
+	if err := s.applyClockOptions(options.ClockOptions); err != nil {
+		return 0, err
+	}
+
+	if options.Seed != nil {
+		s.reseed(*options.Seed)
+	} else if !s.keepRandomState {
+		s.reseed(time.Now().UnixNano())
+	}
+	s.keepRandomState = false
+
 	s.simulating = true
-	go s.runSimulation()
+	s.paused = false
+	s.stopChan = make(chan struct{})
+	go s.runSimulation(s.stopChan)
+	return s.seed, nil
 }
 
 // StopSimulation 停止模拟
@@ -275,7 +489,8 @@ func (s *TrafficService) StopSimulation() {
 	}
 
 	s.simulating = false
-	s.stopChan <- true
+	s.paused = false
+	close(s.stopChan)
 }
 
 // GetSimulationStatus 获取模拟状态
@@ -283,28 +498,79 @@ func (s *TrafficService) GetSimulationStatus() map[string]interface{} {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	mode := "simulation"
+	var replay *ReplayStatus
+	if s.replay != nil {
+		mode = "replay"
+		status := s.replayStatus()
+		replay = &status
+	}
+
 	return map[string]interface{}{
+		"mode":          mode,
+		"replay":        replay,
 		"simulating":    s.simulating,
+		"paused":        s.paused,
+		"seed":          s.seed,
+		"sim_time":      s.clock.Now().Format("2006-01-02 15:04:05"),
+		"elapsed":       s.clock.ElapsedSeconds(),
+		"time_step":     s.clock.StepSeconds(),
+		"speed":         s.clock.Speed,
 		"vehicle_count": len(s.vehicles),
 		"alert_count":   len(s.alerts),
+		"incidents":     len(s.incidents),
+		"transit_trips": len(s.transitTrips),
+		"demand":        s.demandStats,
+		"reroutes":      s.reroutingStats.Reroutes,
+		"weather":       s.weather,
+		"gps_emitted":   s.gpsStats.Emitted,
+		"trips":         s.tripStats.Completed,
+		"emissions":     s.emissionTotals.Total,
 		"last_update":   time.Now().Format("2006-01-02 15:04:05"),
 	}
 }
 
-// 运行模拟
-func (s *TrafficService) runSimulation() {
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
+// newAlertID 生成告警ID
+func (s *TrafficService) newAlertID() uint {
+	s.nextAlertID++
+	return s.nextAlertID
+}
 
-	for {
-		select {
-		case <-ticker.C:
-			s.updateVehicles()
-			s.generateAlerts()
-		case <-s.stopChan:
-			return
-		}
+// addAlert 添加告警并按类型计数，计数不受告警列表长度限制
+func (s *TrafficService) addAlert(alert models.TrafficAlert) {
+	if s.alertCounts == nil {
+		s.alertCounts = make(map[string]int)
+	}
+	s.alertCounts[alert.AlertType]++
+	s.alerts = append(s.alerts, alert)
+}
+
+// step 模拟协程推进一个模拟步长
+// 取得步长锁后重新检查运行状态，等待步长锁期间模拟被暂停或停止时不再推进
+func (s *TrafficService) step() {
+	s.stepMu.Lock()
+	defer s.stepMu.Unlock()
+
+	s.mu.RLock()
+	running := s.simulating && !s.paused
+	s.mu.RUnlock()
+	if !running {
+		return
 	}
+	s.advance()
+}
+
+// advance 推进一个模拟步长，回放时按GPS点更新车辆，调用方需持有步长锁
+func (s *TrafficService) advance() {
+	if s.replaying() {
+		s.updateReplay()
+		s.generateAlerts()
+		return
+	}
+
+	s.updateVehicles()
+	s.generateAlerts()
+	s.emitGPS()
 }
 
 // 更新车辆状态
@@ -312,55 +578,132 @@ func (s *TrafficService) updateVehicles() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	dt := s.clock.StepSeconds()
+
+	// 更新天气，激活和结束交通事件，再按公交时刻表和OD需求发车
+	s.updateWeather()
+	s.updateIncidents()
+	s.dispatchTransit()
+	s.generateDemand(dt)
+
+	// 先推进信号灯、换道和公交停站，再基于同一时刻的状态计算跟驰加速度并统一更新
+	s.signals.Step(dt, s.detectVehicle)
+	s.changeLanes()
+	s.updateTransit(dt)
+	s.updateRerouting(dt)
+	accelerations := s.computeAccelerations()
+
+	active := s.vehicles[:0]
+	for i := range s.vehicles {
+		vehicle := s.vehicles[i]
+		if vehicle.RoadID != 0 {
+			distance := s.applyAcceleration(&vehicle, accelerations[i], dt)
+			s.accumulateEmissions(&vehicle, dt)
+			vehicle.Distance += distance
+			if finished := s.moveAlongRoad(&vehicle, distance); finished {
+				// 驶出终点路段后的超出部分不计入行程
+				vehicle.Distance -= vehicle.Offset
+				s.completeTrip(&vehicle)
+				continue
+			}
+		} else {
+			s.changeSpeedRandomly(&vehicle)
+			s.accumulateEmissions(&vehicle, dt)
+			if left := s.moveRandomly(&vehicle); left {
+				s.completeTrip(&vehicle)
+				continue
+			}
+		}
+
+		vehicle.Status = s.vehicleStatus(&vehicle)
+		active = append(active, vehicle)
+	}
+	s.vehicles = active
+	s.clock.Advance()
+
+	now := s.clock.Now()
 	for i := range s.vehicles {
-		// 随机移动车辆
-		speedFactor := s.vehicles[i].Speed / 100.0
-		moveDistance := speedFactor * 2.0
+		s.vehicles[i].UpdatedAt = now
+	}
+	s.recordTrajectories()
+}
+
+// vehicleStatus 按车速判断车辆状态，超速阈值取车型参数
+func (s *TrafficService) vehicleStatus(vehicle *models.Vehicle) string {
+	if vehicle.Speed > s.profileFor(vehicle.VehicleType).AlertSpeed {
+		return "overspeed"
+	}
+	if vehicle.Speed < 20 {
+		return "slow"
+	}
+	return "normal"
+}
+
+// 沿路网行驶指定距离（米），到达路段终点后驶入相连路段
+// 有行驶路径的车辆按路径行驶，驶出路径终点路段时返回true；无路径的车辆驶入断头路时返回true
+func (s *TrafficService) moveAlongRoad(vehicle *models.Vehicle, distance float64) bool {
+	if _, ok := s.network.Segment(vehicle.RoadID); !ok {
+		s.placeOnNetwork(vehicle)
+		return false
+	}
 
-		//radians := s.vehicles[i].Direction * 3.14159 / 180.0
-		s.vehicles[i].X += float64(moveDistance) * float64(rand.Float64()-0.5)
-		s.vehicles[i].Y += float64(moveDistance) * float64(rand.Float64()-0.5)
+	vehicle.Offset += distance
+	for vehicle.Offset >= s.network.SegmentLength(vehicle.RoadID) {
+		vehicle.Offset -= s.network.SegmentLength(vehicle.RoadID)
 
-		// 边界检查
-		if s.vehicles[i].X < 0 {
-			s.vehicles[i].X = 100
+		if len(vehicle.Route) > 0 {
+			if vehicle.RouteIndex+1 >= len(vehicle.Route) {
+				return true
+			}
+			vehicle.RouteIndex++
+			vehicle.RoadID = vehicle.Route[vehicle.RouteIndex]
+			s.clampLane(vehicle)
+			continue
 		}
-		if s.vehicles[i].X > 100 {
-			s.vehicles[i].X = 0
+
+		next := s.network.NextSegments(vehicle.RoadID)
+		if len(next) == 0 {
+			return true
 		}
-		if s.vehicles[i].Y < 0 {
-			s.vehicles[i].Y = 100
+		vehicle.RoadID = next[s.rng.IntN(len(next))]
+		s.clampLane(vehicle)
+	}
+
+	s.syncPosition(vehicle)
+	return false
+}
+
+// 无路网时随机改变速度
+func (s *TrafficService) changeSpeedRandomly(vehicle *models.Vehicle) {
+	if s.rng.Float64() < 0.2 {
+		speedChange := (s.rng.Float64() - 0.5) * 20
+		vehicle.Speed = vehicle.Speed + speedChange
+		if vehicle.Speed < 10 {
+			vehicle.Speed = 10
 		}
-		if s.vehicles[i].Y > 100 {
-			s.vehicles[i].Y = 0
+		if maxSpeed := s.profileFor(vehicle.VehicleType).MaxSpeed; vehicle.Speed > maxSpeed {
+			vehicle.Speed = maxSpeed
 		}
+	}
+}
 
-		// 随机改变方向
-		if rand.Float64() < 0.1 {
-			s.vehicles[i].Direction = rand.Float64() * 360
-		}
+// 无路网时在0-100区域内随机移动，驶出区域时返回true
+func (s *TrafficService) moveRandomly(vehicle *models.Vehicle) bool {
+	speedFactor := vehicle.Speed / 100.0
+	moveDistance := speedFactor * 2.0
 
-		// 随机改变速度
-		if rand.Float64() < 0.2 {
-			speedChange := (rand.Float64() - 0.5) * 20
-			s.vehicles[i].Speed = s.vehicles[i].Speed + speedChange
-			if s.vehicles[i].Speed < 10 {
-				s.vehicles[i].Speed = 10
-			}
-			if s.vehicles[i].Speed > 120 {
-				s.vehicles[i].Speed = 120
-			}
-		}
+	vehicle.X += moveDistance * (s.rng.Float64() - 0.5)
+	vehicle.Y += moveDistance * (s.rng.Float64() - 0.5)
 
-		// 更新状态
-		if s.vehicles[i].Speed > 80 {
-			s.vehicles[i].Status = "overspeed"
-		} else if s.vehicles[i].Speed < 20 {
-			s.vehicles[i].Status = "slow"
-		} else {
-			s.vehicles[i].Status = "normal"
-		}
+	if vehicle.X < 0 || vehicle.X > 100 || vehicle.Y < 0 || vehicle.Y > 100 {
+		return true
 	}
+
+	// 随机改变方向
+	if s.rng.Float64() < 0.1 {
+		vehicle.Direction = s.rng.Float64() * 360
+	}
+	return false
 }
 
 // 生成告警
@@ -368,9 +711,9 @@ func (s *TrafficService) generateAlerts() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// 检查超速
+	// 检查超速，阈值取车型参数
 	for _, vehicle := range s.vehicles {
-		if vehicle.Speed > 80 {
+		if vehicle.Speed > s.profileFor(vehicle.VehicleType).AlertSpeed {
 			// 检查是否已有未解决的超速告警
 			hasAlert := false
 			for _, alert := range s.alerts {
@@ -382,16 +725,16 @@ func (s *TrafficService) generateAlerts() {
 
 			if !hasAlert {
 				alert := models.TrafficAlert{
-					ID:         uint(len(s.alerts) + 1),
+					ID:         s.newAlertID(),
 					AlertType:  "overspeed",
 					VehicleID:  vehicle.VehicleID,
 					AlertValue: vehicle.Speed,
 					Message:    "车辆" + vehicle.VehicleID + "超速行驶",
 					Severity:   "high",
 					Resolved:   false,
-					Timestamp:  time.Now(),
+					Timestamp:  s.clock.Now(),
 				}
-				s.alerts = append(s.alerts, alert)
+				s.addAlert(alert)
 			}
 		}
 	}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"backend/models"
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func near(got, want, tolerance float64) bool {
+	return math.Abs(got-want) <= tolerance
+}
+
+// newNetworkService 只含 checkpointScenario 路网的服务：路段3和1汇入路段2，路段2终点为断头路
+func newNetworkService(t *testing.T) *TrafficService {
+	t.Helper()
+	scenario := checkpointScenario()
+	scenario.Demand = DemandConfig{}
+	scenario.Signals = nil
+	scenario.Transit = nil
+	scenario.GPSEmission = GPSEmissionConfig{}
+	s := NewStandaloneTrafficService()
+	if err := s.LoadScenario(scenario); err != nil {
+		t.Fatalf("load scenario: %v", err)
+	}
+	return s
+}
+
+// 驶过路段终点后进入相连路段，超出部分计入下一路段，驶入断头路时结束行程
+func TestMoveAlongRoadFollowsConnectedSegments(t *testing.T) {
+	s := newNetworkService(t)
+	vehicle := models.Vehicle{RoadID: 1, Offset: s.network.SegmentLength(1) - 10}
+	if finished := s.moveAlongRoad(&vehicle, 30); finished {
+		t.Fatal("vehicle finished on a connected segment")
+	}
+	if vehicle.RoadID != 2 || !near(vehicle.Offset, 20, 1e-9) {
+		t.Fatalf("road %d offset %.2f, want road 2 offset 20", vehicle.RoadID, vehicle.Offset)
+	}
+	if lng, lat := s.network.PositionOnSegment(2, 20); vehicle.X != lng || vehicle.Y != lat {
+		t.Fatalf("position %v,%v, want %v,%v", vehicle.X, vehicle.Y, lng, lat)
+	}
+	if heading := s.network.SegmentHeading(2); vehicle.Direction != heading {
+		t.Fatalf("direction %v, want %v", vehicle.Direction, heading)
+	}
+
+	if finished := s.moveAlongRoad(&vehicle, s.network.SegmentLength(2)); !finished {
+		t.Fatal("vehicle did not leave at the dead end")
+	}
+}
+
+// 有行驶路径的车辆按路径行驶，驶出路径终点路段时结束行程
+func TestMoveAlongRoadFollowsRoute(t *testing.T) {
+	s := newNetworkService(t)
+	vehicle := models.Vehicle{RoadID: 3, Route: []uint{3}, Offset: 0}
+	if finished := s.moveAlongRoad(&vehicle, s.network.SegmentLength(3)+1); !finished {
+		t.Fatal("vehicle continued past the end of its route")
+	}
+
+	vehicle = models.Vehicle{RoadID: 3, Route: []uint{3, 2}}
+	if finished := s.moveAlongRoad(&vehicle, s.network.SegmentLength(3)+1); finished || vehicle.RoadID != 2 || vehicle.RouteIndex != 1 {
+		t.Fatalf("finished=%v road %d index %d, want road 2 index 1", finished, vehicle.RoadID, vehicle.RouteIndex)
+	}
+}
+
+// 模拟过程中车辆始终位于路网路段上
+func TestVehiclesStayOnNetwork(t *testing.T) {
+	s := newCheckpointService(t)
+	for step := 0; step < 300; step++ {
+		stepService(t, s, 1)
+		for _, vehicle := range s.vehicles {
+			if _, ok := s.network.Segment(vehicle.RoadID); !ok {
+				t.Fatalf("step %d: vehicle %s on unknown road %d", step, vehicle.VehicleID, vehicle.RoadID)
+			}
+			if vehicle.Offset < 0 || vehicle.Offset > s.network.SegmentLength(vehicle.RoadID) {
+				t.Fatalf("step %d: vehicle %s offset %.1f outside road %d", step, vehicle.VehicleID, vehicle.Offset, vehicle.RoadID)
+			}
+		}
+	}
+	if len(s.vehicles) == 0 || s.tripStats.Completed == 0 {
+		t.Fatalf("%d vehicles active, %d trips completed", len(s.vehicles), s.tripStats.Completed)
+	}
+}
+
+// runState 车辆轨迹和告警的序列化结果
+func runState(t *testing.T, s *TrafficService) string {
+	t.Helper()
+	data, err := json.Marshal(struct {
+		Vehicles []models.Vehicle
+		Alerts   []models.TrafficAlert
+	}{s.vehicles, s.alerts})
+	if err != nil {
+		t.Fatalf("marshal state: %v", err)
+	}
+	return string(data)
+}
+
+// 相同种子和场景的两次运行车辆轨迹和告警完全一致，不同种子结果不同
+func TestSameSeedReproducesRun(t *testing.T) {
+	first, second := newCheckpointService(t), newCheckpointService(t)
+	scenario := checkpointScenario()
+	seed := int64(7)
+	scenario.Simulation.Seed = &seed
+	other := NewStandaloneTrafficService()
+	if err := other.LoadScenario(scenario); err != nil {
+		t.Fatalf("load scenario: %v", err)
+	}
+	other.SetGPSSink(stubGPSSink{})
+
+	for step := 0; step < 10; step++ {
+		stepService(t, first, 60)
+		stepService(t, second, 60)
+		if a, b := runState(t, first), runState(t, second); a != b {
+			t.Fatalf("runs diverged after %d steps", (step+1)*60)
+		}
+	}
+	if len(first.vehicles) == 0 {
+		t.Fatal("no vehicles generated")
+	}
+
+	stepService(t, other, 600)
+	if runState(t, first) == runState(t, other) {
+		t.Fatal("different seeds produced the same run")
+	}
+}
+
+// 开始模拟时指定的种子被采用并在模拟状态中报告
+func TestStartSimulationReportsSeed(t *testing.T) {
+	s := newNetworkService(t)
+	seed := int64(12345)
+	got, err := s.StartSimulation(SimulationOptions{Seed: &seed})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer s.StopSimulation()
+	if got != seed {
+		t.Fatalf("start returned seed %d, want %d", got, seed)
+	}
+	if status := s.GetSimulationStatus(); status["seed"] != seed {
+		t.Fatalf("status seed = %v, want %d", status["seed"], seed)
+	}
+}
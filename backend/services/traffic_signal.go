@@ -0,0 +1,554 @@
+package services
+
+import (
+	"backend/algorithms"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// 信号灯色
+const (
+	SignalGreen = "green"
+	SignalAmber = "amber"
+	SignalRed   = "red"
+)
+
+// 信号控制方式
+const (
+	SignalFixedTime = "fixed"
+	SignalActuated  = "actuated"
+)
+
+// SignalPhase 信号相位
+type SignalPhase struct {
+	Name      string  `json:"name"`
+	RoadIDs   []uint  `json:"road_ids"`  // 本相位放行的进口路段（终点位于该路口）
+	Green     float64 `json:"green"`     // 绿灯时间 (s)，感应控制下为最小绿灯未设置时的初始绿灯
+	Amber     float64 `json:"amber"`     // 黄灯时间 (s)
+	AllRed    float64 `json:"all_red"`   // 全红时间 (s)
+	MinGreen  float64 `json:"min_green"` // 感应控制最小绿灯 (s)
+	MaxGreen  float64 `json:"max_green"` // 感应控制最大绿灯 (s)
+	Extension float64 `json:"extension"` // 感应控制单位绿灯延长时间 (s)
+}
+
+// SignalPlan 信号配时方案
+type SignalPlan struct {
+	ID               string        `json:"id"`
+	Name             string        `json:"name"`
+	NodeID           string        `json:"node_id"`           // 路口节点，进口路段终点坐标 "lng,lat"
+	Type             string        `json:"type"`              // fixed, actuated
+	Offset           float64       `json:"offset"`            // 相位差 (s)
+	DetectorDistance float64       `json:"detector_distance"` // 感应检测器距停车线距离 (m)
+	Phases           []SignalPhase `json:"phases"`
+}
+
+// CycleLength 计算周期长度（固定配时）
+func (p *SignalPlan) CycleLength() float64 {
+	cycle := 0.0
+	for _, phase := range p.Phases {
+		cycle += phase.Green + phase.Amber + phase.AllRed
+	}
+	return cycle
+}
+
+// SignalState 信号灯当前状态
+type SignalState struct {
+	PlanID     string          `json:"plan_id"`
+	NodeID     string          `json:"node_id"`
+	PhaseIndex int             `json:"phase_index"`
+	PhaseName  string          `json:"phase_name"`
+	Color      string          `json:"color"`      // 当前相位灯色，全红时为red
+	Elapsed    float64         `json:"elapsed"`    // 当前相位已运行时间 (s)
+	CycleTime  float64         `json:"cycle_time"` // 周期内时刻 (s)，仅固定配时有效
+	Approaches map[uint]string `json:"approaches"` // 各进口路段灯色
+}
+
+// signalController 单个路口的信号控制器运行状态
+type signalController struct {
+	plan         SignalPlan
+	clock        float64 // 控制器运行时间 (s)
+	phase        int
+	phaseTime    float64 // 当前相位已运行时间 (s)
+	lastDetected float64 // 感应控制：当前相位最近一次检测到车辆的时刻
+	color        string
+}
+
+// SignalManager 信号控制子系统
+type SignalManager struct {
+	controllers []*signalController
+	approaches  map[uint]*signalController // 进口路段 -> 控制器
+	nextID      int
+}
+
+// NewSignalManager 创建信号控制子系统
+func NewSignalManager() *SignalManager {
+	return &SignalManager{
+		approaches: make(map[uint]*signalController),
+	}
+}
+
+// SignalRuntime 信号控制器运行状态，用于检查点保存和恢复
+type SignalRuntime struct {
+	ID           string  `json:"id"`
+	Clock        float64 `json:"clock"`
+	Phase        int     `json:"phase"`
+	PhaseTime    float64 `json:"phase_time"`
+	LastDetected float64 `json:"last_detected"`
+	Color        string  `json:"color"`
+}
+
+// validateSignalPlan 校验配时方案并补全默认值
+func validateSignalPlan(plan *SignalPlan, network *algorithms.RoadGraph) error {
+	if plan.Type == "" {
+		plan.Type = SignalFixedTime
+	}
+	if plan.Type != SignalFixedTime && plan.Type != SignalActuated {
+		return fmt.Errorf("unknown signal type: %s", plan.Type)
+	}
+	if len(plan.Phases) == 0 {
+		return errors.New("signal plan must have at least one phase")
+	}
+	if plan.DetectorDistance <= 0 {
+		plan.DetectorDistance = 50
+	}
+
+	for i := range plan.Phases {
+		phase := &plan.Phases[i]
+		if phase.Name == "" {
+			phase.Name = fmt.Sprintf("phase-%d", i+1)
+		}
+		if len(phase.RoadIDs) == 0 {
+			return fmt.Errorf("phase %s has no approach roads", phase.Name)
+		}
+		if phase.Amber <= 0 {
+			phase.Amber = 3
+		}
+		if phase.AllRed < 0 {
+			return fmt.Errorf("phase %s has negative all-red time", phase.Name)
+		}
+
+		if plan.Type == SignalActuated {
+			if phase.MinGreen <= 0 {
+				phase.MinGreen = math.Max(phase.Green, 10)
+			}
+			if phase.MaxGreen < phase.MinGreen {
+				phase.MaxGreen = math.Max(phase.MinGreen, 60)
+			}
+			if phase.Extension <= 0 {
+				phase.Extension = 3
+			}
+		} else if phase.Green <= 0 {
+			return fmt.Errorf("phase %s must have a positive green time", phase.Name)
+		}
+
+		for _, roadID := range phase.RoadIDs {
+			road, ok := network.Segment(roadID)
+			if !ok {
+				return fmt.Errorf("road %d not found", roadID)
+			}
+			node := algorithms.NodeKey(road.EndLng, road.EndLat)
+			if plan.NodeID == "" {
+				plan.NodeID = node
+			}
+			if node != plan.NodeID {
+				return fmt.Errorf("road %d does not end at node %s", roadID, plan.NodeID)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Plans 获取全部配时方案
+func (m *SignalManager) Plans() []SignalPlan {
+	plans := make([]SignalPlan, 0, len(m.controllers))
+	for _, controller := range m.controllers {
+		plans = append(plans, controller.plan)
+	}
+	return plans
+}
+
+// find 根据方案ID查找控制器
+func (m *SignalManager) find(id string) (int, *signalController) {
+	for i, controller := range m.controllers {
+		if controller.plan.ID == id {
+			return i, controller
+		}
+	}
+	return -1, nil
+}
+
+// Plan 获取配时方案
+func (m *SignalManager) Plan(id string) (SignalPlan, bool) {
+	if _, controller := m.find(id); controller != nil {
+		return controller.plan, true
+	}
+	return SignalPlan{}, false
+}
+
+// checkConflicts 检查进口路段是否已被其他方案控制
+func (m *SignalManager) checkConflicts(plan SignalPlan) error {
+	for _, phase := range plan.Phases {
+		for _, roadID := range phase.RoadIDs {
+			if owner, ok := m.approaches[roadID]; ok && owner.plan.ID != plan.ID {
+				return fmt.Errorf("road %d is already controlled by signal %s", roadID, owner.plan.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// Add 添加配时方案
+func (m *SignalManager) Add(plan SignalPlan) (SignalPlan, error) {
+	// 自动编号跳过场景文件中已使用的ID
+	for plan.ID == "" {
+		m.nextID++
+		if _, existing := m.find(fmt.Sprintf("S%03d", m.nextID)); existing == nil {
+			plan.ID = fmt.Sprintf("S%03d", m.nextID)
+		}
+	}
+	if _, existing := m.find(plan.ID); existing != nil {
+		return SignalPlan{}, fmt.Errorf("signal %s already exists", plan.ID)
+	}
+	if err := m.checkConflicts(plan); err != nil {
+		return SignalPlan{}, err
+	}
+
+	controller := &signalController{plan: plan}
+	controller.reset()
+	m.controllers = append(m.controllers, controller)
+	m.bindApproaches(controller)
+	return plan, nil
+}
+
+// Runtime 获取全部控制器运行状态
+func (m *SignalManager) Runtime() []SignalRuntime {
+	runtime := make([]SignalRuntime, 0, len(m.controllers))
+	for _, controller := range m.controllers {
+		runtime = append(runtime, SignalRuntime{
+			ID:           controller.plan.ID,
+			Clock:        controller.clock,
+			Phase:        controller.phase,
+			PhaseTime:    controller.phaseTime,
+			LastDetected: controller.lastDetected,
+			Color:        controller.color,
+		})
+	}
+	return runtime
+}
+
+// checkRuntime 校验运行状态与配时方案是否匹配
+func (m *SignalManager) checkRuntime(runtime []SignalRuntime) error {
+	for _, state := range runtime {
+		_, controller := m.find(state.ID)
+		if controller == nil {
+			return fmt.Errorf("signal %s not found", state.ID)
+		}
+		if state.Phase < 0 || state.Phase >= len(controller.plan.Phases) {
+			return fmt.Errorf("signal %s: phase %d out of range", state.ID, state.Phase)
+		}
+	}
+	return nil
+}
+
+// restoreRuntime 恢复控制器运行状态，调用前需经 checkRuntime 校验
+func (m *SignalManager) restoreRuntime(runtime []SignalRuntime) {
+	for _, state := range runtime {
+		_, controller := m.find(state.ID)
+		controller.clock = state.Clock
+		controller.phase = state.Phase
+		controller.phaseTime = state.PhaseTime
+		controller.lastDetected = state.LastDetected
+		controller.color = state.Color
+	}
+}
+
+// Update 修改配时方案，控制器从新方案的第一相位重新开始
+func (m *SignalManager) Update(id string, plan SignalPlan) (SignalPlan, error) {
+	_, controller := m.find(id)
+	if controller == nil {
+		return SignalPlan{}, fmt.Errorf("signal %s not found", id)
+	}
+
+	plan.ID = id
+	if err := m.checkConflicts(plan); err != nil {
+		return SignalPlan{}, err
+	}
+
+	m.unbindApproaches(controller)
+	controller.plan = plan
+	controller.reset()
+	m.bindApproaches(controller)
+	return plan, nil
+}
+
+// Remove 删除配时方案
+func (m *SignalManager) Remove(id string) bool {
+	i, controller := m.find(id)
+	if controller == nil {
+		return false
+	}
+
+	m.unbindApproaches(controller)
+	m.controllers = append(m.controllers[:i], m.controllers[i+1:]...)
+	return true
+}
+
+// bindApproaches 建立进口路段到控制器的索引
+func (m *SignalManager) bindApproaches(controller *signalController) {
+	for _, phase := range controller.plan.Phases {
+		for _, roadID := range phase.RoadIDs {
+			m.approaches[roadID] = controller
+		}
+	}
+}
+
+// unbindApproaches 移除控制器的进口路段索引
+func (m *SignalManager) unbindApproaches(controller *signalController) {
+	for roadID, owner := range m.approaches {
+		if owner == controller {
+			delete(m.approaches, roadID)
+		}
+	}
+}
+
+// Step 推进所有信号控制器，detect 用于感应控制判断进口道检测区内是否有车
+func (m *SignalManager) Step(dt float64, detect func(roadID uint, distance float64) bool) {
+	for _, controller := range m.controllers {
+		controller.step(dt, detect)
+	}
+}
+
+// ApproachColor 获取进口路段当前灯色，无信号控制时返回空字符串
+func (m *SignalManager) ApproachColor(roadID uint) string {
+	controller, ok := m.approaches[roadID]
+	if !ok {
+		return ""
+	}
+	return controller.approachColor(roadID)
+}
+
+// States 获取全部信号灯状态
+func (m *SignalManager) States() []SignalState {
+	states := make([]SignalState, 0, len(m.controllers))
+	for _, controller := range m.controllers {
+		states = append(states, controller.state())
+	}
+	return states
+}
+
+// State 获取指定信号灯状态
+func (m *SignalManager) State(id string) (SignalState, bool) {
+	if _, controller := m.find(id); controller != nil {
+		return controller.state(), true
+	}
+	return SignalState{}, false
+}
+
+// reset 控制器回到初始状态并按相位差定位
+func (c *signalController) reset() {
+	c.clock = 0
+	c.phase = 0
+	c.phaseTime = 0
+	c.lastDetected = 0
+	c.color = SignalGreen
+	if c.plan.Type == SignalFixedTime {
+		c.updateFixedTime()
+	}
+}
+
+// step 推进控制器
+func (c *signalController) step(dt float64, detect func(roadID uint, distance float64) bool) {
+	c.clock += dt
+	if c.plan.Type == SignalFixedTime {
+		c.updateFixedTime()
+		return
+	}
+	c.updateActuated(dt, detect)
+}
+
+// updateFixedTime 固定配时：由周期内时刻确定相位和灯色
+func (c *signalController) updateFixedTime() {
+	cycle := c.plan.CycleLength()
+	if cycle <= 0 {
+		return
+	}
+
+	t := math.Mod(c.clock+c.plan.Offset, cycle)
+	if t < 0 {
+		t += cycle
+	}
+
+	for i, phase := range c.plan.Phases {
+		duration := phase.Green + phase.Amber + phase.AllRed
+		if t < duration {
+			c.phase = i
+			c.phaseTime = t
+			c.color = phaseColor(phase, t)
+			return
+		}
+		t -= duration
+	}
+}
+
+// updateActuated 感应控制：最小绿灯后检测到车辆则延长绿灯，直至最大绿灯
+func (c *signalController) updateActuated(dt float64, detect func(roadID uint, distance float64) bool) {
+	phase := c.plan.Phases[c.phase]
+	c.phaseTime += dt
+
+	if c.color == SignalGreen {
+		for _, roadID := range phase.RoadIDs {
+			if detect(roadID, c.plan.DetectorDistance) {
+				c.lastDetected = c.phaseTime
+				break
+			}
+		}
+
+		gapOut := c.phaseTime-c.lastDetected >= phase.Extension
+		if c.phaseTime >= phase.MaxGreen || (c.phaseTime >= phase.MinGreen && gapOut) {
+			c.color = SignalAmber
+			c.phaseTime = 0
+		}
+		return
+	}
+
+	if c.color == SignalAmber && c.phaseTime >= phase.Amber {
+		c.color = SignalRed
+		c.phaseTime = 0
+	}
+
+	if c.color == SignalRed && c.phaseTime >= phase.AllRed {
+		c.phase = (c.phase + 1) % len(c.plan.Phases)
+		c.phaseTime = 0
+		c.lastDetected = 0
+		c.color = SignalGreen
+	}
+}
+
+// phaseColor 根据相位内时刻计算灯色
+func phaseColor(phase SignalPhase, t float64) string {
+	if t < phase.Green {
+		return SignalGreen
+	}
+	if t < phase.Green+phase.Amber {
+		return SignalAmber
+	}
+	return SignalRed
+}
+
+// approachColor 获取进口路段灯色：当前相位放行的路段取相位灯色，其余为红灯
+func (c *signalController) approachColor(roadID uint) string {
+	for _, id := range c.plan.Phases[c.phase].RoadIDs {
+		if id == roadID {
+			return c.color
+		}
+	}
+	return SignalRed
+}
+
+// state 生成状态快照
+func (c *signalController) state() SignalState {
+	state := SignalState{
+		PlanID:     c.plan.ID,
+		NodeID:     c.plan.NodeID,
+		PhaseIndex: c.phase,
+		PhaseName:  c.plan.Phases[c.phase].Name,
+		Color:      c.color,
+		Elapsed:    c.phaseTime,
+		Approaches: make(map[uint]string),
+	}
+	if c.plan.Type == SignalFixedTime {
+		if cycle := c.plan.CycleLength(); cycle > 0 {
+			state.CycleTime = math.Mod(c.clock+c.plan.Offset, cycle)
+		}
+	}
+
+	for _, phase := range c.plan.Phases {
+		for _, roadID := range phase.RoadIDs {
+			state.Approaches[roadID] = c.approachColor(roadID)
+		}
+	}
+	return state
+}
+
+// GetSignalPlans 获取全部信号配时方案
+func (s *TrafficService) GetSignalPlans() []SignalPlan {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.signals.Plans()
+}
+
+// GetSignalPlan 获取信号配时方案
+func (s *TrafficService) GetSignalPlan(id string) (SignalPlan, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.signals.Plan(id)
+}
+
+// CreateSignalPlan 创建信号配时方案
+func (s *TrafficService) CreateSignalPlan(plan SignalPlan) (SignalPlan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := validateSignalPlan(&plan, s.network); err != nil {
+		return SignalPlan{}, err
+	}
+	return s.signals.Add(plan)
+}
+
+// UpdateSignalPlan 修改信号配时方案
+func (s *TrafficService) UpdateSignalPlan(id string, plan SignalPlan) (SignalPlan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := validateSignalPlan(&plan, s.network); err != nil {
+		return SignalPlan{}, err
+	}
+	return s.signals.Update(id, plan)
+}
+
+// DeleteSignalPlan 删除信号配时方案
+func (s *TrafficService) DeleteSignalPlan(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.signals.Remove(id)
+}
+
+// GetSignalStates 获取全部信号灯状态
+func (s *TrafficService) GetSignalStates() []SignalState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.signals.States()
+}
+
+// GetSignalState 获取指定信号灯状态
+func (s *TrafficService) GetSignalState(id string) (SignalState, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.signals.State(id)
+}
+
+// detectVehicle 感应检测：进口路段停车线前指定距离内是否有车
+func (s *TrafficService) detectVehicle(roadID uint, distance float64) bool {
+	length := s.network.SegmentLength(roadID)
+	for i := range s.vehicles {
+		if s.vehicles[i].RoadID == roadID && s.vehicles[i].Offset >= length-distance {
+			return true
+		}
+	}
+	return false
+}
+
+// mustStopAtSignal 判断车辆是否需要在停车线前停车
+// 红灯必须停车；黄灯时能以舒适减速度在停车线前停住则停车，否则通过
+func (s *TrafficService) mustStopAtSignal(roadID uint, speed, distance float64, params IDMParams) bool {
+	switch s.signals.ApproachColor(roadID) {
+	case SignalRed:
+		return true
+	case SignalAmber:
+		return speed*speed/(2*params.ComfortDecel) < distance
+	default:
+		return false
+	}
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"backend/algorithms"
+	"backend/models"
+	"testing"
+)
+
+// signalNetwork 路段1和3的终点为同一路口，路段2从该路口驶出
+func signalNetwork() *algorithms.RoadGraph {
+	return algorithms.NewRoadGraph([]models.RoadSegment{
+		{ID: 1, StartLng: 116.0, StartLat: 39.0, EndLng: 116.01, EndLat: 39.0},
+		{ID: 2, StartLng: 116.01, StartLat: 39.0, EndLng: 116.02, EndLat: 39.0},
+		{ID: 3, StartLng: 116.01, StartLat: 39.01, EndLng: 116.01, EndLat: 39.0},
+	})
+}
+
+func newSignalManager(t *testing.T, plan SignalPlan) *SignalManager {
+	t.Helper()
+	if err := validateSignalPlan(&plan, signalNetwork()); err != nil {
+		t.Fatalf("validate plan: %v", err)
+	}
+	manager := NewSignalManager()
+	if _, err := manager.Add(plan); err != nil {
+		t.Fatalf("add plan: %v", err)
+	}
+	return manager
+}
+
+func noDetection(uint, float64) bool { return false }
+
+// 固定配时按周期内时刻切换相位，当前相位以外的进口为红灯
+func TestFixedTimeSignalCycle(t *testing.T) {
+	manager := newSignalManager(t, SignalPlan{Phases: []SignalPhase{
+		{RoadIDs: []uint{1}, Green: 20, Amber: 3, AllRed: 2},
+		{RoadIDs: []uint{3}, Green: 10, Amber: 3, AllRed: 2},
+	}})
+	if plan, _ := manager.Plan("S001"); plan.CycleLength() != 40 || plan.NodeID != algorithms.NodeKey(116.01, 39.0) {
+		t.Fatalf("plan = %+v", plan)
+	}
+
+	expected := map[int][2]string{
+		0:  {SignalGreen, SignalRed},
+		20: {SignalAmber, SignalRed},
+		23: {SignalRed, SignalRed},
+		25: {SignalRed, SignalGreen},
+		35: {SignalRed, SignalAmber},
+		40: {SignalGreen, SignalRed},
+	}
+	for second := 0; second <= 40; second++ {
+		if want, ok := expected[second]; ok {
+			if got := [2]string{manager.ApproachColor(1), manager.ApproachColor(3)}; got != want {
+				t.Fatalf("at %ds colors = %v, want %v", second, got, want)
+			}
+		}
+		manager.Step(1, noDetection)
+	}
+	if color := manager.ApproachColor(2); color != "" {
+		t.Fatalf("uncontrolled road color = %q", color)
+	}
+}
+
+// 相位差使周期整体提前
+func TestFixedTimeSignalOffset(t *testing.T) {
+	manager := newSignalManager(t, SignalPlan{Offset: 25, Phases: []SignalPhase{
+		{RoadIDs: []uint{1}, Green: 20, Amber: 3, AllRed: 2},
+		{RoadIDs: []uint{3}, Green: 10, Amber: 3, AllRed: 2},
+	}})
+	if state, _ := manager.State("S001"); state.PhaseIndex != 1 || state.Color != SignalGreen || state.CycleTime != 25 {
+		t.Fatalf("state = %+v, want phase 2 green at cycle time 25", state)
+	}
+}
+
+// 感应控制：持续检测到车辆时绿灯延长到最大绿灯，无车时最小绿灯后结束
+func TestActuatedSignalExtendsGreen(t *testing.T) {
+	plan := SignalPlan{Type: SignalActuated, Phases: []SignalPhase{
+		{RoadIDs: []uint{1}, MinGreen: 10, MaxGreen: 30, Extension: 3, Amber: 3},
+		{RoadIDs: []uint{3}, MinGreen: 10, MaxGreen: 30, Extension: 3, Amber: 3},
+	}}
+	greenFor := func(detect func(uint, float64) bool) int {
+		manager := newSignalManager(t, plan)
+		seconds := 0
+		for manager.ApproachColor(1) == SignalGreen {
+			manager.Step(1, detect)
+			seconds++
+		}
+		return seconds
+	}
+
+	if green := greenFor(noDetection); green != 10 {
+		t.Fatalf("green without demand = %ds, want min green 10s", green)
+	}
+	always := func(roadID uint, distance float64) bool { return roadID == 1 && distance == 50 }
+	if green := greenFor(always); green != 30 {
+		t.Fatalf("green with continuous demand = %ds, want max green 30s", green)
+	}
+
+	// 黄灯结束后切换到下一相位
+	manager := newSignalManager(t, plan)
+	for i := 0; i < 13; i++ {
+		manager.Step(1, noDetection)
+	}
+	if manager.ApproachColor(1) != SignalRed || manager.ApproachColor(3) != SignalGreen {
+		t.Fatalf("after min green and amber: road 1 %s, road 3 %s", manager.ApproachColor(1), manager.ApproachColor(3))
+	}
+}
+
+func TestValidateSignalPlan(t *testing.T) {
+	network := signalNetwork()
+	plan := SignalPlan{Phases: []SignalPhase{{RoadIDs: []uint{1, 2}, Green: 20}}}
+	if err := validateSignalPlan(&plan, network); err == nil {
+		t.Fatal("accepted approaches ending at different nodes")
+	}
+	plan = SignalPlan{Phases: []SignalPhase{{RoadIDs: []uint{1}}}}
+	if err := validateSignalPlan(&plan, network); err == nil {
+		t.Fatal("accepted a fixed-time phase without green time")
+	}
+	plan = SignalPlan{Type: SignalActuated, Phases: []SignalPhase{{RoadIDs: []uint{1}}}}
+	if err := validateSignalPlan(&plan, network); err != nil {
+		t.Fatalf("actuated defaults: %v", err)
+	}
+	if phase := plan.Phases[0]; phase.MinGreen != 10 || phase.MaxGreen != 60 || phase.Extension != 3 || phase.Amber != 3 || plan.DetectorDistance != 50 {
+		t.Fatalf("defaults not applied: %+v", plan)
+	}
+
+	manager := NewSignalManager()
+	if _, err := manager.Add(plan); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if _, err := manager.Add(plan); err == nil {
+		t.Fatal("added a second plan controlling the same approach")
+	}
+}
+
+// 黄灯时能以舒适减速度在停车线前停住则停车，否则通过
+func TestMustStopAtAmber(t *testing.T) {
+	s := newNetworkService(t)
+	if _, err := s.CreateSignalPlan(SignalPlan{Phases: []SignalPhase{{RoadIDs: []uint{1}, Green: 1, Amber: 10}, {RoadIDs: []uint{3}, Green: 10}}}); err != nil {
+		t.Fatalf("create plan: %v", err)
+	}
+	s.signals.Step(2, noDetection)
+	params := s.idmParamsFor(defaultVehicleType)
+	if !s.mustStopAtSignal(1, 10, 50, params) {
+		t.Fatal("did not stop for amber with 50 m to stop from 10 m/s")
+	}
+	if s.mustStopAtSignal(1, 15, 20, params) {
+		t.Fatal("stopped for amber 20 m before the line at 15 m/s")
+	}
+	if !s.mustStopAtSignal(3, 15, 20, params) {
+		t.Fatal("did not stop for red")
+	}
+}
@@ -0,0 +1,360 @@
+package services
+
+import (
+	"backend/models"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	trajectoryInterval  = 1.0     // 模拟车辆轨迹采样间隔 (s)
+	maxTrajectoryPoints = 100000  // 模拟运行中保留的轨迹点数
+	maxTrajectoryExport = 1000000 // 单次从GPS数据导出的点数上限
+)
+
+// 轨迹导出格式
+const (
+	TrajectoryGeoJSON = "geojson" // 每辆车一条 LineString
+	TrajectoryCSV     = "csv"     // 每个点一行
+	TrajectoryFCD     = "fcd"     // SUMO floating car data XML
+)
+
+// ErrInvalidTrajectoryRange 从GPS数据导出时未指定或指定了无效的时间范围
+var ErrInvalidTrajectoryRange = errors.New("invalid time range")
+
+// ErrTooManyTrajectoryPoints 导出范围内GPS点过多
+var ErrTooManyTrajectoryPoints = fmt.Errorf("more than %d GPS points in range, narrow the time range or filter by vehicle or road", maxTrajectoryExport)
+
+// TrajectoryPoint 轨迹点
+type TrajectoryPoint struct {
+	VehicleID   string    `json:"vehicle_id"`
+	VehicleType string    `json:"vehicle_type"`
+	Timestamp   time.Time `json:"timestamp"`
+	Lng         float64   `json:"lng"`
+	Lat         float64   `json:"lat"`
+	Speed       float64   `json:"speed"`     // km/h
+	Direction   float64   `json:"direction"` // 度，正北为0
+	RoadID      uint      `json:"road_id"`   // 所在路段，0表示未匹配
+	Lane        int       `json:"lane"`
+	Offset      float64   `json:"offset"` // 距路段起点的距离 (m)，GPS数据为0
+}
+
+// TrajectoryFilter 轨迹筛选条件，零值表示不限
+type TrajectoryFilter struct {
+	From       time.Time
+	To         time.Time
+	VehicleIDs []string
+	RoadIDs    []uint
+}
+
+// matches 判断轨迹点是否满足筛选条件
+func (f *TrajectoryFilter) matches(point *TrajectoryPoint) bool {
+	return (f.From.IsZero() || !point.Timestamp.Before(f.From)) &&
+		(f.To.IsZero() || point.Timestamp.Before(f.To)) &&
+		(len(f.VehicleIDs) == 0 || slices.Contains(f.VehicleIDs, point.VehicleID)) &&
+		(len(f.RoadIDs) == 0 || slices.Contains(f.RoadIDs, point.RoadID))
+}
+
+// ParseTrajectoryIDs 解析逗号分隔的车辆ID和路段ID
+func ParseTrajectoryIDs(vehicles, roads string) ([]string, []uint, error) {
+	var vehicleIDs []string
+	for _, id := range strings.Split(vehicles, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			vehicleIDs = append(vehicleIDs, id)
+		}
+	}
+
+	var roadIDs []uint
+	for _, item := range strings.Split(roads, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(item, 10, 64)
+		if err != nil || id == 0 {
+			return nil, nil, fmt.Errorf("invalid road ID %q", item)
+		}
+		roadIDs = append(roadIDs, uint(id))
+	}
+	return vehicleIDs, roadIDs, nil
+}
+
+// ParseTrajectoryFormat 校验导出格式
+func ParseTrajectoryFormat(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case TrajectoryGeoJSON, "json":
+		return TrajectoryGeoJSON, nil
+	case TrajectoryCSV:
+		return TrajectoryCSV, nil
+	case TrajectoryFCD, "xml":
+		return TrajectoryFCD, nil
+	}
+	return "", fmt.Errorf("unknown trajectory format %q, expected geojson, csv or fcd", format)
+}
+
+// TrajectoryFormatFromPath 按文件扩展名判断导出格式
+func TrajectoryFormatFromPath(path string) (string, error) {
+	return ParseTrajectoryFormat(strings.TrimPrefix(filepath.Ext(path), "."))
+}
+
+// TrajectoryFileName 导出文件名
+func TrajectoryFileName(format string) string {
+	switch format {
+	case TrajectoryCSV:
+		return "trajectories.csv"
+	case TrajectoryFCD:
+		return "fcd.xml"
+	}
+	return "trajectories.geojson"
+}
+
+// TrajectoryContentType 导出格式的 Content-Type
+func TrajectoryContentType(format string) string {
+	switch format {
+	case TrajectoryCSV:
+		return "text/csv; charset=utf-8"
+	case TrajectoryFCD:
+		return "application/xml; charset=utf-8"
+	}
+	return "application/geo+json"
+}
+
+// trajectoryPoint 车辆当前位置的轨迹点
+func (s *TrafficService) trajectoryPoint(vehicle *models.Vehicle) TrajectoryPoint {
+	return TrajectoryPoint{
+		VehicleID:   vehicle.VehicleID,
+		VehicleType: vehicle.VehicleType,
+		Timestamp:   s.clock.Now(),
+		Lng:         vehicle.X,
+		Lat:         vehicle.Y,
+		Speed:       vehicle.Speed,
+		Direction:   vehicle.Direction,
+		RoadID:      vehicle.RoadID,
+		Lane:        vehicle.Lane,
+		Offset:      vehicle.Offset,
+	}
+}
+
+// sampleTrajectories 到达采样时刻时将路网车辆满足条件的位置追加到 points，filter 为空时不筛选
+func (s *TrafficService) sampleTrajectories(points []TrajectoryPoint, filter *TrajectoryFilter) []TrajectoryPoint {
+	if !staggeredDue(0, s.clock.ElapsedSeconds(), trajectoryInterval, s.clock.StepSeconds()) {
+		return points
+	}
+	for i := range s.vehicles {
+		if s.vehicles[i].RoadID == 0 {
+			continue
+		}
+		point := s.trajectoryPoint(&s.vehicles[i])
+		if filter == nil || filter.matches(&point) {
+			points = append(points, point)
+		}
+	}
+	return points
+}
+
+// recordTrajectories 记录路网车辆轨迹，保留最近的 maxTrajectoryPoints 个点
+func (s *TrafficService) recordTrajectories() {
+	s.trajectories = s.sampleTrajectories(s.trajectories, nil)
+	if len(s.trajectories) > maxTrajectoryPoints {
+		s.trajectories = append(s.trajectories[:0], s.trajectories[len(s.trajectories)-maxTrajectoryPoints:]...)
+	}
+}
+
+// GetTrajectories 获取模拟车辆的轨迹点，同时返回模拟起始时刻
+func (s *TrafficService) GetTrajectories(filter TrajectoryFilter) ([]TrajectoryPoint, time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	points := make([]TrajectoryPoint, 0)
+	for i := range s.trajectories {
+		if filter.matches(&s.trajectories[i]) {
+			points = append(points, s.trajectories[i])
+		}
+	}
+	return points, s.clock.Start
+}
+
+// GetTrajectories 从已入库的GPS数据获取轨迹点，必须指定时间范围
+func (s *GPSService) GetTrajectories(filter TrajectoryFilter) ([]TrajectoryPoint, error) {
+	if filter.From.IsZero() || filter.To.IsZero() {
+		return nil, fmt.Errorf("%w: from and to are required for gps data", ErrInvalidTrajectoryRange)
+	}
+	if !filter.To.After(filter.From) {
+		return nil, fmt.Errorf("%w: to must be later than from", ErrInvalidTrajectoryRange)
+	}
+
+	data, err := s.gpsRepo.FindTrace(filter.From, filter.To, filter.VehicleIDs, filter.RoadIDs, maxTrajectoryExport+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxTrajectoryExport {
+		return nil, ErrTooManyTrajectoryPoints
+	}
+
+	points := make([]TrajectoryPoint, len(data))
+	for i := range data {
+		gps := &data[i]
+		points[i] = TrajectoryPoint{
+			VehicleID:   gps.VehicleID,
+			VehicleType: gps.VehicleType,
+			Timestamp:   gps.Timestamp,
+			Lng:         gps.Longitude,
+			Lat:         gps.Latitude,
+			Speed:       float64(gps.Speed),
+			Direction:   float64(gps.Direction),
+			RoadID:      gps.RoadSegmentID(),
+		}
+	}
+	return points, nil
+}
+
+// WriteTrajectories 按格式写出轨迹点，begin 为 FCD 的时间零点
+func WriteTrajectories(w io.Writer, format string, points []TrajectoryPoint, begin time.Time) error {
+	sorted := slices.Clone(points)
+	slices.SortStableFunc(sorted, func(a, b TrajectoryPoint) int { return a.Timestamp.Compare(b.Timestamp) })
+
+	switch format {
+	case TrajectoryGeoJSON:
+		return writeTrajectoryGeoJSON(w, sorted)
+	case TrajectoryCSV:
+		return writeTrajectoryCSV(w, sorted)
+	case TrajectoryFCD:
+		return writeTrajectoryFCD(w, sorted, begin)
+	}
+	return fmt.Errorf("unknown trajectory format %q", format)
+}
+
+// geoJSONFeature GeoJSON要素
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// geoJSONGeometry GeoJSON几何
+type geoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// writeTrajectoryGeoJSON 每辆车一个 LineString 要素，只有一个点的车辆为 Point
+// 各点的时刻、车速和路段按顺序放在 times、speeds、road_ids 属性中
+func writeTrajectoryGeoJSON(w io.Writer, points []TrajectoryPoint) error {
+	var order []string
+	tracks := make(map[string][]TrajectoryPoint)
+	for _, point := range points {
+		if _, ok := tracks[point.VehicleID]; !ok {
+			order = append(order, point.VehicleID)
+		}
+		tracks[point.VehicleID] = append(tracks[point.VehicleID], point)
+	}
+
+	features := make([]geoJSONFeature, 0, len(order))
+	for _, vehicleID := range order {
+		track := tracks[vehicleID]
+		coordinates := make([][2]float64, len(track))
+		times := make([]string, len(track))
+		speeds := make([]float64, len(track))
+		roads := make([]uint, len(track))
+		for i, point := range track {
+			coordinates[i] = [2]float64{point.Lng, point.Lat}
+			times[i] = point.Timestamp.Format(time.RFC3339Nano)
+			speeds[i] = point.Speed
+			roads[i] = point.RoadID
+		}
+
+		geometry := geoJSONGeometry{Type: "LineString", Coordinates: coordinates}
+		if len(coordinates) == 1 {
+			geometry = geoJSONGeometry{Type: "Point", Coordinates: coordinates[0]}
+		}
+		features = append(features, geoJSONFeature{
+			Type:     "Feature",
+			Geometry: geometry,
+			Properties: map[string]interface{}{
+				"vehicle_id":   vehicleID,
+				"vehicle_type": track[0].VehicleType,
+				"start_time":   times[0],
+				"end_time":     times[len(times)-1],
+				"times":        times,
+				"speeds":       speeds,
+				"road_ids":     roads,
+			},
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	return encoder.Encode(map[string]interface{}{
+		"type":     "FeatureCollection",
+		"features": features,
+	})
+}
+
+// writeTrajectoryCSV 每个点一行
+func writeTrajectoryCSV(w io.Writer, points []TrajectoryPoint) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"vehicle_id", "vehicle_type", "timestamp", "lng", "lat", "speed", "direction", "road_id", "lane", "offset"}); err != nil {
+		return err
+	}
+	for _, point := range points {
+		record := []string{
+			point.VehicleID,
+			point.VehicleType,
+			point.Timestamp.Format(time.RFC3339Nano),
+			strconv.FormatFloat(point.Lng, 'f', 7, 64),
+			strconv.FormatFloat(point.Lat, 'f', 7, 64),
+			formatFloat(point.Speed),
+			formatFloat(point.Direction),
+			formatUint(point.RoadID),
+			strconv.Itoa(point.Lane),
+			formatFloat(point.Offset),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeTrajectoryFCD 按 SUMO fcd-export 格式写出，x、y 为经纬度（对应 --fcd-output.geo），车速单位 m/s
+// 时刻为相对 begin 的秒数，车道为 <路段ID>_<车道>
+func writeTrajectoryFCD(w io.Writer, points []TrajectoryPoint, begin time.Time) error {
+	writer := bufio.NewWriter(w)
+	writer.WriteString(xml.Header + "<fcd-export>\n")
+	for i, point := range points {
+		if i == 0 || !point.Timestamp.Equal(points[i-1].Timestamp) {
+			if i > 0 {
+				writer.WriteString("    </timestep>\n")
+			}
+			fmt.Fprintf(writer, "    <timestep time=\"%.2f\">\n", point.Timestamp.Sub(begin).Seconds())
+		}
+
+		lane := ""
+		if point.RoadID != 0 {
+			lane = fmt.Sprintf("%d_%d", point.RoadID, point.Lane)
+		}
+		fmt.Fprintf(writer, "        <vehicle id=\"%s\" x=\"%.7f\" y=\"%.7f\" angle=\"%.2f\" type=\"%s\" speed=\"%.2f\" pos=\"%.2f\" lane=\"%s\" slope=\"0.00\"/>\n",
+			xmlEscape(point.VehicleID), point.Lng, point.Lat, point.Direction, xmlEscape(point.VehicleType), point.Speed/3.6, point.Offset, lane)
+	}
+	if len(points) > 0 {
+		writer.WriteString("    </timestep>\n")
+	}
+	writer.WriteString("</fcd-export>\n")
+	return writer.Flush()
+}
+
+// xmlEscape 转义XML属性值
+func xmlEscape(value string) string {
+	var builder strings.Builder
+	xml.EscapeText(&builder, []byte(value))
+	return builder.String()
+}
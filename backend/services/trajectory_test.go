@@ -0,0 +1,200 @@
+package services
+
+import (
+	"backend/models"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"slices"
+	"testing"
+	"time"
+)
+
+// trajectoryPoints 两辆车的轨迹点，未按时间排序：V1 两个点，车辆ID含XML特殊字符的车只有一个点
+func trajectoryPoints() ([]TrajectoryPoint, time.Time) {
+	begin := time.Date(2024, 5, 1, 7, 0, 0, 0, time.UTC)
+	return []TrajectoryPoint{
+		{VehicleID: "V1", VehicleType: "car", Timestamp: begin.Add(2 * time.Second), Lng: 116.0001, Lat: 39, Speed: 36, RoadID: 1, Lane: 1, Offset: 12},
+		{VehicleID: "T<1>", VehicleType: "truck", Timestamp: begin.Add(time.Second), Lng: 116.01, Lat: 39.005, Speed: 18, Direction: 180},
+		{VehicleID: "V1", VehicleType: "car", Timestamp: begin.Add(time.Second), Lng: 116.0, Lat: 39, Speed: 36, RoadID: 1, Offset: 2},
+	}, begin
+}
+
+// GeoJSON 每辆车一个按时间排序的要素，只有一个点的车辆为 Point
+func TestWriteTrajectoryGeoJSON(t *testing.T) {
+	points, begin := trajectoryPoints()
+	var buf bytes.Buffer
+	if err := WriteTrajectories(&buf, TrajectoryGeoJSON, points, begin); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Geometry struct {
+				Type        string          `json:"type"`
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+			Properties struct {
+				VehicleID string    `json:"vehicle_id"`
+				Times     []string  `json:"times"`
+				Speeds    []float64 `json:"speeds"`
+				RoadIDs   []uint    `json:"road_ids"`
+			} `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &collection); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if collection.Type != "FeatureCollection" || len(collection.Features) != 2 {
+		t.Fatalf("collection = %+v", collection)
+	}
+
+	line := collection.Features[1]
+	var coordinates [][2]float64
+	if err := json.Unmarshal(line.Geometry.Coordinates, &coordinates); err != nil {
+		t.Fatalf("decode coordinates: %v", err)
+	}
+	if line.Properties.VehicleID != "V1" || line.Geometry.Type != "LineString" ||
+		!slices.Equal(coordinates, [][2]float64{{116.0, 39}, {116.0001, 39}}) {
+		t.Fatalf("V1 feature = %+v, coordinates %v", line, coordinates)
+	}
+	if line.Properties.Times[0] != "2024-05-01T07:00:01Z" || !slices.Equal(line.Properties.RoadIDs, []uint{1, 1}) {
+		t.Fatalf("V1 properties = %+v", line.Properties)
+	}
+	if point := collection.Features[0]; point.Properties.VehicleID != "T<1>" || point.Geometry.Type != "Point" {
+		t.Fatalf("single point feature = %+v", point)
+	}
+}
+
+// CSV 每个点一行，按时间排序，坐标保留7位小数
+func TestWriteTrajectoryCSV(t *testing.T) {
+	points, begin := trajectoryPoints()
+	var buf bytes.Buffer
+	if err := WriteTrajectories(&buf, TrajectoryCSV, points, begin); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	want := [][]string{
+		{"vehicle_id", "vehicle_type", "timestamp", "lng", "lat", "speed", "direction", "road_id", "lane", "offset"},
+		{"T<1>", "truck", "2024-05-01T07:00:01Z", "116.0100000", "39.0050000", "18", "180", "0", "0", "0"},
+		{"V1", "car", "2024-05-01T07:00:01Z", "116.0000000", "39.0000000", "36", "0", "1", "0", "2"},
+		{"V1", "car", "2024-05-01T07:00:02Z", "116.0001000", "39.0000000", "36", "0", "1", "1", "12"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(rows), len(want))
+	}
+	for i := range want {
+		if !slices.Equal(rows[i], want[i]) {
+			t.Fatalf("row %d = %v, want %v", i, rows[i], want[i])
+		}
+	}
+}
+
+// FCD 按时刻分组为 timestep，时刻相对 begin，车速换算为 m/s，车道为 <路段ID>_<车道>，属性值转义
+func TestWriteTrajectoryFCD(t *testing.T) {
+	points, begin := trajectoryPoints()
+	var buf bytes.Buffer
+	if err := WriteTrajectories(&buf, TrajectoryFCD, points, begin); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	var export struct {
+		Timesteps []struct {
+			Time     float64 `xml:"time,attr"`
+			Vehicles []struct {
+				ID    string  `xml:"id,attr"`
+				X     float64 `xml:"x,attr"`
+				Speed float64 `xml:"speed,attr"`
+				Pos   float64 `xml:"pos,attr"`
+				Lane  string  `xml:"lane,attr"`
+			} `xml:"vehicle"`
+		} `xml:"timestep"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &export); err != nil {
+		t.Fatalf("decode: %v\n%s", err, buf.String())
+	}
+	if len(export.Timesteps) != 2 || export.Timesteps[0].Time != 1 || export.Timesteps[1].Time != 2 {
+		t.Fatalf("timesteps = %+v", export.Timesteps)
+	}
+	first := export.Timesteps[0].Vehicles
+	if len(first) != 2 || first[0].ID != "T<1>" || first[0].Lane != "" || first[0].Speed != 5 {
+		t.Fatalf("first timestep = %+v", first)
+	}
+	if v1 := export.Timesteps[1].Vehicles[0]; v1.ID != "V1" || v1.Lane != "1_1" || v1.Speed != 10 || v1.Pos != 12 || v1.X != 116.0001 {
+		t.Fatalf("V1 at 2s = %+v", v1)
+	}
+
+	buf.Reset()
+	if err := WriteTrajectories(&buf, TrajectoryFCD, nil, begin); err != nil || buf.String() != xml.Header+"<fcd-export>\n</fcd-export>\n" {
+		t.Fatalf("empty export = %q, err = %v", buf.String(), err)
+	}
+	if err := WriteTrajectories(&buf, "kml", points, begin); err == nil {
+		t.Fatal("accepted an unknown format")
+	}
+}
+
+// 格式名、文件扩展名和ID列表的解析
+func TestParseTrajectoryOptions(t *testing.T) {
+	for input, want := range map[string]string{"GeoJSON": TrajectoryGeoJSON, "json": TrajectoryGeoJSON, "csv": TrajectoryCSV, "xml": TrajectoryFCD} {
+		if got, err := ParseTrajectoryFormat(input); err != nil || got != want {
+			t.Fatalf("format %q = %q, %v", input, got, err)
+		}
+	}
+	if format, err := TrajectoryFormatFromPath("out/run1/fcd.xml"); err != nil || format != TrajectoryFCD || TrajectoryFileName(format) != "fcd.xml" {
+		t.Fatalf("format from path = %q, %v", format, err)
+	}
+	if _, err := TrajectoryFormatFromPath("trajectories.kml"); err == nil {
+		t.Fatal("accepted .kml")
+	}
+
+	vehicles, roads, err := ParseTrajectoryIDs(" V1, ,V2", "3, 7")
+	if err != nil || !slices.Equal(vehicles, []string{"V1", "V2"}) || !slices.Equal(roads, []uint{3, 7}) {
+		t.Fatalf("ids = %v, %v, %v", vehicles, roads, err)
+	}
+	for _, roads := range []string{"x", "0"} {
+		if _, _, err := ParseTrajectoryIDs("", roads); err == nil {
+			t.Fatalf("accepted road ids %q", roads)
+		}
+	}
+}
+
+// 模拟运行中每秒记录路网车辆的轨迹点，按车辆、路段和时间窗口筛选
+func TestRecordedTrajectories(t *testing.T) {
+	s := newNetworkService(t)
+	for _, vehicle := range []models.Vehicle{
+		{VehicleID: "V1", RoadID: 1, Route: []uint{1, 2}, Speed: 40},
+		{VehicleID: "V2", RoadID: 3, Route: []uint{3, 2}, Speed: 30},
+	} {
+		if _, err := s.AddVehicle(vehicle); err != nil {
+			t.Fatalf("add vehicle: %v", err)
+		}
+	}
+	stepService(t, s, 10)
+
+	all, begin := s.GetTrajectories(TrajectoryFilter{})
+	if len(all) != 20 || !begin.Equal(s.clock.Start) {
+		t.Fatalf("got %d points, begin %v", len(all), begin)
+	}
+	filter := TrajectoryFilter{
+		From:       begin.Add(3 * time.Second),
+		To:         begin.Add(6 * time.Second),
+		VehicleIDs: []string{"V1"},
+	}
+	points, _ := s.GetTrajectories(filter)
+	if len(points) != 3 {
+		t.Fatalf("filtered %d points, want 3", len(points))
+	}
+	for i, point := range points {
+		if point.VehicleID != "V1" || point.RoadID != 1 || (i > 0 && point.Offset <= points[i-1].Offset) {
+			t.Fatalf("point %d = %+v", i, point)
+		}
+	}
+	if points, _ := s.GetTrajectories(TrajectoryFilter{RoadIDs: []uint{3}}); len(points) != 10 || points[0].VehicleID != "V2" {
+		t.Fatalf("road 3: %d points", len(points))
+	}
+}
@@ -0,0 +1,613 @@
+package services
+
+import (
+	"backend/algorithms"
+	"backend/models"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+)
+
+const (
+	defaultTransitVehicleType = "bus"
+	stopArrivalTolerance      = 2.0   // 判定到站的距离容差 (m)
+	stopArrivalSpeed          = 3.6   // 判定到站的最高车速 (km/h)
+	stopApproachDistance      = 200.0 // 公交车开始驶向最外侧车道的距离 (m)
+	terminalClearance         = 5.0   // 末路段停靠站距路段终点的最小距离 (m)
+	earlyTolerance            = 60.0  // 早于计划超过该时间视为早点 (s)
+	lateTolerance             = 300.0 // 晚于计划超过该时间视为晚点 (s)
+	maxStopEvents             = 10000 // 保留的到站记录数
+)
+
+// DwellTime 停站时间分布，按截断正态分布抽样，标准差为0时为固定值
+type DwellTime struct {
+	Mean   float64 `json:"mean"`    // 平均停站时间 (s)
+	StdDev float64 `json:"std_dev"` // 标准差 (s)
+	Min    float64 `json:"min"`     // 下限 (s)
+	Max    float64 `json:"max"`     // 上限 (s)，0表示不限
+}
+
+// TransitStop 公交停靠站
+type TransitStop struct {
+	ID              string  `json:"id"`
+	Name            string  `json:"name"`
+	RoadID          uint    `json:"road_id"`          // 所在路段，须在线路路径上
+	Position        float64 `json:"position"`         // 距路段起点的距离 (m)
+	ScheduledOffset float64 `json:"scheduled_offset"` // 计划到站时间，相对发车时刻 (s)，为0时按自由流行驶时间和平均停站时间推算
+
+	routeIndex int // 所在路段在线路路径中的位置
+}
+
+// TransitLine 公交线路
+type TransitLine struct {
+	ID             string        `json:"id"`
+	Name           string        `json:"name"`
+	RoadIDs        []uint        `json:"road_ids"`        // 按行驶顺序排列的路段
+	Stops          []TransitStop `json:"stops"`           // 按行驶顺序排列的停靠站
+	Headway        float64       `json:"headway"`         // 发车间隔 (s)
+	FirstDeparture float64       `json:"first_departure"` // 首班发车时刻，相对模拟开始 (s)
+	LastDeparture  float64       `json:"last_departure"`  // 末班发车时刻，相对模拟开始 (s)，0表示不限
+	Dwell          DwellTime     `json:"dwell"`
+	VehicleType    string        `json:"vehicle_type"` // 默认 bus
+	Dispatched     int           `json:"dispatched"`   // 已发班次数
+}
+
+// TransitTrip 运行中的公交班次
+type TransitTrip struct {
+	VehicleID      string  `json:"vehicle_id"`
+	LineID         string  `json:"line_id"`
+	Departure      float64 `json:"departure"`       // 计划发车时刻，相对模拟开始 (s)
+	NextStop       int     `json:"next_stop"`       // 下一停靠站序号
+	Dwelling       bool    `json:"dwelling"`        // 是否正在停站
+	DwellRemaining float64 `json:"dwell_remaining"` // 剩余停站时间 (s)
+}
+
+// StopEvent 到站记录
+type StopEvent struct {
+	LineID    string  `json:"line_id"`
+	VehicleID string  `json:"vehicle_id"`
+	StopID    string  `json:"stop_id"`
+	Scheduled float64 `json:"scheduled"` // 计划到站时刻，相对模拟开始 (s)
+	Actual    float64 `json:"actual"`    // 实际到站时刻，相对模拟开始 (s)，越站时为经过时刻
+	Deviation float64 `json:"deviation"` // 实际减计划 (s)，正值为晚点
+	Dwell     float64 `json:"dwell"`     // 停站时间 (s)
+	Status    string  `json:"status"`    // early、on_time、late、skipped
+}
+
+// StopAdherence 停靠站准点情况
+type StopAdherence struct {
+	StopID           string  `json:"stop_id"`
+	StopName         string  `json:"stop_name"`
+	Arrivals         int     `json:"arrivals"`
+	Early            int     `json:"early"`
+	OnTime           int     `json:"on_time"`
+	Late             int     `json:"late"`
+	Skipped          int     `json:"skipped"`
+	AverageDeviation float64 `json:"average_deviation"` // 平均偏差 (s)，不含越站
+	MaxEarly         float64 `json:"max_early"`         // 最大早点 (s)
+	MaxLate          float64 `json:"max_late"`          // 最大晚点 (s)
+}
+
+// LineAdherence 线路准点情况
+type LineAdherence struct {
+	LineID      string          `json:"line_id"`
+	Name        string          `json:"name"`
+	Dispatched  int             `json:"dispatched"`
+	ActiveTrips int             `json:"active_trips"`
+	OnTimeRate  float64         `json:"on_time_rate"` // 准点到站比例（不含越站）
+	Stops       []StopAdherence `json:"stops"`
+}
+
+// sample 抽样停站时间
+func (d DwellTime) sample(rng *rand.Rand) float64 {
+	dwell := d.Mean
+	if d.StdDev > 0 {
+		dwell += d.StdDev * rng.NormFloat64()
+	}
+	dwell = math.Max(dwell, d.Min)
+	if d.Max > 0 {
+		dwell = math.Min(dwell, d.Max)
+	}
+	return math.Max(dwell, 0)
+}
+
+// departureTime 第n个班次（从0开始）的计划发车时刻
+func (l *TransitLine) departureTime(n int) float64 {
+	return l.FirstDeparture + float64(n)*l.Headway
+}
+
+// skipMissedDepartures 跳过已错过的班次，避免模拟中途新增或修改线路时集中发车
+func (l *TransitLine) skipMissedDepartures(now float64) {
+	if now > l.FirstDeparture {
+		l.Dispatched = max(l.Dispatched, int(math.Ceil((now-l.FirstDeparture)/l.Headway)))
+	}
+}
+
+// validateTransitLine 校验线路，定位停靠站并推算未给出的计划到站时间
+func validateTransitLine(line *TransitLine, network *algorithms.RoadGraph, profiles map[string]VehicleProfile) error {
+	if line.ID == "" {
+		return errors.New("id is required")
+	}
+	if line.VehicleType == "" {
+		line.VehicleType = defaultTransitVehicleType
+	}
+	if err := checkVehicleType(profiles, line.VehicleType); err != nil {
+		return err
+	}
+	if line.Headway <= 0 {
+		return errors.New("headway must be positive")
+	}
+	if line.FirstDeparture < 0 {
+		return errors.New("first_departure cannot be negative")
+	}
+	if line.LastDeparture != 0 && line.LastDeparture < line.FirstDeparture {
+		return errors.New("last_departure must not be before first_departure")
+	}
+	if line.Dwell.Mean < 0 || line.Dwell.StdDev < 0 || line.Dwell.Min < 0 || line.Dwell.Max < 0 {
+		return errors.New("dwell times cannot be negative")
+	}
+	if line.Dwell.Max > 0 && line.Dwell.Max < line.Dwell.Min {
+		return errors.New("dwell max must not be less than min")
+	}
+
+	if len(line.RoadIDs) == 0 {
+		return errors.New("road_ids cannot be empty")
+	}
+	for i, roadID := range line.RoadIDs {
+		if _, ok := network.Segment(roadID); !ok {
+			return fmt.Errorf("road %d not found", roadID)
+		}
+		if i > 0 && !containsRoad(network.NextSegments(line.RoadIDs[i-1]), roadID) {
+			return fmt.Errorf("road %d is not connected to road %d", roadID, line.RoadIDs[i-1])
+		}
+	}
+
+	if len(line.Stops) == 0 {
+		return errors.New("stops cannot be empty")
+	}
+	profile := profiles[line.VehicleType]
+	routeIndex := 0
+	ids := make(map[string]bool)
+	for i := range line.Stops {
+		stop := &line.Stops[i]
+		if stop.ID == "" {
+			stop.ID = fmt.Sprintf("%s-S%02d", line.ID, i+1)
+		}
+		if ids[stop.ID] {
+			return fmt.Errorf("duplicate stop %s", stop.ID)
+		}
+		ids[stop.ID] = true
+
+		// 停靠站按行驶顺序排列，同一路段上的停靠站位置递增
+		for routeIndex < len(line.RoadIDs) {
+			if line.RoadIDs[routeIndex] == stop.RoadID &&
+				(i == 0 || line.Stops[i-1].routeIndex != routeIndex || stop.Position >= line.Stops[i-1].Position) {
+				break
+			}
+			routeIndex++
+		}
+		if routeIndex >= len(line.RoadIDs) {
+			return fmt.Errorf("stop %s: road %d is not on the route after the previous stop", stop.ID, stop.RoadID)
+		}
+		length := network.SegmentLength(stop.RoadID)
+		if stop.Position < 0 || stop.Position > length {
+			return fmt.Errorf("stop %s: position must be between 0 and %.1f", stop.ID, length)
+		}
+		if routeIndex == len(line.RoadIDs)-1 && stop.Position > length-terminalClearance {
+			return fmt.Errorf("stop %s: stops on the last road must be at least %.0f m before its end", stop.ID, terminalClearance)
+		}
+		if stop.ScheduledOffset < 0 {
+			return fmt.Errorf("stop %s: scheduled_offset cannot be negative", stop.ID)
+		}
+		stop.routeIndex = routeIndex
+	}
+
+	// 计划到站时间：按自由流速度行驶，并在此前每站停留平均停站时间
+	travel, index := 0.0, 0
+	for i := range line.Stops {
+		stop := &line.Stops[i]
+		for ; index < stop.routeIndex; index++ {
+			travel += network.SegmentLength(line.RoadIDs[index]) / freeFlowSpeed(network, line.RoadIDs[index], profile)
+		}
+		if stop.ScheduledOffset == 0 {
+			stop.ScheduledOffset = math.Round(travel + stop.Position/freeFlowSpeed(network, stop.RoadID, profile) + float64(i)*line.Dwell.Mean)
+		}
+		if i > 0 && stop.ScheduledOffset < line.Stops[i-1].ScheduledOffset {
+			return fmt.Errorf("stop %s: scheduled_offset must not be earlier than the previous stop", stop.ID)
+		}
+	}
+	return nil
+}
+
+// containsRoad 判断路段列表是否包含指定路段
+func containsRoad(roads []uint, roadID uint) bool {
+	for _, id := range roads {
+		if id == roadID {
+			return true
+		}
+	}
+	return false
+}
+
+// transitLine 按ID查找线路
+func (s *TrafficService) transitLine(id string) *TransitLine {
+	for i := range s.transitLines {
+		if s.transitLines[i].ID == id {
+			return &s.transitLines[i]
+		}
+	}
+	return nil
+}
+
+// transitTripFor 获取车辆对应的公交班次及线路
+func (s *TrafficService) transitTripFor(vehicle *models.Vehicle) (*TransitTrip, *TransitLine, bool) {
+	trip, ok := s.transitTrips[vehicle.VehicleID]
+	if !ok {
+		return nil, nil, false
+	}
+	line := s.transitLine(trip.LineID)
+	if line == nil {
+		return nil, nil, false
+	}
+	return trip, line, true
+}
+
+// distanceToStop 车辆到下一停靠站的距离，已驶过时为负值；无待停靠站时返回false
+func (s *TrafficService) distanceToStop(vehicle *models.Vehicle, trip *TransitTrip, line *TransitLine) (float64, bool) {
+	if trip.NextStop >= len(line.Stops) || vehicle.RouteIndex >= len(line.RoadIDs) {
+		return 0, false
+	}
+	stop := &line.Stops[trip.NextStop]
+	distance := stop.Position - vehicle.Offset
+	for i := vehicle.RouteIndex; i < stop.routeIndex; i++ {
+		distance += s.network.SegmentLength(line.RoadIDs[i])
+	}
+	for i := stop.routeIndex; i < vehicle.RouteIndex; i++ {
+		distance -= s.network.SegmentLength(line.RoadIDs[i])
+	}
+	return distance, true
+}
+
+// approachingStop 判断公交车是否即将到达停靠站，需要驶向最外侧车道
+func (s *TrafficService) approachingStop(vehicle *models.Vehicle) bool {
+	trip, line, ok := s.transitTripFor(vehicle)
+	if !ok || trip.Dwelling {
+		return false
+	}
+	distance, ok := s.distanceToStop(vehicle, trip, line)
+	return ok && distance >= 0 && distance <= stopApproachDistance
+}
+
+// dwelling 判断公交车是否正在停站
+func (s *TrafficService) dwelling(vehicle *models.Vehicle) bool {
+	trip, ok := s.transitTrips[vehicle.VehicleID]
+	return ok && trip.Dwelling
+}
+
+// dispatchTransit 按发车间隔发出公交班次，班次进入发车队列
+func (s *TrafficService) dispatchTransit() {
+	now := s.clock.ElapsedSeconds()
+	for i := range s.transitLines {
+		line := &s.transitLines[i]
+		for {
+			departure := line.departureTime(line.Dispatched)
+			if departure > now || (line.LastDeparture > 0 && departure > line.LastDeparture) {
+				break
+			}
+			line.Dispatched++
+
+			s.nextVehicleID++
+			vehicle := models.Vehicle{
+				ID:          s.nextVehicleID,
+				VehicleID:   fmt.Sprintf("%s-%04d", line.ID, line.Dispatched),
+				VehicleType: line.VehicleType,
+				Status:      "normal",
+				RoadID:      line.RoadIDs[0],
+				Route:       append([]uint(nil), line.RoadIDs...),
+			}
+			s.transitTrips[vehicle.VehicleID] = &TransitTrip{
+				VehicleID: vehicle.VehicleID,
+				LineID:    line.ID,
+				Departure: departure,
+			}
+			s.departures = append(s.departures, pendingDeparture{vehicle: vehicle})
+		}
+	}
+}
+
+// updateTransit 处理公交车到站、停站和越站
+func (s *TrafficService) updateTransit(dt float64) {
+	now := s.clock.ElapsedSeconds()
+	for i := range s.vehicles {
+		vehicle := &s.vehicles[i]
+		trip, line, ok := s.transitTripFor(vehicle)
+		if !ok {
+			continue
+		}
+
+		if trip.Dwelling {
+			trip.DwellRemaining -= dt
+			if trip.DwellRemaining <= 0 {
+				trip.Dwelling = false
+				trip.DwellRemaining = 0
+				trip.NextStop++
+			}
+			continue
+		}
+
+		for {
+			distance, ok := s.distanceToStop(vehicle, trip, line)
+			if !ok {
+				break
+			}
+			stop := &line.Stops[trip.NextStop]
+			if distance < -stopArrivalTolerance {
+				s.recordStopEvent(line, trip, stop, now, 0, true)
+				trip.NextStop++
+				continue
+			}
+			if distance <= stopArrivalTolerance && vehicle.Speed < stopArrivalSpeed {
+				// 到站后停在站点处
+				vehicle.Speed = 0
+				vehicle.Acceleration = 0
+				if stop.routeIndex == vehicle.RouteIndex {
+					vehicle.Offset = stop.Position
+					s.syncPosition(vehicle)
+				}
+				dwell := line.Dwell.sample(s.rng)
+				s.recordStopEvent(line, trip, stop, now, dwell, false)
+				trip.Dwelling = true
+				trip.DwellRemaining = dwell
+			}
+			break
+		}
+	}
+}
+
+// finishTransitTrip 班次驶离路网，未停靠的站记为越站
+func (s *TrafficService) finishTransitTrip(vehicleID string) {
+	trip, ok := s.transitTrips[vehicleID]
+	if !ok {
+		return
+	}
+	if line := s.transitLine(trip.LineID); line != nil {
+		for ; trip.NextStop < len(line.Stops); trip.NextStop++ {
+			s.recordStopEvent(line, trip, &line.Stops[trip.NextStop], s.clock.ElapsedSeconds(), 0, true)
+		}
+	}
+	delete(s.transitTrips, vehicleID)
+}
+
+// detachTransitTrips 线路修改或删除后，运行中的班次按原路径行驶完毕但不再停站，尚未发出的班次取消
+func (s *TrafficService) detachTransitTrips(lineID string) {
+	detached := make(map[string]bool)
+	for vehicleID, trip := range s.transitTrips {
+		if trip.LineID == lineID {
+			detached[vehicleID] = true
+			delete(s.transitTrips, vehicleID)
+		}
+	}
+
+	remaining := s.departures[:0]
+	for _, departure := range s.departures {
+		if !detached[departure.vehicle.VehicleID] {
+			remaining = append(remaining, departure)
+		}
+	}
+	s.departures = remaining
+	s.demandStats.Queued = len(s.departures)
+}
+
+// recordStopEvent 记录到站或越站
+func (s *TrafficService) recordStopEvent(line *TransitLine, trip *TransitTrip, stop *TransitStop, now, dwell float64, skipped bool) {
+	scheduled := trip.Departure + stop.ScheduledOffset
+	event := StopEvent{
+		LineID:    line.ID,
+		VehicleID: trip.VehicleID,
+		StopID:    stop.ID,
+		Scheduled: scheduled,
+		Actual:    now,
+		Deviation: now - scheduled,
+		Dwell:     dwell,
+	}
+	switch {
+	case skipped:
+		event.Status = "skipped"
+	case event.Deviation < -earlyTolerance:
+		event.Status = "early"
+	case event.Deviation > lateTolerance:
+		event.Status = "late"
+	default:
+		event.Status = "on_time"
+	}
+
+	s.stopEvents = append(s.stopEvents, event)
+	if len(s.stopEvents) > maxStopEvents {
+		s.stopEvents = append(s.stopEvents[:0], s.stopEvents[len(s.stopEvents)-maxStopEvents:]...)
+	}
+}
+
+// sortedTransitTrips 按车辆ID排序的运行中班次
+func (s *TrafficService) sortedTransitTrips() []TransitTrip {
+	trips := make([]TransitTrip, 0, len(s.transitTrips))
+	for _, trip := range s.transitTrips {
+		trips = append(trips, *trip)
+	}
+	sort.Slice(trips, func(i, j int) bool { return trips[i].VehicleID < trips[j].VehicleID })
+	return trips
+}
+
+// GetTransitLines 获取全部公交线路
+func (s *TrafficService) GetTransitLines() []TransitLine {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]TransitLine{}, s.transitLines...)
+}
+
+// GetTransitLine 获取公交线路
+func (s *TrafficService) GetTransitLine(id string) (TransitLine, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if line := s.transitLine(id); line != nil {
+		return *line, true
+	}
+	return TransitLine{}, false
+}
+
+// CreateTransitLine 新增公交线路，未指定ID时自动生成，已过的班次不再补发
+func (s *TrafficService) CreateTransitLine(line TransitLine) (TransitLine, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if line.ID == "" {
+		for n := len(s.transitLines) + 1; line.ID == "" || s.transitLine(line.ID) != nil; n++ {
+			line.ID = fmt.Sprintf("L%03d", n)
+		}
+	}
+	if s.transitLine(line.ID) != nil {
+		return TransitLine{}, fmt.Errorf("transit line %s already exists", line.ID)
+	}
+	if err := validateTransitLine(&line, s.network, s.profiles); err != nil {
+		return TransitLine{}, err
+	}
+
+	line.Dispatched = 0
+	line.skipMissedDepartures(s.clock.ElapsedSeconds())
+	s.transitLines = append(s.transitLines, line)
+	return line, nil
+}
+
+// UpdateTransitLine 修改公交线路，对之后发出的班次生效
+func (s *TrafficService) UpdateTransitLine(id string, line TransitLine) (TransitLine, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing := s.transitLine(id)
+	if existing == nil {
+		return TransitLine{}, fmt.Errorf("transit line %s not found", id)
+	}
+	line.ID = id
+	if err := validateTransitLine(&line, s.network, s.profiles); err != nil {
+		return TransitLine{}, err
+	}
+
+	line.Dispatched = existing.Dispatched
+	line.skipMissedDepartures(s.clock.ElapsedSeconds())
+	s.detachTransitTrips(id)
+	*existing = line
+	return line, nil
+}
+
+// DeleteTransitLine 删除公交线路，运行中的班次按原路径行驶完毕但不再停站
+func (s *TrafficService) DeleteTransitLine(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.transitLines {
+		if s.transitLines[i].ID == id {
+			s.detachTransitTrips(id)
+			s.transitLines = append(s.transitLines[:i], s.transitLines[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// GetTransitTrips 获取运行中的公交班次，lineID 为空时返回全部
+func (s *TrafficService) GetTransitTrips(lineID string) []TransitTrip {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	trips := make([]TransitTrip, 0, len(s.transitTrips))
+	for _, trip := range s.sortedTransitTrips() {
+		if lineID == "" || trip.LineID == lineID {
+			trips = append(trips, trip)
+		}
+	}
+	return trips
+}
+
+// GetStopEvents 获取最近的到站记录，lineID 为空时返回全部
+func (s *TrafficService) GetStopEvents(lineID string, limit int) []StopEvent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := make([]StopEvent, 0)
+	for i := len(s.stopEvents) - 1; i >= 0 && (limit <= 0 || len(events) < limit); i-- {
+		if lineID == "" || s.stopEvents[i].LineID == lineID {
+			events = append(events, s.stopEvents[i])
+		}
+	}
+	return events
+}
+
+// GetScheduleAdherence 按线路和停靠站统计准点情况，lineID 为空时统计全部线路
+func (s *TrafficService) GetScheduleAdherence(lineID string) []LineAdherence {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]LineAdherence, 0, len(s.transitLines))
+	for _, line := range s.transitLines {
+		if lineID != "" && line.ID != lineID {
+			continue
+		}
+
+		report := LineAdherence{
+			LineID:     line.ID,
+			Name:       line.Name,
+			Dispatched: line.Dispatched,
+			Stops:      make([]StopAdherence, len(line.Stops)),
+		}
+		stopIndex := make(map[string]int, len(line.Stops))
+		for i, stop := range line.Stops {
+			report.Stops[i] = StopAdherence{StopID: stop.ID, StopName: stop.Name}
+			stopIndex[stop.ID] = i
+		}
+		for _, trip := range s.transitTrips {
+			if trip.LineID == line.ID {
+				report.ActiveTrips++
+			}
+		}
+
+		arrivals, onTime := 0, 0
+		for _, event := range s.stopEvents {
+			i, ok := stopIndex[event.StopID]
+			if event.LineID != line.ID || !ok {
+				continue
+			}
+			stop := &report.Stops[i]
+			if event.Status == "skipped" {
+				stop.Skipped++
+				continue
+			}
+			stop.Arrivals++
+			stop.AverageDeviation += event.Deviation
+			stop.MaxEarly = math.Max(stop.MaxEarly, -event.Deviation)
+			stop.MaxLate = math.Max(stop.MaxLate, event.Deviation)
+			switch event.Status {
+			case "early":
+				stop.Early++
+			case "late":
+				stop.Late++
+			default:
+				stop.OnTime++
+			}
+		}
+		for i := range report.Stops {
+			stop := &report.Stops[i]
+			if stop.Arrivals > 0 {
+				stop.AverageDeviation /= float64(stop.Arrivals)
+			}
+			arrivals += stop.Arrivals
+			onTime += stop.OnTime
+		}
+		if arrivals > 0 {
+			report.OnTimeRate = float64(onTime) / float64(arrivals)
+		}
+		result = append(result, report)
+	}
+	return result
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"math"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// twoStopLine 路段1和2上的线路，每条路段一个停靠站，停站时间固定
+func twoStopLine() TransitLine {
+	return TransitLine{
+		ID:      "L1",
+		RoadIDs: []uint{1, 2},
+		Stops:   []TransitStop{{RoadID: 1, Position: 300}, {RoadID: 2, Position: 400}},
+		Headway: 600,
+		Dwell:   DwellTime{Mean: 20},
+	}
+}
+
+// 停站时间按分布抽样并截断到上下限，标准差为0时为固定值
+func TestDwellTimeSample(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	if dwell := (DwellTime{Mean: 20}).sample(rng); dwell != 20 {
+		t.Fatalf("fixed dwell = %v, want 20", dwell)
+	}
+
+	dwell := DwellTime{Mean: 30, StdDev: 20, Min: 10, Max: 40}
+	sum := 0.0
+	for i := 0; i < 1000; i++ {
+		d := dwell.sample(rng)
+		if d < 10 || d > 40 {
+			t.Fatalf("dwell %v outside [10, 40]", d)
+		}
+		sum += d
+	}
+	if mean := sum / 1000; mean < 25 || mean > 32 {
+		t.Fatalf("mean dwell = %.1f", mean)
+	}
+}
+
+// 未给出的停靠站ID和计划到站时间按自由流行驶时间和平均停站时间补全
+func TestValidateTransitLineSchedulesStops(t *testing.T) {
+	s := newNetworkService(t)
+	line := twoStopLine()
+	if err := validateTransitLine(&line, s.network, s.profiles); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if line.VehicleType != "bus" || line.Stops[0].ID != "L1-S01" || line.Stops[1].ID != "L1-S02" {
+		t.Fatalf("defaults not filled: %+v", line)
+	}
+
+	bus := s.profiles["bus"]
+	road1 := freeFlowSpeed(s.network, 1, bus)
+	road2 := freeFlowSpeed(s.network, 2, bus)
+	first := math.Round(300 / road1)
+	second := math.Round(s.network.SegmentLength(1)/road1 + 400/road2 + 20)
+	if line.Stops[0].ScheduledOffset != first || line.Stops[1].ScheduledOffset != second {
+		t.Fatalf("scheduled offsets %v, %v, want %v, %v",
+			line.Stops[0].ScheduledOffset, line.Stops[1].ScheduledOffset, first, second)
+	}
+}
+
+// 非法线路被拒绝
+func TestValidateTransitLineRejectsInvalidLines(t *testing.T) {
+	s := newNetworkService(t)
+	tests := []struct {
+		modify func(*TransitLine)
+		want   string
+	}{
+		{func(l *TransitLine) { l.Headway = 0 }, "headway must be positive"},
+		{func(l *TransitLine) { l.RoadIDs = []uint{2, 1} }, "not connected"},
+		{func(l *TransitLine) { l.Stops[1].RoadID = 3 }, "not on the route"},
+		{func(l *TransitLine) { l.Stops = []TransitStop{{RoadID: 1, Position: 500}, {RoadID: 1, Position: 300}} }, "not on the route"},
+		{func(l *TransitLine) { l.Stops[1].Position = s.network.SegmentLength(2) - 1 }, "before its end"},
+		{func(l *TransitLine) { l.Stops[0].ScheduledOffset, l.Stops[1].ScheduledOffset = 120, 60 }, "earlier than the previous stop"},
+		{func(l *TransitLine) { l.VehicleType = "tram" }, "unknown vehicle type"},
+	}
+	for _, tt := range tests {
+		line := twoStopLine()
+		tt.modify(&line)
+		if err := validateTransitLine(&line, s.network, s.profiles); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("line %+v: err = %v, want %q", line, err, tt.want)
+		}
+	}
+}
+
+// 公交车按时刻表发车，在每个停靠站停车并停留停站时间，准点统计计入到站记录
+func TestTransitTripStopsAtEachStop(t *testing.T) {
+	s := newNetworkService(t)
+	line, err := s.CreateTransitLine(twoStopLine())
+	if err != nil {
+		t.Fatalf("create line: %v", err)
+	}
+
+	dwelled := make(map[string]bool)
+	for step := 0; step < 300; step++ {
+		stepService(t, s, 1)
+		for _, vehicle := range s.vehicles {
+			if !s.dwelling(&vehicle) {
+				continue
+			}
+			trip := s.transitTrips[vehicle.VehicleID]
+			stop := line.Stops[trip.NextStop]
+			if vehicle.Speed != 0 || vehicle.RoadID != stop.RoadID || vehicle.Offset != stop.Position {
+				t.Fatalf("dwelling bus %s on road %d at %.1f m, %.1f km/h, want stopped at %s",
+					vehicle.VehicleID, vehicle.RoadID, vehicle.Offset, vehicle.Speed, stop.ID)
+			}
+			dwelled[stop.ID] = true
+		}
+	}
+	if len(dwelled) != 2 {
+		t.Fatalf("bus dwelled at %v, want both stops", dwelled)
+	}
+
+	events := s.GetStopEvents("L1", 0)
+	if len(events) != 2 {
+		t.Fatalf("stop events = %+v", events)
+	}
+	for _, event := range events {
+		if event.VehicleID != "L1-0001" || event.Dwell != 20 || event.Status != "on_time" {
+			t.Fatalf("event = %+v", event)
+		}
+	}
+	if len(s.GetTransitTrips("")) != 0 {
+		t.Fatal("trip still active after reaching the end of the route")
+	}
+
+	adherence := s.GetScheduleAdherence("L1")
+	if len(adherence) != 1 || adherence[0].Dispatched != 1 || adherence[0].OnTimeRate != 1 {
+		t.Fatalf("adherence = %+v", adherence)
+	}
+	for _, stop := range adherence[0].Stops {
+		if stop.Arrivals != 1 || stop.OnTime != 1 || stop.Skipped != 0 {
+			t.Fatalf("stop adherence = %+v", stop)
+		}
+	}
+}
+
+// 到站偏差超过容差记为早点或晚点，班次结束时未停靠的站记为越站
+func TestStopEventStatus(t *testing.T) {
+	s := newNetworkService(t)
+	line, err := s.CreateTransitLine(twoStopLine())
+	if err != nil {
+		t.Fatalf("create line: %v", err)
+	}
+	trip := &TransitTrip{VehicleID: "L1-0001", LineID: "L1", Departure: 100}
+	stop := &line.Stops[0]
+	scheduled := 100 + stop.ScheduledOffset
+
+	tests := []struct {
+		actual float64
+		want   string
+	}{
+		{scheduled - earlyTolerance - 1, "early"},
+		{scheduled - earlyTolerance, "on_time"},
+		{scheduled + lateTolerance, "on_time"},
+		{scheduled + lateTolerance + 1, "late"},
+	}
+	for _, tt := range tests {
+		s.recordStopEvent(&line, trip, stop, tt.actual, 20, false)
+		if event := s.stopEvents[len(s.stopEvents)-1]; event.Status != tt.want || event.Deviation != tt.actual-scheduled {
+			t.Fatalf("arrival at %v: event = %+v, want %s", tt.actual, event, tt.want)
+		}
+	}
+
+	s.stopEvents = nil
+	s.transitTrips[trip.VehicleID] = trip
+	s.finishTransitTrip(trip.VehicleID)
+	if len(s.stopEvents) != 2 || s.stopEvents[0].Status != "skipped" || s.stopEvents[1].Status != "skipped" {
+		t.Fatalf("unserved stops: events = %+v", s.stopEvents)
+	}
+	if adherence := s.GetScheduleAdherence("L1"); adherence[0].Stops[0].Skipped != 1 || adherence[0].OnTimeRate != 0 {
+		t.Fatalf("adherence = %+v", adherence)
+	}
+}
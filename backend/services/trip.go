@@ -0,0 +1,202 @@
+package services
+
+import (
+	"backend/models"
+	"math"
+	"sort"
+	"time"
+)
+
+// maxTripRecords 保留的行程记录数
+const maxTripRecords = 10000
+
+// 行程结束方式
+const (
+	TripArrived = "arrived" // 到达目的路段
+	TripExited  = "exited"  // 无目的地的车辆驶入断头路或驶出区域
+)
+
+// TripRecord 已完成的行程
+type TripRecord struct {
+	VehicleID     string           `json:"vehicle_id"`
+	VehicleType   string           `json:"vehicle_type"`
+	Origin        uint             `json:"origin"`      // 出发路段
+	Destination   uint             `json:"destination"` // 驶离路网时所在路段
+	DepartureTime time.Time        `json:"departure_time"`
+	ArrivalTime   time.Time        `json:"arrival_time"`
+	TravelTime    float64          `json:"travel_time"`   // 行程时间 (s)
+	Distance      float64          `json:"distance"`      // 行驶距离 (m)
+	AverageSpeed  float64          `json:"average_speed"` // 平均车速 (km/h)
+	Outcome       string           `json:"outcome"`       // arrived 或 exited
+	Emissions     models.Emissions `json:"emissions"`
+}
+
+// TripStats 全部已完成行程的累计统计，不受记录数上限影响
+type TripStats struct {
+	Completed       int     `json:"completed"`
+	Arrived         int     `json:"arrived"`
+	Exited          int     `json:"exited"`
+	TotalTravelTime float64 `json:"total_travel_time"` // s
+	TotalDistance   float64 `json:"total_distance"`    // m
+}
+
+// TripFilter 行程记录筛选条件，零值表示不限
+type TripFilter struct {
+	VehicleType string
+	Origin      uint
+	Destination uint
+}
+
+// matches 判断行程是否满足筛选条件
+func (f TripFilter) matches(trip *TripRecord) bool {
+	return (f.VehicleType == "" || trip.VehicleType == f.VehicleType) &&
+		(f.Origin == 0 || trip.Origin == f.Origin) &&
+		(f.Destination == 0 || trip.Destination == f.Destination)
+}
+
+// TripSummary 行程统计
+// 数量、平均行程时间、平均距离和平均车速按全部已完成行程计算，行程时间分位数按保留的行程记录计算
+type TripSummary struct {
+	Active           int     `json:"active"` // 路网中行驶的车辆数
+	Completed        int     `json:"completed"`
+	Arrived          int     `json:"arrived"`
+	Exited           int     `json:"exited"`
+	MeanTravelTime   float64 `json:"mean_travel_time"`   // s
+	MedianTravelTime float64 `json:"median_travel_time"` // s
+	P95TravelTime    float64 `json:"p95_travel_time"`    // s
+	MaxTravelTime    float64 `json:"max_travel_time"`    // s
+	MeanDistance     float64 `json:"mean_distance"`      // m
+	MeanSpeed        float64 `json:"mean_speed"`         // 总距离与总行程时间之比 (km/h)
+}
+
+// departVehicle 车辆进入路网，开始新的行程
+func (s *TrafficService) departVehicle(vehicle *models.Vehicle) {
+	now := s.clock.Now()
+	vehicle.CreatedAt = now
+	vehicle.DepartureTime = now
+	vehicle.Origin = vehicle.RoadID
+	vehicle.Destination = 0
+	if len(vehicle.Route) > 0 {
+		vehicle.Destination = vehicle.Route[len(vehicle.Route)-1]
+	}
+	vehicle.Distance = 0
+	vehicle.Emissions = models.Emissions{}
+	s.recordFlow(1, 0)
+}
+
+// completeTrip 车辆驶离路网，记录行程，调用方负责将车辆从路网中移除
+func (s *TrafficService) completeTrip(vehicle *models.Vehicle) {
+	if vehicle.Released {
+		s.demandStats.Arrived++
+	}
+	s.recordFlow(0, 1)
+	s.finishTransitTrip(vehicle.VehicleID)
+
+	now := s.clock.Now()
+	trip := TripRecord{
+		VehicleID:     vehicle.VehicleID,
+		VehicleType:   vehicle.VehicleType,
+		Origin:        vehicle.Origin,
+		Destination:   vehicle.RoadID,
+		DepartureTime: vehicle.DepartureTime,
+		ArrivalTime:   now,
+		TravelTime:    now.Sub(vehicle.DepartureTime).Seconds(),
+		Distance:      math.Max(vehicle.Distance, 0),
+		Outcome:       TripExited,
+		Emissions:     vehicle.Emissions,
+	}
+	if vehicle.Destination != 0 {
+		trip.Outcome = TripArrived
+	}
+	if trip.TravelTime > 0 {
+		trip.AverageSpeed = trip.Distance / trip.TravelTime * 3.6
+	}
+
+	stats := &s.tripStats
+	stats.Completed++
+	if trip.Outcome == TripArrived {
+		stats.Arrived++
+	} else {
+		stats.Exited++
+	}
+	stats.TotalTravelTime += trip.TravelTime
+	stats.TotalDistance += trip.Distance
+
+	s.trips = append(s.trips, trip)
+	if len(s.trips) > maxTripRecords {
+		s.trips = append(s.trips[:0], s.trips[len(s.trips)-maxTripRecords:]...)
+	}
+}
+
+// since 自 start 以来的行程统计
+func (stats TripStats) since(start TripStats) TripStats {
+	return TripStats{
+		Completed:       stats.Completed - start.Completed,
+		Arrived:         stats.Arrived - start.Arrived,
+		Exited:          stats.Exited - start.Exited,
+		TotalTravelTime: stats.TotalTravelTime - start.TotalTravelTime,
+		TotalDistance:   stats.TotalDistance - start.TotalDistance,
+	}
+}
+
+// GetTrips 获取最近完成的行程，按到达时间倒序
+func (s *TrafficService) GetTrips(filter TripFilter, limit int) []TripRecord {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	trips := make([]TripRecord, 0)
+	for i := len(s.trips) - 1; i >= 0 && (limit <= 0 || len(trips) < limit); i-- {
+		if filter.matches(&s.trips[i]) {
+			trips = append(trips, s.trips[i])
+		}
+	}
+	return trips
+}
+
+// GetTripSummary 获取行程统计
+func (s *TrafficService) GetTripSummary() TripSummary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	summary := tripSummary(s.tripStats, s.trips)
+	summary.Active = len(s.vehicles)
+	return summary
+}
+
+// tripSummary 按累计统计和行程记录汇总，分位数取自行程记录
+func tripSummary(stats TripStats, trips []TripRecord) TripSummary {
+	summary := TripSummary{
+		Completed: stats.Completed,
+		Arrived:   stats.Arrived,
+		Exited:    stats.Exited,
+	}
+	if stats.Completed > 0 {
+		summary.MeanTravelTime = stats.TotalTravelTime / float64(stats.Completed)
+		summary.MeanDistance = stats.TotalDistance / float64(stats.Completed)
+	}
+	if stats.TotalTravelTime > 0 {
+		summary.MeanSpeed = stats.TotalDistance / stats.TotalTravelTime * 3.6
+	}
+
+	if len(trips) > 0 {
+		times := make([]float64, len(trips))
+		for i := range trips {
+			times[i] = trips[i].TravelTime
+		}
+		sort.Float64s(times)
+		summary.MedianTravelTime = percentile(times, 0.5)
+		summary.P95TravelTime = percentile(times, 0.95)
+		summary.MaxTravelTime = times[len(times)-1]
+	}
+	return summary
+}
+
+// percentile 已排序数据的分位数，相邻值之间线性插值
+func percentile(sorted []float64, p float64) float64 {
+	position := p * float64(len(sorted)-1)
+	lower := int(position)
+	if lower+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lower] + (position-float64(lower))*(sorted[lower+1]-sorted[lower])
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"backend/models"
+	"testing"
+)
+
+// 需求统计的到达数只计经发车队列进入路网的车辆，场景车辆和手动添加车辆只计入行程统计
+func TestDemandArrivalsCountReleasedVehiclesOnly(t *testing.T) {
+	scenario := checkpointScenario()
+	scenario.Demand = DemandConfig{}
+	scenario.Transit = nil
+	scenario.Vehicles = []ScenarioVehicle{{VehicleID: "S1", RoadID: 1, Speed: 40}}
+	s := NewStandaloneTrafficService()
+	if err := s.LoadScenario(scenario); err != nil {
+		t.Fatalf("load scenario: %v", err)
+	}
+	if _, err := s.AddVehicle(models.Vehicle{VehicleID: "M1", RoadID: 3, Speed: 30}); err != nil {
+		t.Fatalf("add vehicle: %v", err)
+	}
+	stepService(t, s, 600)
+	if s.tripStats.Completed == 0 {
+		t.Fatal("no trip completed")
+	}
+	if s.demandStats.Arrived != 0 {
+		t.Fatalf("demand arrived = %d, want 0 without demand", s.demandStats.Arrived)
+	}
+
+	s = newCheckpointService(t)
+	stepService(t, s, 1200)
+	released := 0
+	for _, vehicle := range s.vehicles {
+		if vehicle.Released {
+			released++
+		}
+	}
+	stats := s.demandStats
+	if stats.Arrived == 0 || stats.Spawned-stats.Arrived != released {
+		t.Fatalf("spawned %d - arrived %d != %d released vehicles in the network", stats.Spawned, stats.Arrived, released)
+	}
+}
+
+// 有目的地的车辆到达终点路段后移出路网并记录行程，行驶距离为路径全长；无目的地的车辆驶入断头路后记为 exited
+func TestVehicleTripLifecycle(t *testing.T) {
+	s := newNetworkService(t)
+	departure := s.clock.Now()
+	if _, err := s.AddVehicle(models.Vehicle{VehicleID: "R1", VehicleType: "truck", RoadID: 1, Route: []uint{1, 2}, Speed: 40}); err != nil {
+		t.Fatalf("add vehicle: %v", err)
+	}
+	if _, err := s.AddVehicle(models.Vehicle{VehicleID: "X1", RoadID: 2, Speed: 40}); err != nil {
+		t.Fatalf("add vehicle: %v", err)
+	}
+	if summary := s.GetTripSummary(); summary.Active != 2 || summary.Completed != 0 {
+		t.Fatalf("summary before arrival = %+v", summary)
+	}
+	stepService(t, s, 600)
+
+	if len(s.vehicles) != 0 {
+		t.Fatalf("%d vehicles still in the network", len(s.vehicles))
+	}
+	trips := s.GetTrips(TripFilter{}, 0)
+	if len(trips) != 2 || trips[0].VehicleID != "R1" || trips[1].VehicleID != "X1" {
+		t.Fatalf("trips = %+v, want R1 then X1", trips)
+	}
+	routed, exited := trips[0], trips[1]
+	if routed.Outcome != TripArrived || routed.Origin != 1 || routed.Destination != 2 || routed.VehicleType != "truck" {
+		t.Fatalf("routed trip = %+v", routed)
+	}
+	if want := s.network.SegmentLength(1) + s.network.SegmentLength(2); !near(routed.Distance, want, 1e-6) {
+		t.Fatalf("distance = %.1f, want %.1f", routed.Distance, want)
+	}
+	if !routed.DepartureTime.Equal(departure) || routed.TravelTime != routed.ArrivalTime.Sub(departure).Seconds() ||
+		!near(routed.AverageSpeed, routed.Distance/routed.TravelTime*3.6, 1e-9) {
+		t.Fatalf("routed trip times = %+v", routed)
+	}
+	if exited.Outcome != TripExited || exited.Origin != 2 || exited.Destination != 2 || exited.TravelTime >= routed.TravelTime {
+		t.Fatalf("exited trip = %+v", exited)
+	}
+
+	summary := s.GetTripSummary()
+	if summary.Active != 0 || summary.Completed != 2 || summary.Arrived != 1 || summary.Exited != 1 {
+		t.Fatalf("summary = %+v", summary)
+	}
+	if !near(summary.MeanDistance, (routed.Distance+exited.Distance)/2, 1e-9) || summary.MaxTravelTime != routed.TravelTime {
+		t.Fatalf("summary = %+v", summary)
+	}
+}
+
+// 均值按累计统计计算，分位数和最大值按保留的记录线性插值
+func TestTripSummary(t *testing.T) {
+	trips := make([]TripRecord, 0, 20)
+	for i := 1; i <= 20; i++ {
+		trips = append(trips, TripRecord{TravelTime: float64(i * 10)})
+	}
+	// 累计统计包含已超出记录上限的早期行程
+	stats := TripStats{Completed: 40, Arrived: 30, Exited: 10, TotalTravelTime: 8000, TotalDistance: 40000}
+	summary := tripSummary(stats, trips)
+	want := TripSummary{
+		Completed: 40, Arrived: 30, Exited: 10,
+		MeanTravelTime: 200, MedianTravelTime: 105, P95TravelTime: 190.5, MaxTravelTime: 200,
+		MeanDistance: 1000, MeanSpeed: 18,
+	}
+	if !near(summary.P95TravelTime, want.P95TravelTime, 1e-9) {
+		t.Fatalf("p95 = %v, want %v", summary.P95TravelTime, want.P95TravelTime)
+	}
+	summary.P95TravelTime = want.P95TravelTime
+	if summary != want {
+		t.Fatalf("summary = %+v, want %+v", summary, want)
+	}
+
+	if summary := tripSummary(TripStats{}, nil); summary != (TripSummary{}) {
+		t.Fatalf("empty summary = %+v", summary)
+	}
+}
+
+// 按车型、起终点筛选，按到达时间倒序返回最近的记录
+func TestGetTripsFilter(t *testing.T) {
+	s := newNetworkService(t)
+	s.trips = []TripRecord{
+		{VehicleID: "A", VehicleType: "car", Origin: 1, Destination: 2},
+		{VehicleID: "B", VehicleType: "truck", Origin: 1, Destination: 2},
+		{VehicleID: "C", VehicleType: "car", Origin: 3, Destination: 2},
+		{VehicleID: "D", VehicleType: "car", Origin: 1, Destination: 3},
+	}
+	tests := []struct {
+		filter TripFilter
+		limit  int
+		want   string
+	}{
+		{TripFilter{}, 0, "DCBA"},
+		{TripFilter{}, 2, "DC"},
+		{TripFilter{VehicleType: "car"}, 0, "DCA"},
+		{TripFilter{Origin: 1, Destination: 2}, 0, "BA"},
+		{TripFilter{VehicleType: "bus"}, 0, ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		for _, trip := range s.GetTrips(tt.filter, tt.limit) {
+			got += trip.VehicleID
+		}
+		if got != tt.want {
+			t.Fatalf("filter %+v limit %d: got %q, want %q", tt.filter, tt.limit, got, tt.want)
+		}
+	}
+}
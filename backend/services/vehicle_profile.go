@@ -0,0 +1,233 @@
+package services
+
+import (
+	"backend/algorithms"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// defaultVehicleType 默认车型，未指定车型的车辆按该车型处理，不能删除
+const defaultVehicleType = "car"
+
+// VehicleProfile 车型动力学参数，决定跟驰、换道、最高车速、超速告警和排放
+type VehicleProfile struct {
+	Type string `json:"type"`
+	IDMParams
+	MaxSpeed      float64 `json:"max_speed"`      // 车辆最高车速 (km/h)
+	AlertSpeed    float64 `json:"alert_speed"`    // 超速告警阈值 (km/h)
+	EmissionClass string  `json:"emission_class"` // 排放类别，为空时按小汽车计算
+	LaneRule
+}
+
+// defaultVehicleProfiles 默认车型参数
+func defaultVehicleProfiles() map[string]VehicleProfile {
+	return map[string]VehicleProfile{
+		"car": {
+			Type: "car",
+			IDMParams: IDMParams{
+				DesiredSpeedFactor: 1.0,
+				MaxAcceleration:    1.5,
+				ComfortDecel:       2.0,
+				TimeHeadway:        1.5,
+				MinGap:             2.0,
+				Length:             4.5,
+				Delta:              4,
+			},
+			MaxSpeed:      120,
+			AlertSpeed:    80,
+			EmissionClass: "passenger_car",
+			LaneRule:      LaneRule{MaxLane: -1, KeepRightBias: 0.1, Politeness: 0.2},
+		},
+		// 货车只能使用最外侧两条车道
+		"truck": {
+			Type: "truck",
+			IDMParams: IDMParams{
+				DesiredSpeedFactor: 0.85,
+				MaxAcceleration:    0.7,
+				ComfortDecel:       1.5,
+				TimeHeadway:        2.0,
+				MinGap:             3.0,
+				Length:             12.0,
+				Delta:              4,
+			},
+			MaxSpeed:      90,
+			AlertSpeed:    70,
+			EmissionClass: "heavy_truck",
+			LaneRule:      LaneRule{MaxLane: 1, KeepRightBias: 0.3, Politeness: 0.5},
+		},
+		// 公交车倾向最外侧车道
+		"bus": {
+			Type: "bus",
+			IDMParams: IDMParams{
+				DesiredSpeedFactor: 0.9,
+				MaxAcceleration:    1.0,
+				ComfortDecel:       1.5,
+				TimeHeadway:        1.8,
+				MinGap:             2.5,
+				Length:             12.0,
+				Delta:              4,
+			},
+			MaxSpeed:      80,
+			AlertSpeed:    70,
+			EmissionClass: "diesel_bus",
+			LaneRule:      LaneRule{MaxLane: -1, KeepRightBias: 0.6, Politeness: 0.5},
+		},
+	}
+}
+
+// NewVehicleProfile 创建新车型参数，车道不限，其余参数需调用方填写
+func NewVehicleProfile(vehicleType string) VehicleProfile {
+	return VehicleProfile{Type: vehicleType, LaneRule: LaneRule{MaxLane: -1}}
+}
+
+// validateVehicleProfile 校验车型参数并补全默认值
+func validateVehicleProfile(profile *VehicleProfile) error {
+	if profile.Type == "" {
+		return errors.New("type is required")
+	}
+	if profile.Length <= 0 || profile.MaxAcceleration <= 0 || profile.ComfortDecel <= 0 {
+		return errors.New("length, max_acceleration and comfort_decel must be positive")
+	}
+	if profile.DesiredSpeedFactor <= 0 || profile.MaxSpeed <= 0 || profile.AlertSpeed <= 0 {
+		return errors.New("desired_speed_factor, max_speed and alert_speed must be positive")
+	}
+	if profile.TimeHeadway < 0 || profile.MinGap < 0 || profile.Politeness < 0 {
+		return errors.New("time_headway, min_gap and politeness cannot be negative")
+	}
+	if profile.MaxLane < -1 {
+		return errors.New("max_lane must be -1 (unrestricted) or a lane index")
+	}
+	if profile.Delta <= 0 {
+		profile.Delta = 4
+	}
+	if profile.EmissionClass == "" {
+		profile.EmissionClass = algorithms.DefaultEmissionClass
+	}
+	if _, ok := algorithms.LookupEmissionClass(profile.EmissionClass); !ok {
+		return fmt.Errorf("unknown emission_class %s, expected one of %s", profile.EmissionClass, strings.Join(algorithms.EmissionClassNames(), ", "))
+	}
+	return nil
+}
+
+// profileFor 获取车型参数，未知车型按默认车型处理
+func (s *TrafficService) profileFor(vehicleType string) VehicleProfile {
+	if profile, ok := s.profiles[vehicleType]; ok {
+		return profile
+	}
+	return s.profiles[defaultVehicleType]
+}
+
+// idmParamsFor 获取车型在当前天气下的跟驰参数
+// 车头时距按天气系数放大；静止间距放大使车辆占用的路段长度按通行能力系数折减，车长不变
+func (s *TrafficService) idmParamsFor(vehicleType string) IDMParams {
+	params := s.profileFor(vehicleType).IDMParams
+	effect := s.weatherEffect()
+	params.TimeHeadway *= effect.HeadwayFactor
+	params.MinGap = (params.Length+params.MinGap)/effect.CapacityFactor - params.Length
+	return params
+}
+
+// laneRuleFor 获取车型的车道规则
+func (s *TrafficService) laneRuleFor(vehicleType string) LaneRule {
+	return s.profileFor(vehicleType).LaneRule
+}
+
+// checkVehicleType 校验车型是否已定义，空车型视为默认车型
+func checkVehicleType(profiles map[string]VehicleProfile, vehicleType string) error {
+	if vehicleType == "" {
+		return nil
+	}
+	if _, ok := profiles[vehicleType]; !ok {
+		return fmt.Errorf("unknown vehicle type %s", vehicleType)
+	}
+	return nil
+}
+
+// checkVehicleMix 校验需求车型比例中的车型均已定义
+func checkVehicleMix(config *DemandConfig, profiles map[string]VehicleProfile) error {
+	for _, od := range config.Matrix {
+		for vehicleType := range od.VehicleMix {
+			if err := checkVehicleType(profiles, vehicleType); err != nil {
+				return fmt.Errorf("od %s: %w", od.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// sortedProfiles 按车型名排序的参数列表
+func sortedProfiles(profiles map[string]VehicleProfile) []VehicleProfile {
+	result := make([]VehicleProfile, 0, len(profiles))
+	for _, profile := range profiles {
+		result = append(result, profile)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Type < result[j].Type })
+	return result
+}
+
+// GetVehicleProfiles 获取全部车型参数
+func (s *TrafficService) GetVehicleProfiles() []VehicleProfile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedProfiles(s.profiles)
+}
+
+// GetVehicleProfile 获取车型参数
+func (s *TrafficService) GetVehicleProfile(vehicleType string) (VehicleProfile, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	profile, ok := s.profiles[vehicleType]
+	return profile, ok
+}
+
+// SetVehicleProfile 新增或修改车型参数，运行中修改立即生效
+func (s *TrafficService) SetVehicleProfile(profile VehicleProfile) (VehicleProfile, error) {
+	if err := validateVehicleProfile(&profile); err != nil {
+		return VehicleProfile{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.profiles[profile.Type] = profile
+	return profile, nil
+}
+
+// DeleteVehicleProfile 删除车型参数，默认车型和仍在使用的车型不能删除
+func (s *TrafficService) DeleteVehicleProfile(vehicleType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.profiles[vehicleType]; !ok {
+		return fmt.Errorf("vehicle type %s not found", vehicleType)
+	}
+	if vehicleType == defaultVehicleType {
+		return fmt.Errorf("the default vehicle type %s cannot be deleted", defaultVehicleType)
+	}
+	for _, vehicle := range s.vehicles {
+		if vehicle.VehicleType == vehicleType {
+			return fmt.Errorf("vehicle type %s is used by vehicle %s", vehicleType, vehicle.VehicleID)
+		}
+	}
+	for _, departure := range s.departures {
+		if departure.vehicle.VehicleType == vehicleType {
+			return fmt.Errorf("vehicle type %s is used by queued vehicle %s", vehicleType, departure.vehicle.VehicleID)
+		}
+	}
+	for _, line := range s.transitLines {
+		if line.VehicleType == vehicleType {
+			return fmt.Errorf("vehicle type %s is used by transit line %s", vehicleType, line.ID)
+		}
+	}
+	for _, od := range s.demand.Matrix {
+		if _, ok := od.VehicleMix[vehicleType]; ok {
+			return fmt.Errorf("vehicle type %s is used by demand %s", vehicleType, od.ID)
+		}
+	}
+
+	delete(s.profiles, vehicleType)
+	return nil
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"backend/models"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// 场景中的车型未指定 max_lane 时与新建车型一致，车道不限
+func TestScenarioProfileDefaultsToUnrestrictedLanes(t *testing.T) {
+	data := `{"name":"profiles","vehicle_profiles":[
+		{"type":"van","length":5,"max_acceleration":1.2,"comfort_decel":2,"desired_speed_factor":0.95,"max_speed":110,"alert_speed":90},
+		{"type":"tractor","length":6,"max_acceleration":0.5,"comfort_decel":1.5,"desired_speed_factor":0.5,"max_speed":40,"alert_speed":35,"max_lane":0}
+	]}`
+	var scenario Scenario
+	if err := json.Unmarshal([]byte(data), &scenario); err != nil {
+		t.Fatalf("unmarshal scenario: %v", err)
+	}
+	if scenario.Name != "profiles" || len(scenario.Profiles) != 2 {
+		t.Fatalf("scenario = %+v", scenario)
+	}
+
+	s := NewStandaloneTrafficService()
+	if err := s.LoadScenario(scenario); err != nil {
+		t.Fatalf("load scenario: %v", err)
+	}
+	if van, _ := s.GetVehicleProfile("van"); van.MaxLane != -1 {
+		t.Fatalf("van max_lane = %d, want -1", van.MaxLane)
+	}
+	if tractor, _ := s.GetVehicleProfile("tractor"); tractor.MaxLane != 0 {
+		t.Fatalf("tractor max_lane = %d, want 0", tractor.MaxLane)
+	}
+}
+
+// 发车队列中的车辆仍在使用的车型不能删除
+func TestDeleteVehicleProfileInUseByQueuedVehicle(t *testing.T) {
+	s := NewStandaloneTrafficService()
+	van := NewVehicleProfile("van")
+	van.IDMParams = defaultVehicleProfiles()[defaultVehicleType].IDMParams
+	van.MaxSpeed, van.AlertSpeed = 110, 90
+	if _, err := s.SetVehicleProfile(van); err != nil {
+		t.Fatalf("set profile: %v", err)
+	}
+
+	s.departures = append(s.departures, pendingDeparture{vehicle: models.Vehicle{VehicleID: "D000001", VehicleType: "van"}})
+	err := s.DeleteVehicleProfile("van")
+	if err == nil || !strings.Contains(err.Error(), "D000001") {
+		t.Fatalf("err = %v, want in use by queued vehicle", err)
+	}
+
+	s.departures = nil
+	if err := s.DeleteVehicleProfile("van"); err != nil {
+		t.Fatalf("delete unused profile: %v", err)
+	}
+	if err := s.DeleteVehicleProfile(defaultVehicleType); err == nil {
+		t.Fatal("deleted the default vehicle type")
+	}
+}
+
+// 添加车辆时拒绝未定义的车型，未指定车型时按默认车型
+func TestAddVehicleChecksType(t *testing.T) {
+	s := newNetworkService(t)
+	if _, err := s.AddVehicle(models.Vehicle{VehicleID: "V1", VehicleType: "tank", RoadID: 1}); err == nil {
+		t.Fatal("added a vehicle of unknown type")
+	}
+	vehicle, err := s.AddVehicle(models.Vehicle{VehicleID: "V2", RoadID: 1})
+	if err != nil || vehicle.VehicleType != defaultVehicleType {
+		t.Fatalf("vehicle = %+v, err = %v", vehicle, err)
+	}
+}
+
+// 期望车速取路段限速乘车型系数，且不超过车型最高车速
+func TestDesiredSpeedUsesProfile(t *testing.T) {
+	s := newNetworkService(t)
+	tractor := NewVehicleProfile("tractor")
+	tractor.IDMParams = defaultVehicleProfiles()[defaultVehicleType].IDMParams
+	tractor.MaxSpeed, tractor.AlertSpeed = 30, 35
+	if _, err := s.SetVehicleProfile(tractor); err != nil {
+		t.Fatalf("set profile: %v", err)
+	}
+
+	tests := []struct {
+		vehicleType string
+		want        float64 // km/h
+	}{
+		{"car", 60},
+		{"truck", 60 * 0.85},
+		{"tractor", 30},
+	}
+	for _, tt := range tests {
+		vehicle := models.Vehicle{VehicleType: tt.vehicleType, RoadID: 1}
+		if got := s.desiredSpeed(&vehicle) * 3.6; !near(got, tt.want, 1e-9) {
+			t.Fatalf("%s: desired speed = %.2f km/h, want %.2f", tt.vehicleType, got, tt.want)
+		}
+	}
+}
+
+// 超速告警阈值取车型参数，同一车辆未解决的告警不重复生成
+func TestOverspeedAlertUsesProfileThreshold(t *testing.T) {
+	s := newNetworkService(t)
+	s.vehicles = []models.Vehicle{
+		{VehicleID: "C1", VehicleType: "car", Speed: 75},
+		{VehicleID: "T1", VehicleType: "truck", Speed: 75},
+	}
+	s.generateAlerts()
+	s.generateAlerts()
+
+	if len(s.alerts) != 1 || s.alerts[0].VehicleID != "T1" || s.alerts[0].AlertType != "overspeed" {
+		t.Fatalf("alerts = %+v, want one overspeed alert for T1", s.alerts)
+	}
+}
+
+// 非法车型参数被拒绝
+func TestSetVehicleProfileValidates(t *testing.T) {
+	s := NewStandaloneTrafficService()
+	valid := NewVehicleProfile("van")
+	valid.IDMParams = defaultVehicleProfiles()[defaultVehicleType].IDMParams
+	valid.MaxSpeed, valid.AlertSpeed = 110, 90
+	valid.Delta = 0
+
+	tests := []struct {
+		modify func(*VehicleProfile)
+		want   string
+	}{
+		{func(p *VehicleProfile) { p.Type = "" }, "type is required"},
+		{func(p *VehicleProfile) { p.Length = 0 }, "must be positive"},
+		{func(p *VehicleProfile) { p.AlertSpeed = 0 }, "must be positive"},
+		{func(p *VehicleProfile) { p.MinGap = -1 }, "cannot be negative"},
+		{func(p *VehicleProfile) { p.MaxLane = -2 }, "max_lane"},
+		{func(p *VehicleProfile) { p.EmissionClass = "rocket" }, "unknown emission_class"},
+	}
+	for _, tt := range tests {
+		profile := valid
+		tt.modify(&profile)
+		if _, err := s.SetVehicleProfile(profile); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("profile %+v: err = %v, want %q", profile, err, tt.want)
+		}
+	}
+
+	profile, err := s.SetVehicleProfile(valid)
+	if err != nil || profile.EmissionClass == "" || profile.Delta != 4 {
+		t.Fatalf("profile = %+v, err = %v, want defaults filled", profile, err)
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"backend/models"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// WeatherChange 天气变化，从指定时刻起保持到下一次变化
+type WeatherChange struct {
+	At        float64                 `json:"at"` // 相对模拟开始的时刻 (s)
+	Condition models.WeatherCondition `json:"condition"`
+}
+
+// WeatherState 当前天气及其影响和计划
+type WeatherState struct {
+	Condition models.WeatherCondition `json:"condition"`
+	Effect    models.WeatherEffect    `json:"effect"`
+	Schedule  []WeatherChange         `json:"schedule"`
+}
+
+// validateWeatherSchedule 校验天气计划并按时刻排序，首次变化之前为晴天
+func validateWeatherSchedule(schedule []WeatherChange) error {
+	for _, change := range schedule {
+		if !change.Condition.IsValid() {
+			return fmt.Errorf("unknown weather %q, expected clear, rain, heavy_rain, fog or snow", change.Condition)
+		}
+		if change.At < 0 {
+			return errors.New("weather change time cannot be negative")
+		}
+	}
+
+	sort.SliceStable(schedule, func(i, j int) bool { return schedule[i].At < schedule[j].At })
+	for i := 1; i < len(schedule); i++ {
+		if schedule[i].At == schedule[i-1].At {
+			return fmt.Errorf("duplicate weather change at %.0fs", schedule[i].At)
+		}
+	}
+	return nil
+}
+
+// updateWeather 按模拟时间更新当前天气
+func (s *TrafficService) updateWeather() {
+	now := s.clock.ElapsedSeconds()
+	s.weather = models.WeatherClear
+	for _, change := range s.weatherSchedule {
+		if change.At > now {
+			break
+		}
+		s.weather = change.Condition
+	}
+}
+
+// weatherEffect 当前天气的影响系数
+func (s *TrafficService) weatherEffect() models.WeatherEffect {
+	return s.weather.Effect()
+}
+
+// weatherState 当前天气状态
+func (s *TrafficService) weatherState() WeatherState {
+	return WeatherState{
+		Condition: s.weather,
+		Effect:    s.weatherEffect(),
+		Schedule:  append([]WeatherChange{}, s.weatherSchedule...),
+	}
+}
+
+// GetWeather 获取当前天气和天气计划
+func (s *TrafficService) GetWeather() WeatherState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.weatherState()
+}
+
+// SetWeatherSchedule 替换天气计划，立即按当前模拟时间生效
+func (s *TrafficService) SetWeatherSchedule(schedule []WeatherChange) (WeatherState, error) {
+	schedule = append([]WeatherChange(nil), schedule...)
+	if err := validateWeatherSchedule(schedule); err != nil {
+		return WeatherState{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.weatherSchedule = schedule
+	s.updateWeather()
+	return s.weatherState(), nil
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"backend/models"
+	"testing"
+)
+
+// 天气计划按时刻排序并在到达时刻的步长生效，首次变化之前为晴天，模拟状态中可见
+func TestWeatherScheduleFollowsSimulationTime(t *testing.T) {
+	s := newNetworkService(t)
+	state, err := s.SetWeatherSchedule([]WeatherChange{
+		{At: 300, Condition: models.WeatherRain},
+		{At: 120, Condition: models.WeatherFog},
+	})
+	if err != nil {
+		t.Fatalf("set schedule: %v", err)
+	}
+	if state.Condition != models.WeatherClear || state.Schedule[0].At != 120 || state.Schedule[1].At != 300 {
+		t.Fatalf("state = %+v", state)
+	}
+
+	tests := []struct {
+		steps int
+		want  models.WeatherCondition
+	}{
+		{120, models.WeatherClear},
+		{1, models.WeatherFog},
+		{180, models.WeatherRain},
+	}
+	for _, tt := range tests {
+		stepService(t, s, tt.steps)
+		if got := s.GetWeather().Condition; got != tt.want {
+			t.Fatalf("at %.0fs: weather = %s, want %s", s.clock.ElapsedSeconds(), got, tt.want)
+		}
+		if status := s.GetSimulationStatus(); status["weather"] != tt.want {
+			t.Fatalf("at %.0fs: status weather = %v, want %s", s.clock.ElapsedSeconds(), status["weather"], tt.want)
+		}
+	}
+}
+
+// 天气按系数降低期望速度、放大车头时距，并按通行能力系数放大车辆占用的长度
+func TestWeatherScalesDrivingParameters(t *testing.T) {
+	s := newNetworkService(t)
+	vehicle := models.Vehicle{VehicleType: defaultVehicleType, RoadID: 1}
+	clearSpeed := s.desiredSpeed(&vehicle)
+	clearParams := s.idmParamsFor(defaultVehicleType)
+
+	if _, err := s.SetWeatherSchedule([]WeatherChange{{At: 0, Condition: models.WeatherSnow}}); err != nil {
+		t.Fatalf("set schedule: %v", err)
+	}
+	effect := models.WeatherSnow.Effect()
+	if got := s.desiredSpeed(&vehicle); !near(got, clearSpeed*effect.SpeedFactor, 1e-9) {
+		t.Fatalf("snow desired speed = %.2f, want %.2f", got, clearSpeed*effect.SpeedFactor)
+	}
+	params := s.idmParamsFor(defaultVehicleType)
+	if !near(params.TimeHeadway, clearParams.TimeHeadway*effect.HeadwayFactor, 1e-9) {
+		t.Fatalf("snow headway = %.2f", params.TimeHeadway)
+	}
+	if params.Length != clearParams.Length ||
+		!near(params.Length+params.MinGap, (clearParams.Length+clearParams.MinGap)/effect.CapacityFactor, 1e-9) {
+		t.Fatalf("snow length %.2f, min gap %.2f", params.Length, params.MinGap)
+	}
+}
+
+// 同一场景下雪天的平均车速低于晴天
+func TestSnowSlowsTraffic(t *testing.T) {
+	clearRun := newCheckpointService(t)
+	scenario := checkpointScenario()
+	scenario.Weather = []WeatherChange{{At: 0, Condition: models.WeatherSnow}}
+	snowRun := NewStandaloneTrafficService()
+	if err := snowRun.LoadScenario(scenario); err != nil {
+		t.Fatalf("load scenario: %v", err)
+	}
+
+	meanSpeed := func(s *TrafficService) float64 {
+		stepService(t, s, 300)
+		total := 0.0
+		for _, vehicle := range s.vehicles {
+			total += vehicle.Speed
+		}
+		return total / float64(len(s.vehicles))
+	}
+	if clearSpeed, snowSpeed := meanSpeed(clearRun), meanSpeed(snowRun); snowSpeed >= clearSpeed*0.9 {
+		t.Fatalf("mean speed %.1f km/h in snow, %.1f km/h in clear weather", snowSpeed, clearSpeed)
+	}
+}
+
+// 非法天气计划被拒绝，不改变当前计划
+func TestValidateWeatherSchedule(t *testing.T) {
+	s := newNetworkService(t)
+	for _, schedule := range [][]WeatherChange{
+		{{At: 0, Condition: "hail"}},
+		{{At: -1, Condition: models.WeatherRain}},
+		{{At: 60, Condition: models.WeatherRain}, {At: 60, Condition: models.WeatherFog}},
+	} {
+		if _, err := s.SetWeatherSchedule(schedule); err == nil {
+			t.Fatalf("accepted schedule %+v", schedule)
+		}
+	}
+	if len(s.GetWeather().Schedule) != 0 {
+		t.Fatal("invalid schedule was stored")
+	}
+}
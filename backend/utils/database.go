@@ -28,7 +28,7 @@ func InitDatabase() error {
 	}
 
 	// 注册模型
-	orm.RegisterModel(new(models.RoadSegment), new(models.GPSData), new(models.TrafficAlert), new(models.Vehicle))
+	orm.RegisterModel(new(models.RoadSegment), new(models.GPSData), new(models.TrafficAlert), new(models.Vehicle), new(models.SimulationCheckpoint))
 
 	// 自动建表（开发环境）
 	runMode, _ := beego.AppConfig.String("runmode")
@@ -20,6 +20,7 @@ func CreateTables() error {
 	end_lat DECIMAL(10,6) NOT NULL,
 	max_speed INT DEFAULT 60,
 	capacity INT DEFAULT 1000,
+	lanes INT DEFAULT 1,
 	length DECIMAL(8,2),
 	road_type VARCHAR(10) DEFAULT 'urban',
 	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
@@ -34,6 +35,12 @@ func CreateTables() error {
 		return err
 	}
 
+	// 早期创建的路段表没有车道数字段
+	if err := addColumnIfMissing(o, "road_segments", "lanes", "INT DEFAULT 1 AFTER capacity"); err != nil {
+		logs.Error("路段表添加车道数字段失败: ", err)
+		return err
+	}
+
 	// 创建GPS数据表
 	_, err = o.Raw(`
 	CREATE TABLE IF NOT EXISTS gps_data (
@@ -84,16 +91,51 @@ func CreateTables() error {
 		return err
 	}
 
+	// 创建模拟检查点表
+	_, err = o.Raw(`
+	CREATE TABLE IF NOT EXISTS simulation_checkpoints (
+	id INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	sim_time DATETIME NOT NULL,
+	data LONGTEXT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE KEY uk_name (name)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4
+	`).Exec()
+
+	if err != nil {
+		logs.Error("创建模拟检查点表失败: ", err)
+		return err
+	}
+
 	logs.Info("数据库表创建成功")
 	return nil
 }
 
+// addColumnIfMissing 字段不存在时添加，重复执行不报错
+func addColumnIfMissing(o orm.Ormer, table, column, definition string) error {
+	var count int
+	err := o.Raw(`
+	SELECT COUNT(*) FROM information_schema.COLUMNS
+	WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?
+	`, table, column).QueryRow(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	_, err = o.Raw("ALTER TABLE " + table + " ADD COLUMN " + column + " " + definition).Exec()
+	return err
+}
+
 // DropTables 删除数据库表
 func DropTables() error {
 	o := orm.NewOrm()
 
 	// 删除表（注意外键约束顺序）
-	tables := []string{"traffic_alerts", "gps_data", "road_segments"}
+	tables := []string{"simulation_checkpoints", "traffic_alerts", "gps_data", "road_segments"}
 
 	for _, table := range tables {
 		_, err := o.Raw("DROP TABLE IF EXISTS " + table).Exec()